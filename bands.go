@@ -0,0 +1,71 @@
+package minhashlsh
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+)
+
+// errPartialIndex is returned when saving a partially loaded index.
+var errPartialIndex = errors.New("minhashlsh: a partially loaded index cannot be saved")
+
+// WithBands makes Load decode only the hash tables of the given bands, such
+// as for replicas short of memory, trading recall for memory: a similar
+// key is only found if it collides with the query in one of these bands.
+// The sections of the other bands are skipped without being decompressed,
+// and lazily loaded indexes do not read them at all. Queries still take the
+// full signatures. A partially loaded index can be queried and added to,
+// but not saved.
+func WithBands(bands ...int) PersistOption {
+	return func(c *persistConfig) {
+		c.bands = append(make([]int, 0, len(bands)), bands...)
+	}
+}
+
+// Bands returns the bands of signatures indexed, in order: all of them,
+// unless the index was loaded partially by WithBands.
+func (f *MinhashLSH) Bands() []int {
+	if f.bands != nil {
+		return append([]int(nil), f.bands...)
+	}
+	bands := make([]int, f.L)
+	for i := range bands {
+		bands[i] = i
+	}
+	return bands
+}
+
+// selectBands restricts a decoded header to the given bands, returning
+// the position of each band in the hash tables, or nil if all are loaded.
+func (f *MinhashLSH) selectBands(bands []int) (map[int]int, error) {
+	if bands == nil {
+		return nil, nil
+	}
+	sorted := append([]int(nil), bands...)
+	sort.Ints(sorted)
+	positions := make(map[int]int, len(sorted))
+	for i, b := range sorted {
+		if b < 0 || b >= f.L {
+			return nil, fmt.Errorf("minhashlsh: band %d out of range, the index has %d bands", b, f.L)
+		}
+		if i > 0 && b == sorted[i-1] {
+			return nil, fmt.Errorf("minhashlsh: band %d selected twice", b)
+		}
+		positions[b] = i
+	}
+	if len(sorted) == 0 {
+		return nil, errors.New("minhashlsh: no bands selected")
+	}
+	f.bands = sorted
+	f.L = len(sorted)
+	f.HashTables = make([]hashTable, f.L)
+	return positions, nil
+}
+
+// skipSection reads the next section of r without decoding it.
+func skipSection(r io.Reader) error {
+	_, err := io.Copy(ioutil.Discard, &frameReader{r: r})
+	return err
+}
@@ -0,0 +1,58 @@
+package minhashlsh
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func Test_LoadBands(t *testing.T) {
+	f := testIndex(200)
+	filename, cleanup := tempFilename(t)
+	defer cleanup()
+	if err := f.Save(filename); err != nil {
+		t.Fatal(err)
+	}
+	bands := []int{f.L - 1, 1}
+	for _, opts := range [][]PersistOption{nil, {WithLazyLoading()}, {WithParallelism(4)}} {
+		g, err := Load(filename, append(opts, WithBands(bands...))...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if g.L != 2 || !reflect.DeepEqual(g.Bands(), []int{1, f.L - 1}) {
+			t.Fatalf("unexpected bands %v", g.Bands())
+		}
+		if g.SignatureSize() != f.SignatureSize() {
+			t.Errorf("expected signatures of %d values, got %d", f.SignatureSize(), g.SignatureSize())
+		}
+		if err := g.Materialize(); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(g.HashTables[0], f.HashTables[1]) || !reflect.DeepEqual(g.HashTables[1], f.HashTables[f.L-1]) {
+			t.Fatal("loaded hash tables differ from the selected bands")
+		}
+		// Only the last band of the query matches key 7.
+		sig := randomSignature(64, 7)
+		query := append(randomSignature(64, 1000)[:f.SignatureSize()-f.bandSize()], f.band(sig, f.L-1)...)
+		if results := g.Query(query); !reflect.DeepEqual(results, []interface{}{"7"}) {
+			t.Errorf("expected key 7, got %v", results)
+		}
+		g.Add("new", sig)
+		g.Index()
+		if !contains(g.Query(sig), "new") {
+			t.Error("key added to the partial index not found")
+		}
+		if err := g.Encode(&bytes.Buffer{}); err != errPartialIndex {
+			t.Errorf("expected errPartialIndex, got %v", err)
+		}
+	}
+
+	for _, bands := range [][]int{{}, {-1}, {f.L}, {2, 2}} {
+		if _, err := Load(filename, WithBands(bands...)); err == nil {
+			t.Errorf("bands %v: expected an error", bands)
+		}
+	}
+	if all, _ := Load(filename); !reflect.DeepEqual(all.Bands()[:3], []int{0, 1, 2}) || len(all.Bands()) != f.L {
+		t.Errorf("unexpected bands of a fully loaded index %v", all.Bands())
+	}
+}
@@ -0,0 +1,49 @@
+package minhashlsh
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// SketchBatch computes the Minhash signatures of many documents, each a set
+// of tokens, concurrently using the given number of workers, GOMAXPROCS if
+// not positive. The signatures are returned in the order of the documents;
+// the memory used besides them is one Minhash per worker.
+func SketchBatch(seed int64, numHash int, docs [][][]byte, workers int, opts ...MinhashOption) [][]uint64 {
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	if workers > len(docs) {
+		workers = len(docs)
+	}
+	sigs := make([][]uint64, len(docs))
+	next := int64(-1)
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go func() {
+			defer wg.Done()
+			mh := NewMinhash(seed, numHash, opts...)
+			for {
+				i := int(atomic.AddInt64(&next, 1))
+				if i >= len(docs) {
+					return
+				}
+				mh.Reset()
+				for _, token := range docs[i] {
+					mh.Push(token)
+				}
+				sigs[i] = mh.Signature()
+			}
+		}()
+	}
+	wg.Wait()
+	return sigs
+}
+
+// SketchBatch computes the signatures of many sets of tokens concurrently,
+// see SketchBatch.
+func (s *SetIndex) SketchBatch(docs [][][]byte, workers int) [][]uint64 {
+	return SketchBatch(s.seed, s.numHash, docs, workers)
+}
@@ -0,0 +1,38 @@
+package minhashlsh
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_SketchBatch(t *testing.T) {
+	docs := make([][][]byte, 100)
+	for i := range docs {
+		docs[i] = randomTokens(20, int64(i))
+	}
+	s := NewSetIndex(1, 64, 0.5, 0)
+	for _, workers := range []int{0, 1, 7} {
+		sigs := s.SketchBatch(docs, workers)
+		if len(sigs) != len(docs) {
+			t.Fatalf("expected %d signatures, got %d", len(docs), len(sigs))
+		}
+		for i := range docs {
+			if !reflect.DeepEqual(sigs[i], s.Signature(docs[i])) {
+				t.Fatalf("%d workers: signature %d differs", workers, i)
+			}
+		}
+	}
+	sigs := SketchBatch(1, 64, docs, 3, WithTabulationHashing())
+	for i := range docs {
+		mh := NewMinhash(1, 64, WithTabulationHashing())
+		for _, token := range docs[i] {
+			mh.Push(token)
+		}
+		if !reflect.DeepEqual(sigs[i], mh.Signature()) {
+			t.Fatalf("tabulation hashing: signature %d differs", i)
+		}
+	}
+	if sigs := SketchBatch(1, 64, nil, 4); len(sigs) != 0 {
+		t.Errorf("expected no signatures, got %d", len(sigs))
+	}
+}
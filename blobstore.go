@@ -0,0 +1,130 @@
+package minhashlsh
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// BlobStore is an object storage bucket, such as S3 or GCS,
+// that indexes can be saved to and loaded from.
+type BlobStore interface {
+	// Create returns a writer that uploads the object with the given name.
+	Create(name string) (BlobWriter, error)
+	// Open returns a reader of the object with the given name.
+	Open(name string) (io.ReadCloser, error)
+}
+
+// BlobWriter streams an object to a BlobStore.
+// The object only becomes visible once Close returns nil,
+// Abort discards everything written so far.
+type BlobWriter interface {
+	io.WriteCloser
+	Abort(err error)
+}
+
+// SaveTo saves the index as the named object in the blob store.
+// The index is streamed, so stores supporting multipart uploads
+// never need to hold the whole index in memory.
+func (minhashLsh *MinhashLSH) SaveTo(store BlobStore, name string, opts ...PersistOption) error {
+	w, err := store.Create(name)
+	if err != nil {
+		return err
+	}
+	if err := minhashLsh.write(w, newPersistConfig(opts)); err != nil {
+		w.Abort(err)
+		return err
+	}
+	return w.Close()
+}
+
+// LoadFrom loads the index saved as the named object in the blob store.
+func LoadFrom(store BlobStore, name string, opts ...PersistOption) (*MinhashLSH, error) {
+	r, err := store.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return read(r, newPersistConfig(opts))
+}
+
+// DirStore is a BlobStore keeping objects as files in a local directory.
+// Objects are written to a temporary file and renamed on Close,
+// so readers never observe a partially written index.
+type DirStore string
+
+// Create creates a temporary file that is renamed to name on Close.
+func (d DirStore) Create(name string) (BlobWriter, error) {
+	fi, err := ioutil.TempFile(string(d), "."+filepath.Base(name)+".tmp")
+	if err != nil {
+		return nil, err
+	}
+	return &dirWriter{fi, filepath.Join(string(d), name)}, nil
+}
+
+// Open opens the file of the named object.
+func (d DirStore) Open(name string) (io.ReadCloser, error) {
+	return os.Open(filepath.Join(string(d), name))
+}
+
+type dirWriter struct {
+	*os.File
+	name string
+}
+
+func (w *dirWriter) Close() error {
+	if err := w.File.Close(); err != nil {
+		os.Remove(w.File.Name())
+		return err
+	}
+	return os.Rename(w.File.Name(), w.name)
+}
+
+func (w *dirWriter) Abort(err error) {
+	w.File.Close()
+	os.Remove(w.File.Name())
+}
+
+// StreamStore adapts upload and download functions of an object storage
+// client to a BlobStore. Upload is called with a reader streaming the index
+// as it is being saved, which suits multipart uploaders such as the one in
+// the AWS SDK's s3manager package. If saving fails, the reader returns the
+// error so that Upload can abort the upload.
+type StreamStore struct {
+	Upload   func(name string, r io.Reader) error
+	Download func(name string) (io.ReadCloser, error)
+}
+
+// Create starts the upload of the named object.
+func (s StreamStore) Create(name string) (BlobWriter, error) {
+	pr, pw := io.Pipe()
+	done := make(chan error, 1)
+	go func() {
+		err := s.Upload(name, pr)
+		// Unblock the writer if Upload returns before reading everything.
+		pr.CloseWithError(err)
+		done <- err
+	}()
+	return &streamWriter{pw, done}, nil
+}
+
+// Open starts the download of the named object.
+func (s StreamStore) Open(name string) (io.ReadCloser, error) {
+	return s.Download(name)
+}
+
+type streamWriter struct {
+	*io.PipeWriter
+	done chan error
+}
+
+func (w *streamWriter) Close() error {
+	w.PipeWriter.Close()
+	return <-w.done
+}
+
+func (w *streamWriter) Abort(err error) {
+	w.PipeWriter.CloseWithError(err)
+	<-w.done
+}
@@ -0,0 +1,68 @@
+package minhashlsh
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func Test_DirStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "minhashlsh")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	f := testIndex(100)
+	if err := f.SaveTo(DirStore(dir), "index"); err != nil {
+		t.Fatal(err)
+	}
+	g, err := LoadFrom(DirStore(dir), "index")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.NumIndexedKeys != f.NumIndexedKeys {
+		t.Fatal("loaded index has different number of keys")
+	}
+}
+
+func Test_StreamStore(t *testing.T) {
+	objects := make(map[string][]byte)
+	store := StreamStore{
+		Upload: func(name string, r io.Reader) error {
+			data, err := ioutil.ReadAll(r)
+			if err != nil {
+				return err
+			}
+			objects[name] = data
+			return nil
+		},
+		Download: func(name string) (io.ReadCloser, error) {
+			data, ok := objects[name]
+			if !ok {
+				return nil, errors.New("not found")
+			}
+			return ioutil.NopCloser(bytes.NewReader(data)), nil
+		},
+	}
+	f := testIndex(100)
+	if err := f.SaveTo(store, "index"); err != nil {
+		t.Fatal(err)
+	}
+	g, err := LoadFrom(store, "index")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.NumIndexedKeys != f.NumIndexedKeys {
+		t.Fatal("loaded index has different number of keys")
+	}
+	// A failed save must not produce an object.
+	if err := f.SaveTo(store, "bad", WithEncryptionKey([]byte("short"))); err == nil {
+		t.Fatal("expected an error saving with an invalid key")
+	}
+	if _, ok := objects["bad"]; ok {
+		t.Fatal("aborted upload produced an object")
+	}
+}
@@ -0,0 +1,67 @@
+package minhashlsh
+
+// BooleanQuery composes similarity queries, such as "similar to A and
+// similar to B but not similar to C", built with Similar, And and Not.
+type BooleanQuery struct {
+	f    *MinhashLSH
+	sigs [][]uint64
+	not  []bool
+}
+
+// Similar starts a boolean query for the candidate keys of sig.
+func (f *MinhashLSH) Similar(sig []uint64) *BooleanQuery {
+	return &BooleanQuery{f: f, sigs: [][]uint64{sig}, not: []bool{false}}
+}
+
+// And keeps only the keys that are also candidates of sig.
+func (q *BooleanQuery) And(sig []uint64) *BooleanQuery {
+	q.sigs = append(q.sigs, sig)
+	q.not = append(q.not, false)
+	return q
+}
+
+// Not drops the keys that are candidates of sig.
+func (q *BooleanQuery) Not(sig []uint64) *BooleanQuery {
+	q.sigs = append(q.sigs, sig)
+	q.not = append(q.not, true)
+	return q
+}
+
+// Run returns the keys matching the query. Only the candidates of the first
+// signature are collected, the other signatures filter them, their buckets
+// being scanned until every remaining key is matched, and no more signatures
+// are evaluated once no key remains. The keys left are kept by the verifier
+// of the index, if any, for the first signature.
+func (q *BooleanQuery) Run() []interface{} {
+	keys := q.f.query(q.sigs[0])
+	for i := 1; i < len(q.sigs) && keys.len() > 0; i++ {
+		matched := q.f.match(q.sigs[i], keys)
+		kept := &candidateSet{keys: make([]interface{}, 0)}
+		for _, key := range keys.keys {
+			if matched.has(key) != q.not[i] {
+				kept.add(key)
+			}
+		}
+		keys = kept
+	}
+	if q.f.verifier != nil {
+		return verify(q.f.verifier, q.sigs[0], keys.keys)
+	}
+	return keys.keys
+}
+
+// match returns the keys of the given set that are candidates of sig.
+func (f *MinhashLSH) match(sig []uint64, keys *candidateSet) *candidateSet {
+	matched := &candidateSet{}
+	for i, hashKey := range f.hashKeys(sig) {
+		for _, e := range f.bucket(i, hashKey) {
+			if keys.has(e.Key) {
+				matched.add(e.Key)
+			}
+		}
+		if matched.len() == keys.len() {
+			break
+		}
+	}
+	return matched
+}
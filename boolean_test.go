@@ -0,0 +1,37 @@
+package minhashlsh
+
+import "testing"
+
+func Test_BooleanQuery(t *testing.T) {
+	f := NewMinhashLSH16(64, 0.5, 0)
+	a, b, c := randomSignature(64, 1), randomSignature(64, 2), randomSignature(64, 3)
+	f.Add("a", a)
+	f.Add("ab", a)
+	f.Add("ab", b)
+	f.Add("ac", a)
+	f.Add("ac", c)
+	f.Index()
+
+	tests := []struct {
+		query    *BooleanQuery
+		expected []interface{}
+	}{
+		{f.Similar(a), []interface{}{"a", "ab", "ac"}},
+		{f.Similar(a).And(b), []interface{}{"ab"}},
+		{f.Similar(a).Not(c), []interface{}{"a", "ab"}},
+		{f.Similar(a).And(b).Not(b), nil},
+		{f.Similar(b).And(c), nil},
+	}
+	for i, test := range tests {
+		results := test.query.Run()
+		if len(results) != len(test.expected) {
+			t.Errorf("query %d: expected %v, got %v", i, test.expected, results)
+			continue
+		}
+		for _, key := range test.expected {
+			if !contains(results, key) {
+				t.Errorf("query %d: expected %v, got %v", i, test.expected, results)
+			}
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package minhashlsh
+
+import (
+	"container/list"
+	"strings"
+	"sync"
+)
+
+// queryCache is an LRU cache of query results keyed by the band hash keys
+// of the query.
+type queryCache struct {
+	mu      sync.Mutex
+	size    int
+	order   *list.List
+	items   map[string]*list.Element
+	hits    uint64
+	misses  uint64
+	version uint64
+}
+
+type cachedResult struct {
+	hashKeys string
+	keys     []interface{}
+}
+
+// WithQueryCache caches the results of the last size distinct queries of
+// Query, keyed by the band hash keys of the query signature, so repeated
+// queries and queries differing only in truncated bits skip the table
+// lookups. The cache is cleared by Index and by the methods hiding keys,
+// such as Remove and SoftDelete.
+func WithQueryCache(size int) Option {
+	return func(f *MinhashLSH) {
+		f.cache = newQueryCache(size)
+	}
+}
+
+func newQueryCache(size int) *queryCache {
+	return &queryCache{
+		size:  size,
+		order: list.New(),
+		items: make(map[string]*list.Element),
+	}
+}
+
+// CacheStats returns the number of queries answered from the query cache
+// and the number of queries that were not.
+func (f *MinhashLSH) CacheStats() (hits, misses uint64) {
+	if f.cache == nil {
+		return 0, 0
+	}
+	f.cache.mu.Lock()
+	defer f.cache.mu.Unlock()
+	return f.cache.hits, f.cache.misses
+}
+
+// invalidateCache clears the query cache and the negative cache, if any.
+func (f *MinhashLSH) invalidateCache() {
+	if f.negCache != nil {
+		f.negCache.clear()
+	}
+	if f.cache == nil {
+		return
+	}
+	f.cache.mu.Lock()
+	defer f.cache.mu.Unlock()
+	f.cache.order.Init()
+	f.cache.items = make(map[string]*list.Element)
+	f.cache.version++
+}
+
+// get returns a copy of the cached results of the given hash keys.
+func (c *queryCache) get(hashKeys string) ([]interface{}, uint64, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	elem, exist := c.items[hashKeys]
+	if !exist {
+		c.misses++
+		return nil, c.version, false
+	}
+	c.hits++
+	c.order.MoveToFront(elem)
+	return append([]interface{}(nil), elem.Value.(*cachedResult).keys...), c.version, true
+}
+
+// put caches the results of the given hash keys unless the cache was
+// cleared since version.
+func (c *queryCache) put(hashKeys string, keys []interface{}, version uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.version != version || c.size <= 0 {
+		return
+	}
+	if elem, exist := c.items[hashKeys]; exist {
+		c.order.MoveToFront(elem)
+		return
+	}
+	c.items[hashKeys] = c.order.PushFront(&cachedResult{hashKeys, append([]interface{}(nil), keys...)})
+	if c.order.Len() > c.size {
+		oldest := c.order.Remove(c.order.Back()).(*cachedResult)
+		delete(c.items, oldest.hashKeys)
+	}
+}
+
+// cacheKey returns the cache key of the given band hash keys, which all
+// have the same length.
+func cacheKey(hashKeys []string) string {
+	return strings.Join(hashKeys, "")
+}
@@ -0,0 +1,42 @@
+package minhashlsh
+
+import "testing"
+
+func Test_QueryCache(t *testing.T) {
+	f := NewMinhashLSH16(64, 0.5, 0, WithQueryCache(2))
+	sig := randomSignature(64, 1)
+	f.Add("a", sig)
+	f.Index()
+	for i := 0; i < 3; i++ {
+		if results := f.Query(sig); len(results) != 1 {
+			t.Fatalf("expected one result, got %v", results)
+		}
+	}
+	if hits, misses := f.CacheStats(); hits != 2 || misses != 1 {
+		t.Errorf("expected 2 hits and 1 miss, got %d and %d", hits, misses)
+	}
+	// Returned results are copies.
+	f.Query(sig)[0] = "modified"
+	if results := f.Query(sig); results[0] != "a" {
+		t.Errorf("cached results modified: %v", results)
+	}
+
+	f.Add("b", sig)
+	f.Index()
+	if results := f.Query(sig); len(results) != 2 {
+		t.Errorf("expected Index to clear the cache, got %v", results)
+	}
+	f.Remove("a")
+	if results := f.Query(sig); len(results) != 1 || results[0] != "b" {
+		t.Errorf("expected Remove to clear the cache, got %v", results)
+	}
+
+	// The least recently used query is evicted.
+	f.Query(randomSignature(64, 2))
+	f.Query(randomSignature(64, 3))
+	_, misses := f.CacheStats()
+	f.Query(sig)
+	if _, m := f.CacheStats(); m != misses+1 {
+		t.Error("expected the oldest query to be evicted")
+	}
+}
@@ -0,0 +1,132 @@
+package minhashlsh
+
+import "fmt"
+
+// LabeledPair is a pair of signatures labeled as similar or not,
+// used to calibrate the LSH parameters on real data.
+type LabeledPair struct {
+	Sig1, Sig2 []uint64
+	Similar    bool
+}
+
+// Calibration holds LSH parameters and their measured collision behavior
+// on labeled pairs.
+type Calibration struct {
+	K, L int
+	// Precision is the fraction of colliding pairs that are similar.
+	Precision float64
+	// Recall is the fraction of similar pairs that collide.
+	Recall float64
+}
+
+// Calibrate measures which labeled pairs collide, in at least one band,
+// for every K and L using up to numHash hash values of hashValueSize bytes,
+// and returns the parameters with the highest recall among those reaching
+// the given precision. Use them with WithParams.
+// The band hash keys are those of an index created by New with the given
+// options, such as WithSalt or WithTrimPolicy, and the same options must
+// be used with the calibrated parameters.
+// The theoretical model used by the constructors assumes signatures are
+// random, which often does not hold for short documents.
+func Calibrate(pairs []LabeledPair, numHash, hashValueSize int, precision float64, opts ...Option) (Calibration, error) {
+	if numHash <= 0 {
+		return Calibration{}, fmt.Errorf("minhashlsh: invalid number of hash values %d", numHash)
+	}
+	// index returns an index with bands of k hash values.
+	index := func(k int) (*MinhashLSH, error) {
+		return New(numHash, 0.5, hashValueSize, 0,
+			append(opts[:len(opts):len(opts)], WithParams(k, numHash/k))...)
+	}
+	f, err := index(1)
+	if err != nil {
+		return Calibration{}, err
+	}
+	size := f.SignatureSize()
+	var numSimilar int
+	for _, p := range pairs {
+		if len(p.Sig1) < size || len(p.Sig2) < size {
+			return Calibration{}, fmt.Errorf("minhashlsh: signatures shorter than %d", size)
+		}
+		if p.Similar {
+			numSimilar++
+		}
+	}
+	if numSimilar == 0 {
+		return Calibration{}, fmt.Errorf("minhashlsh: no similar pairs to calibrate with")
+	}
+	var best Calibration
+	found := false
+	firstMatch := make([]int, len(pairs))
+	for k := 1; k <= numHash; k++ {
+		if k > 1 {
+			if f, err = index(k); err != nil {
+				return Calibration{}, err
+			}
+		}
+		maxL := f.L
+		// The first band, of k hash values, in which each pair collides.
+		for i, p := range pairs {
+			firstMatch[i] = maxL
+			hashKeys1, hashKeys2 := f.hashKeys(p.Sig1), f.hashKeys(p.Sig2)
+			for b := 0; b < maxL; b++ {
+				if hashKeys1[b] == hashKeys2[b] {
+					firstMatch[i] = b
+					break
+				}
+			}
+		}
+		for l := 1; l <= maxL; l++ {
+			var truePositives, falsePositives int
+			for i, p := range pairs {
+				if firstMatch[i] >= l {
+					continue
+				}
+				if p.Similar {
+					truePositives++
+				} else {
+					falsePositives++
+				}
+			}
+			if truePositives == 0 {
+				continue
+			}
+			c := Calibration{
+				K:         k,
+				L:         l,
+				Precision: float64(truePositives) / float64(truePositives+falsePositives),
+				Recall:    float64(truePositives) / float64(numSimilar),
+			}
+			if c.Precision < precision {
+				continue
+			}
+			if !found || c.Recall > best.Recall ||
+				(c.Recall == best.Recall && c.Precision > best.Precision) {
+				best, found = c, true
+			}
+		}
+	}
+	if !found {
+		return Calibration{}, fmt.Errorf("minhashlsh: no parameters reach a precision of %v", precision)
+	}
+	return best, nil
+}
+
+// WithParams sets K and L instead of deriving them from the threshold,
+// for example from the result of Calibrate. K and L must be positive, and
+// K*L at most the number of hash values the index is created for.
+func WithParams(k, l int) Option {
+	return func(f *MinhashLSH) {
+		if k <= 0 || l <= 0 || k*l > f.numHash {
+			if f.optionErr == nil {
+				f.optionErr = fmt.Errorf("minhashlsh: invalid parameters K = %d, L = %d for %d hash values", k, l, f.numHash)
+			}
+			return
+		}
+		initSize := cap(f.HashTables[0])
+		f.K, f.L = k, l
+		f.HashTables = make([]hashTable, l)
+		for i := range f.HashTables {
+			f.HashTables[i] = make(hashTable, 0, initSize)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package minhashlsh
+
+import "testing"
+
+func Test_Calibrate(t *testing.T) {
+	var pairs []LabeledPair
+	for i := 0; i < 100; i++ {
+		sig1 := randomSignature(32, int64(i))
+		sig2 := randomSignature(32, int64(i+1000))
+		similar := i%2 == 0
+		if similar {
+			// Similar pairs share their first half.
+			copy(sig2, sig1[:16])
+		}
+		pairs = append(pairs, LabeledPair{sig1, sig2, similar})
+	}
+	c, err := Calibrate(pairs, 32, 4, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Precision != 1 || c.Recall != 1 {
+		t.Errorf("expected perfect calibration, got %+v", c)
+	}
+	if c.K*c.L > 32 {
+		t.Errorf("calibration uses %d hash values", c.K*c.L)
+	}
+
+	f := NewMinhashLSH32(32, 0.5, 0, WithParams(c.K, c.L))
+	if k, l := f.Params(); k != c.K || l != c.L || len(f.HashTables) != l {
+		t.Errorf("expected K = %d, L = %d, got %d, %d", c.K, c.L, k, l)
+	}
+	for i, p := range pairs {
+		f.Add(i, p.Sig1)
+	}
+	f.Index()
+	for i, p := range pairs {
+		found := false
+		for _, key := range f.Query(p.Sig2) {
+			found = found || key == i
+		}
+		if found != p.Similar {
+			t.Errorf("pair %d collides: %v", i, found)
+		}
+	}
+}
+
+func Test_CalibrateOptions(t *testing.T) {
+	var pairs []LabeledPair
+	for i := 0; i < 100; i++ {
+		sig1 := randomSignature(32, int64(i))
+		sig2 := randomSignature(32, int64(i+1000))
+		similar := i%2 == 0
+		if similar {
+			copy(sig2, sig1[:16])
+		} else {
+			// Dissimilar pairs only share the low bits of their hash values.
+			for j := range sig2 {
+				sig2[j] = sig2[j]&^(1<<32-1) | sig1[j]&(1<<32-1)
+			}
+		}
+		pairs = append(pairs, LabeledPair{sig1, sig2, similar})
+	}
+	if _, err := Calibrate(pairs, 32, 4, 1); err == nil {
+		t.Error("expected the low bits to make dissimilar pairs collide")
+	}
+	c, err := Calibrate(pairs, 32, 4, 1, WithTrimPolicy(TrimHighBits))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Precision != 1 || c.Recall != 1 {
+		t.Errorf("expected perfect calibration keeping the high bits, got %+v", c)
+	}
+
+	// 128-bit hash values take two signature values each.
+	pairs = pairs[:0]
+	for i := 0; i < 100; i++ {
+		sig1 := randomSignature(64, int64(i))
+		sig2 := randomSignature(64, int64(i+1000))
+		if i%2 == 0 {
+			copy(sig2, sig1[:32])
+		}
+		pairs = append(pairs, LabeledPair{sig1, sig2, i%2 == 0})
+	}
+	if c, err = Calibrate(pairs, 32, 16, 1); err != nil {
+		t.Fatal(err)
+	}
+	f := NewMinhashLSH128(32, 0.5, 0, WithParams(c.K, c.L))
+	for i, p := range pairs {
+		f.Add(i, p.Sig1)
+	}
+	f.Index()
+	for i, p := range pairs {
+		if contains(f.Query(p.Sig2), i) != p.Similar {
+			t.Errorf("pair %d of 128-bit signatures collides: %v", i, !p.Similar)
+		}
+	}
+	if _, err := Calibrate(pairs, 32, 3, 1); err == nil {
+		t.Error("expected an error for an invalid hash value size")
+	}
+}
+
+func Test_CalibrateNoSimilarPairs(t *testing.T) {
+	pairs := []LabeledPair{{randomSignature(8, 1), randomSignature(8, 2), false}}
+	if _, err := Calibrate(pairs, 8, 8, 0.5); err == nil {
+		t.Error("expected an error without similar pairs")
+	}
+}
+
+func Test_WithParamsInvalid(t *testing.T) {
+	for _, params := range [][2]int{{0, 4}, {4, -1}, {8, 8}} {
+		if _, err := New(32, 0.5, 4, 0, WithParams(params[0], params[1])); err == nil {
+			t.Errorf("K = %d, L = %d: expected an error", params[0], params[1])
+		}
+	}
+	f, err := New(32, 0.5, 4, 0, WithParams(4, 8))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if k, l := f.Params(); k != 4 || l != 8 {
+		t.Errorf("expected K = 4, L = 8, got %d, %d", k, l)
+	}
+	if _, err := New(32, 0.5, 3, 0); err == nil {
+		t.Error("expected an error for an invalid hash value size")
+	}
+	defer func() {
+		if recover() == nil {
+			t.Error("expected the constructor to panic")
+		}
+	}()
+	NewMinhashLSH32(32, 0.5, 0, WithParams(8, 8))
+}
+
+func Test_CalibrateInvalid(t *testing.T) {
+	if _, err := Calibrate(nil, 0, 4, 1); err == nil {
+		t.Error("expected an error for no hash values")
+	}
+}
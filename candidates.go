@@ -0,0 +1,94 @@
+package minhashlsh
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"sync/atomic"
+)
+
+// candidateCounts is a count-min sketch of the number of times keys are
+// returned as query candidates.
+type candidateCounts struct {
+	width    uint64
+	depth    int
+	counters []uint64
+}
+
+// WithCandidateCounts counts how often each key is returned as a candidate
+// by queries in a count-min sketch of depth rows of width counters, so keys
+// matching nearly everything, such as overly generic documents, can be found
+// with CandidateCount. Counts are overestimated by at most e/width of the
+// total count with a probability of 1-exp(-depth). Queries update counters
+// atomically, so they can still run concurrently. Keys are not counted
+// unless width and depth are positive.
+func WithCandidateCounts(width, depth int) Option {
+	return func(f *MinhashLSH) {
+		if width <= 0 || depth <= 0 {
+			f.candidateCounts = nil
+			return
+		}
+		f.candidateCounts = &candidateCounts{
+			width:    uint64(width),
+			depth:    depth,
+			counters: make([]uint64, width*depth),
+		}
+	}
+}
+
+// keyHash hashes a key for the count-min sketch.
+func keyHash(key interface{}) uint64 {
+	h := fnv.New64a()
+	var buf [8]byte
+	switch k := key.(type) {
+	case string:
+		h.Write([]byte(k))
+	case int:
+		binary.LittleEndian.PutUint64(buf[:], uint64(k))
+		h.Write(buf[:])
+	case int64:
+		binary.LittleEndian.PutUint64(buf[:], uint64(k))
+		h.Write(buf[:])
+	case uint64:
+		binary.LittleEndian.PutUint64(buf[:], k)
+		h.Write(buf[:])
+	default:
+		fmt.Fprintf(h, "%T%v", key, key)
+	}
+	return h.Sum64()
+}
+
+// counter returns the index of the counter of a key hash in row i,
+// deriving independent hashes by double hashing.
+func (c *candidateCounts) counter(h uint64, i int) int {
+	return i*int(c.width) + int((h+uint64(i)*(h>>32|1))%c.width)
+}
+
+func (c *candidateCounts) add(key interface{}) {
+	h := keyHash(key)
+	for i := 0; i < c.depth; i++ {
+		atomic.AddUint64(&c.counters[c.counter(h, i)], 1)
+	}
+}
+
+func (c *candidateCounts) count(key interface{}) uint64 {
+	h := keyHash(key)
+	min := uint64(math.MaxUint64)
+	for i := 0; i < c.depth; i++ {
+		if n := atomic.LoadUint64(&c.counters[c.counter(h, i)]); n < min {
+			min = n
+		}
+	}
+	return min
+}
+
+// CandidateCount returns an estimate, never lower than the truth, of the
+// number of times a key was returned as a candidate by queries, or 0 unless
+// the index was created with WithCandidateCounts.
+func (f *MinhashLSH) CandidateCount(key interface{}) uint64 {
+	if f.candidateCounts == nil {
+		return 0
+	}
+	return f.candidateCounts.count(key)
+}
@@ -0,0 +1,41 @@
+package minhashlsh
+
+import "testing"
+
+func Test_CandidateCounts(t *testing.T) {
+	f := NewMinhashLSH16(64, 0.5, 0, WithCandidateCounts(1024, 4))
+	generic := make([]uint64, 64)
+	f.Add("generic", generic)
+	for i := 0; i < 100; i++ {
+		f.Add(i, randomSignature(64, int64(i)))
+	}
+	f.Index()
+	for i := 0; i < 100; i++ {
+		sig := randomSignature(64, int64(i))
+		// Every query shares a band with the generic key.
+		copy(sig, generic[:f.K])
+		f.Query(sig)
+	}
+	if n := f.CandidateCount("generic"); n != 100 {
+		t.Errorf("expected the generic key to be a candidate 100 times, got %d", n)
+	}
+	if n := f.CandidateCount(5); n < 1 || n > 10 {
+		t.Errorf("expected key 5 to be a candidate about once, got %d", n)
+	}
+	if n := NewMinhashLSH16(64, 0.5, 0).CandidateCount("generic"); n != 0 {
+		t.Errorf("expected 0 without candidate counts, got %d", n)
+	}
+}
+
+func Test_CandidateCountsInvalid(t *testing.T) {
+	for _, size := range [][2]int{{0, 4}, {100, 0}, {-1, -1}} {
+		f := NewMinhashLSH16(64, 0.5, 0, WithCandidateCounts(size[0], size[1]))
+		sig := randomSignature(64, 1)
+		f.Add("a", sig)
+		f.Index()
+		f.Query(sig)
+		if n := f.CandidateCount("a"); n != 0 {
+			t.Errorf("width %d and depth %d: expected no count, got %d", size[0], size[1], n)
+		}
+	}
+}
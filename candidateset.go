@@ -0,0 +1,61 @@
+package minhashlsh
+
+// candidateSet is the set of candidate keys of a query, in the order they
+// were found. String and int keys, the most common, are deduplicated by
+// typed maps, which hash much faster than maps of interface{} keys.
+type candidateSet struct {
+	keys    []interface{}
+	strings map[string]struct{}
+	ints    map[int]struct{}
+	others  map[interface{}]struct{}
+}
+
+// add adds a key unless it is in the set already.
+func (s *candidateSet) add(key interface{}) {
+	switch k := key.(type) {
+	case string:
+		if _, exist := s.strings[k]; exist {
+			return
+		}
+		if s.strings == nil {
+			s.strings = make(map[string]struct{})
+		}
+		s.strings[k] = struct{}{}
+	case int:
+		if _, exist := s.ints[k]; exist {
+			return
+		}
+		if s.ints == nil {
+			s.ints = make(map[int]struct{})
+		}
+		s.ints[k] = struct{}{}
+	default:
+		if _, exist := s.others[k]; exist {
+			return
+		}
+		if s.others == nil {
+			s.others = make(map[interface{}]struct{})
+		}
+		s.others[k] = struct{}{}
+	}
+	s.keys = append(s.keys, key)
+}
+
+// has returns true if the key is in the set.
+func (s *candidateSet) has(key interface{}) bool {
+	var exist bool
+	switch k := key.(type) {
+	case string:
+		_, exist = s.strings[k]
+	case int:
+		_, exist = s.ints[k]
+	default:
+		_, exist = s.others[k]
+	}
+	return exist
+}
+
+// len returns the number of keys in the set.
+func (s *candidateSet) len() int {
+	return len(s.keys)
+}
@@ -0,0 +1,96 @@
+package minhashlsh
+
+import "sync"
+
+// ChangeType is the type of a mutation of an index.
+type ChangeType uint8
+
+const (
+	// ChangeAdd is a key added with Add, or merged from another index.
+	ChangeAdd ChangeType = iota + 1
+	// ChangeRemove is a key removed with Remove.
+	ChangeRemove
+	// ChangeSoftDelete is a key hidden with SoftDelete.
+	ChangeSoftDelete
+	// ChangeUndelete is a key restored with Undelete.
+	ChangeUndelete
+	// ChangeIndex is a call to Index, making the keys added before it
+	// searchable.
+	ChangeIndex
+)
+
+// Change is a mutation of an index, numbered in order from 1.
+// Signature is the signature of an added key, and the stored signature of
+// a removed one if the index stores signatures, truncated to the signature
+// size of the index.
+type Change struct {
+	Seq       uint64
+	Type      ChangeType
+	Key       interface{}
+	Signature []uint64
+}
+
+// Subscription receives the changes of an index from C, in order.
+type Subscription struct {
+	C    <-chan Change
+	c    chan Change
+	done chan struct{}
+	once sync.Once
+	feed *changeFeed
+}
+
+type changeFeed struct {
+	mu   sync.Mutex
+	seq  uint64
+	subs map[*Subscription]struct{}
+}
+
+// Subscribe returns a subscription to the changes of the index from now
+// on, so replicas, audit logs or search engines can mirror its state.
+// Changes are buffered up to the given number, beyond which the mutations
+// block until they are received: a subscriber must keep receiving them, or
+// close its subscription. Like Add, Subscribe must not be called
+// concurrently with the mutations of the index.
+func (f *MinhashLSH) Subscribe(buffer int) *Subscription {
+	if f.changes == nil {
+		f.changes = &changeFeed{subs: make(map[*Subscription]struct{})}
+	}
+	c := make(chan Change, buffer)
+	s := &Subscription{C: c, c: c, done: make(chan struct{}), feed: f.changes}
+	f.changes.mu.Lock()
+	f.changes.subs[s] = struct{}{}
+	f.changes.mu.Unlock()
+	return s
+}
+
+// Close ends the subscription, unblocking the mutations waiting on it,
+// and closes C. The changes not received yet are lost.
+func (s *Subscription) Close() {
+	s.once.Do(func() {
+		close(s.done)
+		s.feed.mu.Lock()
+		delete(s.feed.subs, s)
+		close(s.c)
+		s.feed.mu.Unlock()
+	})
+}
+
+// publish sends a change to the subscribers, if any.
+func (f *MinhashLSH) publish(t ChangeType, key interface{}, sig []uint64) {
+	if f.changes == nil {
+		return
+	}
+	if sig != nil {
+		sig = append([]uint64(nil), sig[:minInt(len(sig), f.SignatureSize())]...)
+	}
+	f.changes.mu.Lock()
+	defer f.changes.mu.Unlock()
+	f.changes.seq++
+	change := Change{f.changes.seq, t, key, sig}
+	for s := range f.changes.subs {
+		select {
+		case s.c <- change:
+		case <-s.done:
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package minhashlsh
+
+import "testing"
+
+func Test_Subscribe(t *testing.T) {
+	f := NewMinhashLSH16(64, 0.5, 0, WithSignatureStorage())
+	sub := f.Subscribe(10)
+	sig := randomSignature(64, 1)
+	f.Add("a", sig)
+	f.Index()
+	f.SoftDelete("a")
+	f.Undelete("a")
+	f.Remove("a")
+	expected := []ChangeType{ChangeAdd, ChangeIndex, ChangeSoftDelete, ChangeUndelete, ChangeRemove}
+	for i, typ := range expected {
+		change := <-sub.C
+		if change.Seq != uint64(i+1) || change.Type != typ {
+			t.Fatalf("expected change %d of type %d, got %v", i+1, typ, change)
+		}
+		if typ == ChangeAdd || typ == ChangeRemove {
+			if change.Key != "a" || len(change.Signature) != f.SignatureSize() {
+				t.Fatalf("unexpected key or signature in %v", change)
+			}
+			for j, v := range change.Signature {
+				if v != sig[j] {
+					t.Fatalf("unexpected signature in %v", change)
+				}
+			}
+		}
+	}
+
+	// A closed subscriber does not block mutations.
+	sub.Close()
+	sub.Close()
+	full := f.Subscribe(0)
+	done := make(chan struct{})
+	go func() {
+		f.Add("b", sig)
+		close(done)
+	}()
+	full.Close()
+	<-done
+	if _, ok := <-sub.C; ok {
+		t.Error("change received after Close")
+	}
+}
+
+func Test_SubscribeMerge(t *testing.T) {
+	f, other := testIndex(0), testIndex(3)
+	sub := f.Subscribe(10)
+	if err := f.Merge(other); err != nil {
+		t.Fatal(err)
+	}
+	sub.Close()
+	var added int
+	for change := range sub.C {
+		if change.Type == ChangeAdd {
+			added++
+		}
+	}
+	if added != 3 {
+		t.Errorf("expected 3 added keys, got %d", added)
+	}
+}
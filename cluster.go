@@ -0,0 +1,114 @@
+package minhashlsh
+
+import "sort"
+
+// Linkage defines the similarity of clusters merged by ClusterCandidates
+// from the similarities of their keys.
+type Linkage uint8
+
+const (
+	// SingleLinkage is the similarity of the most similar keys of the
+	// clusters, chaining keys similar to each other in turn.
+	SingleLinkage Linkage = iota
+	// CompleteLinkage is the similarity of the least similar keys of the
+	// clusters, so all the keys of a cluster are similar to each other.
+	CompleteLinkage
+	// AverageLinkage is the average similarity of the keys of the clusters.
+	AverageLinkage
+)
+
+// ClusterCandidates groups keys, such as the candidates of a query, by
+// agglomerative clustering: starting with a cluster per key, the two most
+// similar clusters are merged while their similarity, given the pairwise
+// similarities of keys and the linkage, is at least the cut threshold.
+// The clusters are in the order of their first key, and their keys in the
+// order given. It takes time cubic in the number of keys.
+func ClusterCandidates(keys []interface{}, similarity func(a, b interface{}) float64, cut float64, linkage Linkage) [][]interface{} {
+	n := len(keys)
+	sims := make([][]float64, n)
+	for i := range sims {
+		sims[i] = make([]float64, n)
+		for j := 0; j < i; j++ {
+			sims[i][j] = similarity(keys[i], keys[j])
+			sims[j][i] = sims[i][j]
+		}
+	}
+	// members[i] holds the positions of the keys of the cluster i, nil
+	// once merged into another. Clusters are merged into the one of lower
+	// index, so i is the first position of its cluster.
+	members := make([][]int, n)
+	for i := range members {
+		members[i] = []int{i}
+	}
+	for {
+		best, bi, bj := cut, -1, -1
+		for i := range members {
+			if members[i] == nil {
+				continue
+			}
+			for j := 0; j < i; j++ {
+				if members[j] != nil && sims[i][j] >= best {
+					best, bi, bj = sims[i][j], i, j
+				}
+			}
+		}
+		if bi < 0 {
+			break
+		}
+		// Merge bi into bj, updating the similarities of bj.
+		for k := range members {
+			if members[k] == nil || k == bi || k == bj {
+				continue
+			}
+			s := sims[bj][k]
+			switch linkage {
+			case SingleLinkage:
+				if sims[bi][k] > s {
+					s = sims[bi][k]
+				}
+			case CompleteLinkage:
+				if sims[bi][k] < s {
+					s = sims[bi][k]
+				}
+			case AverageLinkage:
+				ni, nj := float64(len(members[bi])), float64(len(members[bj]))
+				s = (ni*sims[bi][k] + nj*s) / (ni + nj)
+			}
+			sims[bj][k], sims[k][bj] = s, s
+		}
+		members[bj] = append(members[bj], members[bi]...)
+		members[bi] = nil
+	}
+	clusters := make([][]interface{}, 0)
+	for _, positions := range members {
+		if positions == nil {
+			continue
+		}
+		sort.Ints(positions)
+		cluster := make([]interface{}, len(positions))
+		for i, p := range positions {
+			cluster[i] = keys[p]
+		}
+		clusters = append(clusters, cluster)
+	}
+	return clusters
+}
+
+// QueryClusters returns the candidate keys given the query signature
+// grouped by ClusterCandidates, with the similarities of the candidates
+// estimated from their stored signatures.
+// ErrNoSignatures is returned unless the index stores signatures.
+func (f *MinhashLSH) QueryClusters(sig []uint64, cut float64, linkage Linkage) ([][]interface{}, error) {
+	if f.signatures == nil {
+		return nil, ErrNoSignatures
+	}
+	words := f.HashValueSize / wordSize(f.HashValueSize)
+	similarity := func(a, b interface{}) float64 {
+		equal, n := countEqual(f.signatures[a], f.signatures[b], words)
+		if n == 0 {
+			return 0
+		}
+		return float64(equal) / float64(n)
+	}
+	return ClusterCandidates(f.queryVerified(sig), similarity, cut, linkage), nil
+}
@@ -0,0 +1,62 @@
+package minhashlsh
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_ClusterCandidates(t *testing.T) {
+	// Keys on a line, similar when close.
+	keys := []interface{}{0, 10, 1, 3, 11, 20}
+	similarity := func(a, b interface{}) float64 {
+		d := a.(int) - b.(int)
+		if d < 0 {
+			d = -d
+		}
+		return 1 / float64(1+d)
+	}
+	for _, c := range []struct {
+		linkage  Linkage
+		cut      float64
+		expected [][]interface{}
+	}{
+		{SingleLinkage, 0.3, [][]interface{}{{0, 1, 3}, {10, 11}, {20}}},
+		{CompleteLinkage, 0.3, [][]interface{}{{0, 1}, {10, 11}, {3}, {20}}},
+		{AverageLinkage, 0.25, [][]interface{}{{0, 1, 3}, {10, 11}, {20}}},
+		{SingleLinkage, 0.01, [][]interface{}{{0, 10, 1, 3, 11, 20}}},
+		{SingleLinkage, 0.9, [][]interface{}{{0}, {10}, {1}, {3}, {11}, {20}}},
+	} {
+		clusters := ClusterCandidates(keys, similarity, c.cut, c.linkage)
+		if !reflect.DeepEqual(clusters, c.expected) {
+			t.Errorf("linkage %d at %.2f: expected %v, got %v", c.linkage, c.cut, c.expected, clusters)
+		}
+	}
+}
+
+func Test_QueryClusters(t *testing.T) {
+	f := NewMinhashLSH16(64, 0.3, 0, WithSignatureStorage())
+	if _, err := NewMinhashLSH16(64, 0.3, 0).QueryClusters(randomSignature(64, 1), 0.5, SingleLinkage); err != ErrNoSignatures {
+		t.Errorf("expected ErrNoSignatures, got %v", err)
+	}
+	query := randomSignature(64, 1)
+	// Two groups of near-duplicates, each half similar to the query.
+	for g := 0; g < 2; g++ {
+		base := append([]uint64(nil), query...)
+		for j := g * 32; j < g*32+32; j++ {
+			base[j] = uint64(g + 1)
+		}
+		for i := 0; i < 3; i++ {
+			sig := append([]uint64(nil), base...)
+			sig[63-g*32-i] = uint64(100 + i)
+			f.Add(g*10+i, sig)
+		}
+	}
+	f.Index()
+	clusters, err := f.QueryClusters(query, 0.8, CompleteLinkage)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(clusters) != 2 || len(clusters[0]) != 3 || len(clusters[1]) != 3 {
+		t.Errorf("expected two clusters of three keys, got %v", clusters)
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+
+	minhashlsh "github.com/omorillo/minhash-lsh"
+)
+
+type diffResult struct {
+	Params  []string `json:"params,omitempty"`
+	OnlyA   []string `json:"only_a,omitempty"`
+	OnlyB   []string `json:"only_b,omitempty"`
+	Changed []string `json:"changed,omitempty"`
+}
+
+// diff compares two saved indexes and writes their differences as JSON to
+// stdout, keys being sorted and formatted with fmt.Sprint. Like diff(1),
+// it exits with status 1 if the indexes differ.
+func diff(args []string) {
+	flags := flag.NewFlagSet("minhash-lsh-all-pair diff", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: minhash-lsh-all-pair diff <index file> <index file>")
+	}
+	flags.Parse(args)
+	if flags.NArg() != 2 {
+		flags.Usage()
+		os.Exit(2)
+	}
+	var indexes [2]*minhashlsh.MinhashLSH
+	for i := range indexes {
+		lsh, err := minhashlsh.Load(flags.Arg(i))
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+		indexes[i] = lsh
+	}
+	d, err := minhashlsh.Diff(indexes[0], indexes[1])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	result := diffResult{
+		Params:  d.Params,
+		OnlyA:   sortedKeys(d.OnlyA),
+		OnlyB:   sortedKeys(d.OnlyB),
+		Changed: sortedKeys(d.Changed),
+	}
+	if err := json.NewEncoder(os.Stdout).Encode(result); err != nil {
+		panic(err)
+	}
+	if !d.Equal() {
+		os.Exit(1)
+	}
+}
+
+func sortedKeys(keys []interface{}) []string {
+	s := make([]string, len(keys))
+	for i, key := range keys {
+		s[i] = fmt.Sprint(key)
+	}
+	sort.Strings(s)
+	return s
+}
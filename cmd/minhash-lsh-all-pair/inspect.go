@@ -0,0 +1,59 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	minhashlsh "github.com/omorillo/minhash-lsh"
+)
+
+type bandSummary struct {
+	Band          int `json:"band"`
+	NumBuckets    int `json:"num_buckets"`
+	MaxBucketSize int `json:"max_bucket_size"`
+}
+
+type inspectResult struct {
+	K              int           `json:"k"`
+	L              int           `json:"l"`
+	HashValueSize  int           `json:"hash_value_size"`
+	NumIndexedKeys int           `json:"num_indexed_keys"`
+	MemoryUsage    int64         `json:"memory_usage"`
+	Bands          []bandSummary `json:"bands"`
+}
+
+// inspect writes the parameters, the number of keys, the estimated memory
+// usage and the bucket statistics of each band of a saved index as JSON to
+// stdout.
+func inspect(args []string) {
+	flags := flag.NewFlagSet("minhash-lsh-all-pair inspect", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: minhash-lsh-all-pair inspect <index file>")
+	}
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		flags.Usage()
+		os.Exit(2)
+	}
+	lsh, err := minhashlsh.Load(flags.Arg(0))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	k, l := lsh.Params()
+	result := inspectResult{
+		K:              k,
+		L:              l,
+		HashValueSize:  lsh.HashValueSize,
+		NumIndexedKeys: lsh.NumIndexedKeys,
+		MemoryUsage:    lsh.MemoryUsage(),
+	}
+	for _, s := range lsh.BucketStats() {
+		result.Bands = append(result.Bands, bandSummary{s.Band, s.NumBuckets, s.MaxSize})
+	}
+	if err := json.NewEncoder(os.Stdout).Encode(result); err != nil {
+		panic(err)
+	}
+}
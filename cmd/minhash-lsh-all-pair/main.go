@@ -5,6 +5,7 @@ import (
 	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"os"
 	"regexp"
 	"strconv"
@@ -21,17 +22,50 @@ var (
 	threshold      float64
 	outputSelfPair bool
 	hasID          bool
+	outputFormat   string
+	indexFilename  string
 )
 
+// Subcommands other than the default all pair search.
+var commands = map[string]func(args []string){
+	"query":   pointquery,
+	"diff":    diff,
+	"inspect": inspect,
+	"merge":   merge,
+}
+
 func main() {
-	flag.StringVar(&setFilename, "input", "", "The set file as input")
-	flag.Int64Var(&minhashSeed, "seed", 42, "The Minhash seed")
-	flag.IntVar(&minhashSize, "sigsize", 128,
+	if len(os.Args) > 1 {
+		if command, exist := commands[os.Args[1]]; exist {
+			command(os.Args[2:])
+			return
+		}
+	}
+	allPair(os.Args[1:])
+}
+
+func minhashFlags(flags *flag.FlagSet) {
+	flags.Int64Var(&minhashSeed, "seed", 42, "The Minhash seed")
+	flags.IntVar(&minhashSize, "sigsize", 128,
 		"The Minhash signature size in number of hash functions")
-	flag.Float64Var(&threshold, "threshold", 0.9, "The Jaccard similarity threshold")
-	flag.BoolVar(&outputSelfPair, "selfpair", false, "Allow self-pair in results")
-	flag.BoolVar(&hasID, "hasIDfield", true, "The input set file has ID field in the beginning of each line")
-	flag.Parse()
+}
+
+func allPair(args []string) {
+	flags := flag.NewFlagSet("minhash-lsh-all-pair", flag.ExitOnError)
+	flags.StringVar(&setFilename, "input", "", "The set file as input")
+	minhashFlags(flags)
+	flags.Float64Var(&threshold, "threshold", 0.9, "The Jaccard similarity threshold")
+	flags.BoolVar(&outputSelfPair, "selfpair", false, "Allow self-pair in results")
+	flags.BoolVar(&hasID, "hasIDfield", true, "The input set file has ID field in the beginning of each line")
+	flags.StringVar(&outputFormat, "format", "text", "The output format of pairs: text, csv, jsonl, dot or graphml")
+	flags.StringVar(&indexFilename, "save", "", "Save the index to this file for the query subcommand")
+	flags.Parse(args)
+	switch outputFormat {
+	case "text", "csv", "jsonl", "dot", "graphml":
+	default:
+		fmt.Fprintln(os.Stderr, "Unknown output format:", outputFormat)
+		os.Exit(2)
+	}
 
 	// Create Minhash signatures
 	start := time.Now()
@@ -45,13 +79,18 @@ func main() {
 
 	// Indexing
 	start = time.Now()
-	lsh := minhashlsh.NewMinhashLSH(minhashSize, threshold, len(sets))
+	lsh := minhashlsh.NewMinhashLSH(minhashSize, threshold, len(setSigs))
 	for _, s := range setSigs {
 		lsh.Add(s.ID, s.signature)
 	}
 	lsh.Index()
 	indexingTime := time.Now().Sub(start)
 	fmt.Fprintf(os.Stderr, "Indexing time: %.2f seconds\n", indexingTime.Seconds())
+	if indexFilename != "" {
+		if err := lsh.Save(indexFilename); err != nil {
+			panic(err)
+		}
+	}
 
 	// Querying and output results
 	start = time.Now()
@@ -67,21 +106,13 @@ func main() {
 			}
 		}
 	}()
-	w := bufio.NewWriter(os.Stdout)
-	for pair := range pairs {
-		w.WriteString(pair.String() + "\n")
-	}
-	if err := w.Flush(); err != nil {
+	if err := writePairs(pairs, outputFormat); err != nil {
 		panic(err)
 	}
 	searchTime := time.Now().Sub(start)
 	fmt.Fprintf(os.Stderr, "All pair search time: %.2f seconds\n", searchTime.Seconds())
 }
 
-func pointquery() {
-	panic("Not implemented")
-}
-
 type valueCountPair struct {
 	value string
 	count int
@@ -119,15 +150,21 @@ type set struct {
 //    * frequency is an integer count of the occurance of value
 //    * ____ (4 underscores) is the separator
 func readSets(setFilename string, firstItemIsID bool) <-chan set {
+	file, err := os.Open(setFilename)
+	if err != nil {
+		panic(err)
+	}
+	return readSetsFrom(file, firstItemIsID)
+}
+
+// readSetsFrom reads sets in the format of readSets from r,
+// closing it at the end.
+func readSetsFrom(r io.ReadCloser, firstItemIsID bool) <-chan set {
 	sets := make(chan set)
 	go func() {
 		defer close(sets)
-		file, err := os.Open(setFilename)
-		if err != nil {
-			panic(err)
-		}
-		defer file.Close()
-		scanner := bufio.NewScanner(file)
+		defer r.Close()
+		scanner := bufio.NewScanner(r)
 		scanner.Buffer(nil, 4096*1024*1024*8)
 		var count int
 		for scanner.Scan() {
@@ -184,6 +221,44 @@ type pair struct {
 	ID2 string
 }
 
+func (p *pair) sorted() minhashlsh.Pair {
+	if p.ID1 <= p.ID2 {
+		return minhashlsh.Pair{Key1: p.ID1, Key2: p.ID2}
+	}
+	return minhashlsh.Pair{Key1: p.ID2, Key2: p.ID1}
+}
+
+// writePairs writes the pairs to stdout in the given format.
+func writePairs(pairs <-chan pair, format string) error {
+	var exporter minhashlsh.Exporter
+	switch format {
+	case "text":
+		w := bufio.NewWriter(os.Stdout)
+		for pair := range pairs {
+			w.WriteString(pair.String() + "\n")
+		}
+		return w.Flush()
+	case "csv":
+		exporter = minhashlsh.NewCSVExporter(os.Stdout)
+	case "jsonl":
+		exporter = minhashlsh.NewJSONLExporter(os.Stdout)
+	case "dot":
+		exporter = minhashlsh.NewDOTExporter(os.Stdout)
+	case "graphml":
+		exporter = minhashlsh.NewGraphMLExporter(os.Stdout)
+	default:
+		return errors.New("Unknown output format: " + format)
+	}
+	out := make(chan minhashlsh.Pair)
+	go func() {
+		defer close(out)
+		for pair := range pairs {
+			out <- pair.sorted()
+		}
+	}()
+	return minhashlsh.ExportPairs(exporter, out)
+}
+
 func (p *pair) String() string {
 	if p.ID1 <= p.ID2 {
 		return fmt.Sprintf("%s, %s", p.ID1, p.ID2)
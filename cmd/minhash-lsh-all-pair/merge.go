@@ -0,0 +1,46 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	minhashlsh "github.com/omorillo/minhash-lsh"
+)
+
+// merge combines saved indexes built with the same parameters into a new
+// index file.
+func merge(args []string) {
+	var output string
+	flags := flag.NewFlagSet("minhash-lsh-all-pair merge", flag.ExitOnError)
+	flags.StringVar(&output, "o", "", "The index file to write")
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: minhash-lsh-all-pair merge -o <output file> <index file>...")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if output == "" || flags.NArg() == 0 {
+		flags.Usage()
+		os.Exit(2)
+	}
+	merged, err := minhashlsh.Load(flags.Arg(0))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	for _, filename := range flags.Args()[1:] {
+		lsh, err := minhashlsh.Load(filename)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		if err := merged.Merge(lsh); err != nil {
+			fmt.Fprintln(os.Stderr, filename+":", err)
+			os.Exit(1)
+		}
+	}
+	if err := merged.Save(output); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
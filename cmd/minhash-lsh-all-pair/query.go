@@ -0,0 +1,92 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	minhashlsh "github.com/omorillo/minhash-lsh"
+)
+
+type queryResult struct {
+	Query   string        `json:"query"`
+	Matches []interface{} `json:"matches"`
+}
+
+// pointquery reads sets or signatures from stdin, queries a saved index
+// and writes the matches of each as a JSON line to stdout.
+// Sets use the format of the set file, signatures are CSV rows of the
+// key followed by the hash values. The Minhash seed and signature size
+// must match the ones used to build the index.
+func pointquery(args []string) {
+	var inputType string
+	flags := flag.NewFlagSet("minhash-lsh-all-pair query", flag.ExitOnError)
+	flags.StringVar(&indexFilename, "index", "", "The saved index file to query")
+	flags.StringVar(&inputType, "input-type", "set", "The type of the input: set or signature")
+	flags.BoolVar(&hasID, "hasIDfield", true, "The input sets have ID field in the beginning of each line")
+	minhashFlags(flags)
+	flags.Parse(args)
+
+	lsh, err := minhashlsh.Load(indexFilename)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	// Signatures shorter than the K*L values of the bands cannot be queried.
+	size := lsh.SignatureSize()
+	var sigs <-chan setSig
+	switch inputType {
+	case "set":
+		if minhashSize < size {
+			fmt.Fprintf(os.Stderr, "Signature size %d is smaller than the %d hash values of the index\n", minhashSize, size)
+			os.Exit(2)
+		}
+		sigs = createSigantures(readSetsFrom(os.Stdin, hasID))
+	case "signature":
+		sigs = readSignatures(os.Stdin, size)
+	default:
+		fmt.Fprintln(os.Stderr, "Unknown input type:", inputType)
+		os.Exit(2)
+	}
+
+	w := bufio.NewWriter(os.Stdout)
+	encoder := json.NewEncoder(w)
+	for s := range sigs {
+		if err := encoder.Encode(queryResult{s.ID, lsh.Query(s.signature)}); err != nil {
+			panic(err)
+		}
+		// Flush each result so downstream pipeline stages see it right away.
+		if err := w.Flush(); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// readSignatures reads the signatures of r, skipping with a warning the
+// lines with less than size hash values.
+func readSignatures(r io.Reader, size int) <-chan setSig {
+	out := make(chan setSig)
+	go func() {
+		defer close(out)
+		reader := minhashlsh.NewCSVSignatureReader(r)
+		for {
+			key, sig, err := reader.Read()
+			if err == io.EOF {
+				return
+			}
+			if err != nil {
+				panic(err)
+			}
+			if len(sig) < size {
+				fmt.Fprintf(os.Stderr, "Skipping line %d: %d hash values, expected at least %d\n", reader.Line(), len(sig), size)
+				continue
+			}
+			out <- setSig{key, 0, sig}
+		}
+	}()
+	return out
+}
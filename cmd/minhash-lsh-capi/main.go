@@ -0,0 +1,209 @@
+// Command minhash-lsh-capi exports a C API of the MinHash LSH index,
+// so it can be embedded in other languages. Build it as a shared library:
+//
+//	go build -buildmode=c-shared -o libminhashlsh.so ./cmd/minhash-lsh-capi
+//
+// Indexes are referred to by handles, keys are C strings. Functions that
+// can fail return an error message that must be released with
+// minhashlsh_free_string, or NULL on success. Panics of the index, such as
+// on invalid parameters, are returned as errors instead of crashing the
+// process.
+package main
+
+/*
+#include <stdint.h>
+#include <stdlib.h>
+*/
+import "C"
+
+import (
+	"fmt"
+	"sync"
+	"unsafe"
+
+	minhashlsh "github.com/omorillo/minhash-lsh"
+)
+
+var (
+	handlesMutex sync.Mutex
+	handles                = make(map[C.int64_t]*minhashlsh.MinhashLSH)
+	nextHandle   C.int64_t = 1
+)
+
+func register(lsh *minhashlsh.MinhashLSH) C.int64_t {
+	handlesMutex.Lock()
+	defer handlesMutex.Unlock()
+	h := nextHandle
+	nextHandle++
+	handles[h] = lsh
+	return h
+}
+
+func lookup(h C.int64_t) *minhashlsh.MinhashLSH {
+	handlesMutex.Lock()
+	defer handlesMutex.Unlock()
+	return handles[h]
+}
+
+func cError(msg string) *C.char {
+	return C.CString(msg)
+}
+
+const errInvalidHandle = "minhashlsh: invalid handle"
+
+// recoverError turns a panic into the error message returned in msg.
+// It must be deferred directly by the exported functions.
+func recoverError(msg **C.char) {
+	if r := recover(); r != nil {
+		*msg = cError(fmt.Sprint(r))
+	}
+}
+
+// checkSignature returns an error message unless a signature of n values
+// can be added to or queried from the index.
+func checkSignature(lsh *minhashlsh.MinhashLSH, n C.int) string {
+	if n < 0 {
+		return "minhashlsh: negative signature length"
+	}
+	if int(n) < lsh.SignatureSize() {
+		return "minhashlsh: signature too short"
+	}
+	return ""
+}
+
+func signature(sig *C.uint64_t, n C.int) []uint64 {
+	values := (*[1 << 30]C.uint64_t)(unsafe.Pointer(sig))[:n:n]
+	s := make([]uint64, n)
+	for i, v := range values {
+		s[i] = uint64(v)
+	}
+	return s
+}
+
+// minhashlsh_new returns the handle of a new index, or 0 if the
+// parameters are invalid.
+//
+//export minhashlsh_new
+func minhashlsh_new(numHash C.int, threshold C.double, initSize C.int) (h C.int64_t) {
+	defer func() {
+		if recover() != nil {
+			h = 0
+		}
+	}()
+	return register(minhashlsh.NewMinhashLSH(int(numHash), float64(threshold), int(initSize)))
+}
+
+//export minhashlsh_free
+func minhashlsh_free(h C.int64_t) {
+	defer func() { recover() }()
+	handlesMutex.Lock()
+	defer handlesMutex.Unlock()
+	delete(handles, h)
+}
+
+//export minhashlsh_add
+func minhashlsh_add(h C.int64_t, key *C.char, sig *C.uint64_t, n C.int) (msg *C.char) {
+	defer recoverError(&msg)
+	lsh := lookup(h)
+	if lsh == nil {
+		return cError(errInvalidHandle)
+	}
+	if err := checkSignature(lsh, n); err != "" {
+		return cError(err)
+	}
+	lsh.Add(C.GoString(key), signature(sig, n))
+	return nil
+}
+
+//export minhashlsh_index
+func minhashlsh_index(h C.int64_t) (msg *C.char) {
+	defer recoverError(&msg)
+	lsh := lookup(h)
+	if lsh == nil {
+		return cError(errInvalidHandle)
+	}
+	lsh.Index()
+	return nil
+}
+
+// minhashlsh_query returns the candidate keys as an array of count C strings,
+// to be released with minhashlsh_free_results. Keys of indexes built in Go
+// that are not strings are formatted with fmt.Sprint. On errors, such as an
+// invalid handle or a signature too short, it returns NULL and sets count
+// to -1.
+//
+//export minhashlsh_query
+func minhashlsh_query(h C.int64_t, sig *C.uint64_t, n C.int, count *C.int) (results **C.char) {
+	defer func() {
+		if recover() != nil {
+			if results != nil {
+				C.free(unsafe.Pointer(results))
+			}
+			results, *count = nil, -1
+		}
+	}()
+	*count = 0
+	lsh := lookup(h)
+	if lsh == nil || checkSignature(lsh, n) != "" {
+		*count = -1
+		return nil
+	}
+	keys := lsh.Query(signature(sig, n))
+	if len(keys) == 0 {
+		return nil
+	}
+	results = (**C.char)(C.malloc(C.size_t(len(keys)) * C.size_t(unsafe.Sizeof((*C.char)(nil)))))
+	array := (*[1 << 30]*C.char)(unsafe.Pointer(results))[:len(keys):len(keys)]
+	for i, key := range keys {
+		array[i] = C.CString(fmt.Sprint(key))
+	}
+	*count = C.int(len(keys))
+	return results
+}
+
+//export minhashlsh_free_results
+func minhashlsh_free_results(results **C.char, count C.int) {
+	defer func() { recover() }()
+	if results == nil || count < 0 {
+		return
+	}
+	array := (*[1 << 30]*C.char)(unsafe.Pointer(results))[:count:count]
+	for _, s := range array {
+		C.free(unsafe.Pointer(s))
+	}
+	C.free(unsafe.Pointer(results))
+}
+
+//export minhashlsh_save
+func minhashlsh_save(h C.int64_t, filename *C.char) (msg *C.char) {
+	defer recoverError(&msg)
+	lsh := lookup(h)
+	if lsh == nil {
+		return cError(errInvalidHandle)
+	}
+	if err := lsh.Save(C.GoString(filename)); err != nil {
+		return cError(err.Error())
+	}
+	return nil
+}
+
+// minhashlsh_load loads the index and stores its handle in h.
+//
+//export minhashlsh_load
+func minhashlsh_load(filename *C.char, h *C.int64_t) (msg *C.char) {
+	defer recoverError(&msg)
+	lsh, err := minhashlsh.Load(C.GoString(filename))
+	if err != nil {
+		return cError(err.Error())
+	}
+	*h = register(lsh)
+	return nil
+}
+
+//export minhashlsh_free_string
+func minhashlsh_free_string(s *C.char) {
+	defer func() { recover() }()
+	C.free(unsafe.Pointer(s))
+}
+
+func main() {}
@@ -0,0 +1,67 @@
+package minhashlsh
+
+import "math"
+
+// TruncationStats describes how often distinct band values of the indexed
+// signatures share a hash key only because hash values are truncated to
+// the hash value size.
+type TruncationStats struct {
+	// DistinctValues is the number of distinct full band values,
+	// summed over bands.
+	DistinctValues int
+	// Collisions is the number of distinct full band values merged into
+	// the hash key of another one, summed over bands.
+	Collisions int
+	// Rate is Collisions divided by DistinctValues.
+	Rate float64
+	// ExpectedRate is the rate expected from uniformly distributed
+	// hash values.
+	ExpectedRate float64
+}
+
+// TruncationCollisions compares the hash keys of every band with the full
+// band values of the stored signatures. A high rate means that candidates
+// are found because of truncation rather than similarity, and that wider
+// hash values should be used; a rate much higher than ExpectedRate points
+// to a hash function with weak bits, see WithTrimPolicy.
+// ErrNoSignatures is returned unless the index stores signatures.
+func (f *MinhashLSH) TruncationCollisions() (TruncationStats, error) {
+	if f.signatures == nil {
+		return TruncationStats{}, ErrNoSignatures
+	}
+	var stats TruncationStats
+	var expected float64
+	fullKey := hashKeyFuncGen(8)
+	for i := 0; i < f.L; i++ {
+		values := make(map[string]struct{})
+		hashKeys := make(map[string]struct{})
+		for j, e := range f.table(i) {
+			sig, exist := f.signatures[e.Key]
+			if !exist || f.isHiddenAt(e.Key, j) {
+				continue
+			}
+			values[fullKey(f.band(sig, i))] = struct{}{}
+			hashKeys[e.HashKey] = struct{}{}
+		}
+		stats.DistinctValues += len(values)
+		stats.Collisions += len(values) - len(hashKeys)
+		expected += float64(len(values)) * f.expectedCollisionRate(len(values))
+	}
+	if stats.DistinctValues > 0 {
+		stats.Rate = float64(stats.Collisions) / float64(stats.DistinctValues)
+		stats.ExpectedRate = expected / float64(stats.DistinctValues)
+	}
+	return stats, nil
+}
+
+// expectedCollisionRate returns the expected fraction of n distinct
+// uniformly distributed band values lost to hash key collisions.
+func (f *MinhashLSH) expectedCollisionRate(n int) float64 {
+	if n == 0 || f.HashValueSize >= 8 {
+		return 0
+	}
+	// The number of occupied hash keys among m is m(1-(1-1/m)^n).
+	m := math.Pow(2, float64(8*f.K*f.HashValueSize))
+	occupied := -m * math.Expm1(float64(n)*math.Log1p(-1/m))
+	return 1 - occupied/float64(n)
+}
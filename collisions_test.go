@@ -0,0 +1,37 @@
+package minhashlsh
+
+import (
+	"math"
+	"testing"
+)
+
+func Test_TruncationCollisions(t *testing.T) {
+	// One hash value per band of 16 bits: 4000 random values collide often.
+	f := NewMinhashLSH16(4, 0.5, 0, WithParams(1, 4), WithSignatureStorage())
+	for i := 0; i < 4000; i++ {
+		f.Add(i, randomSignature(4, int64(i)))
+	}
+	f.Index()
+	stats, err := f.TruncationCollisions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Collisions == 0 || stats.ExpectedRate == 0 {
+		t.Fatalf("expected collisions, got %+v", stats)
+	}
+	if math.Abs(stats.Rate-stats.ExpectedRate) > 0.01 {
+		t.Errorf("rate %f far from the expected rate %f", stats.Rate, stats.ExpectedRate)
+	}
+
+	g := NewMinhashLSH64(4, 0.5, 0, WithParams(1, 4), WithSignatureStorage())
+	for i := 0; i < 4000; i++ {
+		g.Add(i, randomSignature(4, int64(i)))
+	}
+	g.Index()
+	if stats, _ := g.TruncationCollisions(); stats.Collisions != 0 || stats.ExpectedRate != 0 {
+		t.Errorf("expected no collisions of 64-bit hash values, got %+v", stats)
+	}
+	if _, err := testIndex(1).TruncationCollisions(); err != ErrNoSignatures {
+		t.Errorf("expected ErrNoSignatures, got %v", err)
+	}
+}
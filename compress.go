@@ -0,0 +1,139 @@
+package minhashlsh
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Compression IDs recorded in index files.
+const (
+	CompressionNone uint8 = iota
+	CompressionGzip
+	CompressionZstd
+)
+
+// Compressor compresses serialized indexes on Save and decompresses them
+// on Load. Gzip, zstd and no compression are built in, other compressors
+// can be supplied using WithCompression to both Save and Load.
+type Compressor interface {
+	// ID identifies the compression format in index files.
+	ID() uint8
+	NewWriter(w io.Writer) (io.WriteCloser, error)
+	NewReader(r io.Reader) (io.ReadCloser, error)
+}
+
+// WithCompression sets the compression used by Save,
+// the default is gzip with the default compression level.
+// When given to Load, the compressor is used for files with
+// a matching compression ID.
+func WithCompression(c Compressor) PersistOption {
+	return func(config *persistConfig) {
+		config.compressor = c
+	}
+}
+
+type gzipCompressor struct {
+	level int
+}
+
+// GzipCompressor returns a gzip Compressor using the given compression level,
+// from gzip.BestSpeed to gzip.BestCompression, or gzip.DefaultCompression.
+func GzipCompressor(level int) Compressor {
+	return gzipCompressor{level}
+}
+
+func (gzipCompressor) ID() uint8 { return CompressionGzip }
+
+func (c gzipCompressor) NewWriter(w io.Writer) (io.WriteCloser, error) {
+	return gzip.NewWriterLevel(w, c.level)
+}
+
+func (gzipCompressor) NewReader(r io.Reader) (io.ReadCloser, error) {
+	return gzip.NewReader(r)
+}
+
+type zstdCompressor struct {
+	level int
+}
+
+// ZstdCompressor returns a zstd Compressor using the given zstd compression
+// level, from 1 to 22, 3 being the default of zstd. Zstd is faster than
+// gzip for smaller files, both to save and to load large indexes.
+func ZstdCompressor(level int) Compressor {
+	return zstdCompressor{level}
+}
+
+func (zstdCompressor) ID() uint8 { return CompressionZstd }
+
+// Sections are compressed concurrently by WithParallelism, so each encoder
+// and decoder uses a single goroutine.
+func (c zstdCompressor) NewWriter(w io.Writer) (io.WriteCloser, error) {
+	return zstd.NewWriter(w, zstd.WithEncoderLevel(zstd.EncoderLevelFromZstd(c.level)),
+		zstd.WithEncoderConcurrency(1))
+}
+
+func (zstdCompressor) NewReader(r io.Reader) (io.ReadCloser, error) {
+	d, err := zstd.NewReader(r, zstd.WithDecoderConcurrency(1))
+	if err != nil {
+		return nil, err
+	}
+	return zstdReader{d}, nil
+}
+
+// zstdReader adapts a zstd decoder, whose Close returns no error.
+type zstdReader struct {
+	*zstd.Decoder
+}
+
+func (r zstdReader) Close() error {
+	r.Decoder.Close()
+	return nil
+}
+
+type noCompressor struct{}
+
+// NoCompression stores indexes uncompressed, trading file size for
+// the fastest Save and Load.
+var NoCompression Compressor = noCompressor{}
+
+func (noCompressor) ID() uint8 { return CompressionNone }
+
+func (noCompressor) NewWriter(w io.Writer) (io.WriteCloser, error) {
+	return nopWriteCloser{w}, nil
+}
+
+func (noCompressor) NewReader(r io.Reader) (io.ReadCloser, error) {
+	return nopReadCloser{r}, nil
+}
+
+type nopReadCloser struct {
+	io.Reader
+}
+
+func (nopReadCloser) Close() error { return nil }
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error { return nil }
+
+// compressorFor returns the compressor for the compression ID of an index
+// file, preferring the one supplied by the caller.
+func compressorFor(id uint8, supplied Compressor) (Compressor, error) {
+	if supplied != nil && supplied.ID() == id {
+		return supplied, nil
+	}
+	switch id {
+	case CompressionNone:
+		return NoCompression, nil
+	case CompressionGzip:
+		return GzipCompressor(gzip.DefaultCompression), nil
+	case CompressionZstd:
+		return ZstdCompressor(3), nil
+	}
+	return nil, fmt.Errorf("minhashlsh: unsupported compression %d, use WithCompression to supply a compressor", id)
+}
@@ -0,0 +1,75 @@
+package minhashlsh
+
+// maxCountScan is the number of bucket entries up to which Count finds the
+// distinct candidates by scanning the entries seen before, beyond which it
+// falls back to a set of candidates.
+const maxCountScan = 256
+
+// Count returns the number of distinct candidate keys given the query
+// signature, the length of the result of Query, without allocating the
+// results, for monitoring or gating logic that only needs the count.
+// The keys of small buckets are compared with each other instead of being
+// deduplicated by a map; buckets of more than a few hundred entries in
+// total are deduplicated by a set. Candidate counts are not updated.
+func (f *MinhashLSH) Count(sig []uint64) int {
+	var array [16]hashTable
+	buckets := array[:0]
+	total := 0
+	b := acquireKeyBuffer(0)
+	for i := 0; i < f.L; i++ {
+		var bucket hashTable
+		if f.appendKey != nil {
+			*b = f.appendKey((*b)[:0], f.band(sig, i))
+			bucket = f.bucketBytes(i, *b)
+		} else {
+			bucket = f.bucket(i, f.HashKeyFunc(f.band(sig, i)))
+		}
+		buckets = append(buckets, bucket)
+		total += len(bucket)
+	}
+	releaseKeyBuffer(b)
+	if total > maxCountScan {
+		var results candidateSet
+		for _, bucket := range buckets {
+			f.collect(&results, bucket)
+		}
+		return results.len()
+	}
+	// The step of each bucket is found once, as finding it counts capped
+	// buckets.
+	var stepArray [16]int
+	steps := stepArray[:0]
+	for _, bucket := range buckets {
+		steps = append(steps, f.bucketCap.step(len(bucket)))
+	}
+	count := 0
+	for i, bucket := range buckets {
+		for j := 0; j < len(bucket); j += steps[i] {
+			key := bucket[j].Key
+			if !f.isHidden(key) && !seenBefore(key, buckets[:i+1], steps, j) {
+				count++
+			}
+		}
+	}
+	return count
+}
+
+// seenBefore reports whether a key is in the collected entries of the
+// buckets before the last one, or in the ones of the last bucket before
+// position j, visiting each bucket with its step.
+func seenBefore(key interface{}, buckets []hashTable, steps []int, j int) bool {
+	last := len(buckets) - 1
+	for k := 0; k < j; k += steps[last] {
+		if buckets[last][k].Key == key {
+			return true
+		}
+	}
+	for i, bucket := range buckets[:last] {
+		for k := 0; k < len(bucket); k += steps[i] {
+			if bucket[k].Key == key {
+				return true
+			}
+		}
+	}
+	return false
+}
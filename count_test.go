@@ -0,0 +1,56 @@
+package minhashlsh
+
+import (
+	"strconv"
+	"testing"
+)
+
+func Test_Count(t *testing.T) {
+	f := testIndex(100)
+	f.Remove("3")
+	f.SoftDelete("5")
+	// Keys added twice are in their buckets twice.
+	f.Add("1", randomSignature(64, 1))
+	// A bucket too large to scan in every band.
+	sig := randomSignature(64, 1000)
+	for i := 0; i < 300; i++ {
+		f.Add("dup"+strconv.Itoa(i%150), sig)
+	}
+	f.Index()
+	if n := f.Count(sig); n != 150 {
+		t.Errorf("expected 150 candidates, got %d", n)
+	}
+	for i := 0; i < 100; i++ {
+		sig := randomSignature(64, int64(i))
+		if n := len(f.Query(sig)); f.Count(sig) != n {
+			t.Fatalf("signature %d: expected %d candidates, got %d", i, n, f.Count(sig))
+		}
+	}
+
+	if raceEnabled {
+		return
+	}
+	sig = randomSignature(64, 10)
+	allocs := testing.AllocsPerRun(100, func() {
+		f.Count(sig)
+	})
+	if allocs > 0 {
+		t.Errorf("expected no allocations, got %.0f", allocs)
+	}
+}
+
+func Test_CountCappedBuckets(t *testing.T) {
+	f := NewMinhashLSH16(64, 0.5, 0, WithBucketCap(4))
+	sig := randomSignature(64, 1)
+	// Few enough entries for Count to scan them.
+	for i := 0; i < 10; i++ {
+		f.Add(i, sig)
+	}
+	f.Index()
+	f.Query(sig)
+	queried := f.CappedBuckets()
+	f.Count(sig)
+	if counted := f.CappedBuckets() - queried; counted != queried {
+		t.Errorf("expected %d capped buckets counted by Count, got %d", queried, counted)
+	}
+}
@@ -0,0 +1,51 @@
+package minhashlsh
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+)
+
+// ErrInvalidLeanMinHash is returned when decoding a malformed LeanMinHash.
+var ErrInvalidLeanMinHash = errors.New("minhashlsh: invalid LeanMinHash")
+
+// EncodeLeanMinHash encodes a signature in the byte layout of
+// LeanMinHash.serialize of the Python datasketch library: the seed as
+// an int64, the number of hash values as an int32, then each hash value as
+// a uint32, in the given byte order; datasketch defaults to the native one,
+// little-endian on common platforms. datasketch hash values are 32-bit,
+// so a hash value that does not fit in 32 bits is an error.
+func EncodeLeanMinHash(seed int64, sig []uint64, order binary.ByteOrder) ([]byte, error) {
+	b := make([]byte, 12+4*len(sig))
+	order.PutUint64(b, uint64(seed))
+	order.PutUint32(b[8:], uint32(len(sig)))
+	for i, v := range sig {
+		if v > math.MaxUint32 {
+			return nil, errors.New("minhashlsh: hash value does not fit in 32 bits")
+		}
+		order.PutUint32(b[12+4*i:], uint32(v))
+	}
+	return b, nil
+}
+
+// DecodeLeanMinHash decodes the seed and signature of a LeanMinHash
+// serialized by the Python datasketch library, see EncodeLeanMinHash.
+// Signatures of datasketch are computed by its own hash functions: they
+// can only be compared with signatures sketched by datasketch with the
+// same seed and number of permutations, and fit in indexes using 32-bit
+// hash values, see NewMinhashLSH32.
+func DecodeLeanMinHash(b []byte, order binary.ByteOrder) (seed int64, sig []uint64, err error) {
+	if len(b) < 12 {
+		return 0, nil, ErrInvalidLeanMinHash
+	}
+	seed = int64(order.Uint64(b))
+	n := int32(order.Uint32(b[8:]))
+	if n < 0 || int64(len(b)) != 12+4*int64(n) {
+		return 0, nil, ErrInvalidLeanMinHash
+	}
+	sig = make([]uint64, n)
+	for i := range sig {
+		sig[i] = uint64(order.Uint32(b[12+4*i:]))
+	}
+	return seed, sig, nil
+}
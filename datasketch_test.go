@@ -0,0 +1,39 @@
+package minhashlsh
+
+import (
+	"bytes"
+	"encoding/binary"
+	"reflect"
+	"testing"
+)
+
+func Test_LeanMinHash(t *testing.T) {
+	// struct.pack("<qi2I", 1, 2, 1, 0xfffffffe)
+	serialized := []byte{
+		1, 0, 0, 0, 0, 0, 0, 0,
+		2, 0, 0, 0,
+		1, 0, 0, 0,
+		0xfe, 0xff, 0xff, 0xff,
+	}
+	seed, sig, err := DecodeLeanMinHash(serialized, binary.LittleEndian)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if seed != 1 || !reflect.DeepEqual(sig, []uint64{1, 0xfffffffe}) {
+		t.Errorf("unexpected seed %d and signature %v", seed, sig)
+	}
+	b, err := EncodeLeanMinHash(seed, sig, binary.LittleEndian)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(b, serialized) {
+		t.Errorf("expected %v, got %v", serialized, b)
+	}
+
+	if _, _, err := DecodeLeanMinHash(serialized[:16], binary.LittleEndian); err != ErrInvalidLeanMinHash {
+		t.Errorf("expected ErrInvalidLeanMinHash, got %v", err)
+	}
+	if _, err := EncodeLeanMinHash(1, []uint64{1 << 32}, binary.LittleEndian); err == nil {
+		t.Error("expected an error encoding a 64-bit hash value")
+	}
+}
@@ -0,0 +1,91 @@
+package minhashlsh
+
+import (
+	"math"
+	"time"
+)
+
+// hashValues sets dst to the hash values of b, the signature of the set
+// holding b alone.
+func (m *Minhash) hashValues(b []byte, dst []uint64) {
+	for i := range dst {
+		dst[i] = math.MaxUint64
+	}
+	if m.family != nil {
+		m.family.push(b, dst)
+		return
+	}
+	v1, v2 := m.h1(b), m.h2(b)
+	for i := range dst {
+		dst[i] = v1 + uint64(i)*v2
+	}
+}
+
+// timedMin is a hash value pushed at a time.
+type timedMin struct {
+	value uint64
+	t     time.Time
+}
+
+// DecayedMinhash is a MinHash of the values pushed within a sliding window
+// of time, so signatures follow recent behavior, such as the last hour of
+// activity of a user, without sketching the window again as it slides.
+// For each hash function, it keeps the hash values smaller than the ones of
+// all the values pushed after them, in time order, about the logarithm of
+// the number of values in the window: once the older ones are out of the
+// window, the oldest left is the minimum.
+type DecayedMinhash struct {
+	mh     *Minhash
+	window time.Duration
+	minima [][]timedMin
+	values []uint64
+}
+
+// NewDecayedMinhash creates a MinHash of the values pushed within the given
+// window, whose signatures are the ones of a Minhash with the same seed,
+// number of hash functions and options of these values.
+func NewDecayedMinhash(seed int64, numHash int, window time.Duration, opts ...MinhashOption) *DecayedMinhash {
+	return &DecayedMinhash{
+		mh:     NewMinhash(seed, numHash, opts...),
+		window: window,
+		minima: make([][]timedMin, numHash),
+		values: make([]uint64, numHash),
+	}
+}
+
+// Push pushes a value at a time, which must not be before the time of the
+// values pushed before.
+func (d *DecayedMinhash) Push(b []byte, t time.Time) {
+	d.mh.hashValues(b, d.values)
+	for i, v := range d.values {
+		minima := d.minima[i]
+		for len(minima) > 0 && minima[len(minima)-1].value >= v {
+			minima = minima[:len(minima)-1]
+		}
+		d.minima[i] = append(minima, timedMin{v, t})
+	}
+}
+
+// Signature returns the signature of the values pushed within the window
+// ending at now, later than now minus the window. The values out of the
+// window are dropped, so now must not be before the now of previous calls.
+func (d *DecayedMinhash) Signature(now time.Time) []uint64 {
+	start := now.Add(-d.window)
+	sig := make([]uint64, len(d.minima))
+	for i, minima := range d.minima {
+		j := 0
+		for j < len(minima) && !minima[j].t.After(start) {
+			j++
+		}
+		// Drop the expired values without keeping the array growing.
+		if j > 0 {
+			minima = append(minima[:0], minima[j:]...)
+			d.minima[i] = minima
+		}
+		sig[i] = math.MaxUint64
+		if len(minima) > 0 {
+			sig[i] = minima[0].value
+		}
+	}
+	return sig
+}
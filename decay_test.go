@@ -0,0 +1,44 @@
+package minhashlsh
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func Test_DecayedMinhash(t *testing.T) {
+	tokens := randomTokens(1000, 1)
+	start := time.Unix(0, 0)
+	window := 100 * time.Second
+	for _, opts := range [][]MinhashOption{nil, {WithUniversalHashing()}} {
+		d := NewDecayedMinhash(1, 64, window, opts...)
+		for i, token := range tokens {
+			d.Push(token, start.Add(time.Duration(i)*time.Second))
+			if i%97 != 0 {
+				continue
+			}
+			// The window holds the last 100 tokens.
+			from := i - 99
+			if from < 0 {
+				from = 0
+			}
+			mh := NewMinhash(1, 64, opts...)
+			for _, token := range tokens[from : i+1] {
+				mh.Push(token)
+			}
+			now := start.Add(time.Duration(i) * time.Second)
+			if !reflect.DeepEqual(d.Signature(now), mh.Signature()) {
+				t.Fatalf("signature at %d differs from the one of the window", i)
+			}
+		}
+		for i, minima := range d.minima {
+			if len(minima) > 30 {
+				t.Errorf("hash function %d keeps %d values", i, len(minima))
+			}
+		}
+		empty := d.Signature(start.Add(2000 * time.Second))
+		if !reflect.DeepEqual(empty, NewMinhash(1, 64, opts...).Signature()) {
+			t.Error("expected the signature of an empty set once all values expired")
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package minhashlsh
+
+// WithDeduplication makes Index collapse the entries of a key added several
+// times with the same signature, such as by retried or replayed Adds, which
+// otherwise stay in every band forever. As every key must have as many
+// entries in all bands, a key added again with a different signature keeps
+// its entries in the bands where they differ.
+func WithDeduplication() Option {
+	return func(f *MinhashLSH) {
+		f.dedupe = true
+	}
+}
+
+// collapseDuplicates drops duplicate (key, hash key) entries of the sorted
+// tables. The same number of entries of a key is dropped in every band,
+// the smallest number of duplicates of the key over bands.
+func (f *MinhashLSH) collapseDuplicates() {
+	var drops map[interface{}]int
+	for i, table := range f.HashTables {
+		counts := make(map[interface{}]int)
+		forEachBucket(table, func(bucket hashTable) {
+			seen := make(map[interface{}]struct{}, len(bucket))
+			for _, e := range bucket {
+				if _, exist := seen[e.Key]; exist {
+					counts[e.Key]++
+				} else {
+					seen[e.Key] = struct{}{}
+				}
+			}
+		})
+		if i == 0 {
+			drops = counts
+			continue
+		}
+		for key, n := range drops {
+			if counts[key] < n {
+				drops[key] = counts[key]
+			}
+		}
+	}
+	for key, n := range drops {
+		if n == 0 || key == (removedEntry{}) {
+			delete(drops, key)
+		}
+	}
+	if len(drops) == 0 {
+		return
+	}
+	f.forEachBand(func(i int) {
+		remaining := make(map[interface{}]int, len(drops))
+		for key, n := range drops {
+			remaining[key] = n
+		}
+		table := f.HashTables[i]
+		kept := table[:0]
+		forEachBucket(table, func(bucket hashTable) {
+			seen := make(map[interface{}]struct{}, len(bucket))
+			for _, e := range bucket {
+				if _, exist := seen[e.Key]; exist && remaining[e.Key] > 0 {
+					remaining[e.Key]--
+					continue
+				}
+				seen[e.Key] = struct{}{}
+				kept = append(kept, e)
+			}
+		})
+		// Release the dropped keys.
+		for j := len(kept); j < len(table); j++ {
+			table[j] = entry{}
+		}
+		f.HashTables[i] = kept
+	})
+}
+
+// forEachBucket calls fn with the runs of entries of a sorted table
+// sharing a hash key, in order.
+func forEachBucket(table hashTable, fn func(bucket hashTable)) {
+	for j := 0; j < len(table); {
+		k := j + 1
+		for k < len(table) && table[k].HashKey == table[j].HashKey {
+			k++
+		}
+		fn(table[j:k])
+		j = k
+	}
+}
@@ -0,0 +1,31 @@
+package minhashlsh
+
+import "testing"
+
+func Test_Deduplication(t *testing.T) {
+	f := NewMinhashLSH64(64, 0.5, 0, WithDeduplication())
+	sig := randomSignature(64, 1)
+	for i := 0; i < 3; i++ {
+		f.Add("dup", sig)
+	}
+	// Added again with a signature differing in the first band only.
+	other := append([]uint64(nil), sig...)
+	other[0]++
+	f.Add("changed", sig)
+	f.Add("changed", other)
+	f.Add("key", randomSignature(64, 2))
+	f.Index()
+	// dup collapses to one entry per band, changed keeps two.
+	if f.NumIndexedKeys != 4 {
+		t.Errorf("expected 4 entries per band, got %d", f.NumIndexedKeys)
+	}
+	for i, table := range f.HashTables {
+		if len(table) != f.NumIndexedKeys {
+			t.Errorf("band %d has %d entries", i, len(table))
+		}
+	}
+	results := f.Query(sig)
+	if len(results) != 2 || !contains(results, "dup") || !contains(results, "changed") {
+		t.Errorf("unexpected results %v", results)
+	}
+}
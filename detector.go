@@ -0,0 +1,100 @@
+package minhashlsh
+
+// Detector finds near-duplicates in an unbounded stream of documents: each
+// document is checked against all the documents seen before, then indexed.
+// Newly seen documents are looked up in per-band hash maps, and merged into
+// the sorted hash tables of the index once they reach a fraction of it, so
+// the cost of sorting is amortized.
+type Detector struct {
+	lsh     *MinhashLSH
+	pending []map[string][]interface{}
+	count   int
+}
+
+// Document is a key and the signature of its content.
+type Document struct {
+	Key       interface{}
+	Signature []uint64
+}
+
+// Detection reports the near-duplicates of a document seen before it.
+type Detection struct {
+	Key        interface{}
+	Duplicates []interface{}
+}
+
+// minPending is the number of pending documents below which they are
+// not merged into the index.
+const minPending = 1024
+
+// NewDetector creates a detector adding documents to lsh, which may already
+// hold indexed documents.
+func NewDetector(lsh *MinhashLSH) *Detector {
+	lsh.Index()
+	d := &Detector{lsh: lsh}
+	d.reset()
+	return d
+}
+
+func (d *Detector) reset() {
+	d.pending = make([]map[string][]interface{}, d.lsh.L)
+	for i := range d.pending {
+		d.pending[i] = make(map[string][]interface{})
+	}
+	d.count = 0
+}
+
+// Observe returns the candidate near-duplicates of a document among the
+// documents seen before, and adds it.
+func (d *Detector) Observe(key interface{}, sig []uint64) []interface{} {
+	hashKeys := d.lsh.hashKeys(sig)
+	results := d.candidates(hashKeys)
+	d.add(key, sig, hashKeys)
+	return results
+}
+
+// candidates returns the documents seen before sharing a hash key.
+func (d *Detector) candidates(hashKeys []string) []interface{} {
+	set := d.lsh.queryHashKeys(hashKeys)
+	for i, hashKey := range hashKeys {
+		for _, k := range d.pending[i][hashKey] {
+			if !d.lsh.isHidden(k) {
+				set.add(k)
+			}
+		}
+	}
+	return set.keys
+}
+
+// add adds a document with the hash keys of its signature.
+func (d *Detector) add(key interface{}, sig []uint64, hashKeys []string) {
+	d.lsh.Add(key, sig)
+	for i, hashKey := range hashKeys {
+		d.pending[i][hashKey] = append(d.pending[i][hashKey], key)
+	}
+	d.count++
+	if d.count >= minPending && d.count >= d.lsh.NumIndexedKeys/8 {
+		d.lsh.Index()
+		d.reset()
+	}
+}
+
+// Detect observes the documents of a stream, reporting the near-duplicates
+// of each of them in order. The output is closed once the input is.
+func (d *Detector) Detect(docs <-chan Document) <-chan Detection {
+	out := make(chan Detection)
+	go func() {
+		defer close(out)
+		for doc := range docs {
+			out <- Detection{doc.Key, d.Observe(doc.Key, doc.Signature)}
+		}
+	}()
+	return out
+}
+
+// Index merges the pending documents into the index, for example before
+// saving it.
+func (d *Detector) Index() {
+	d.lsh.Index()
+	d.reset()
+}
@@ -0,0 +1,39 @@
+package minhashlsh
+
+import "testing"
+
+func Test_Detector(t *testing.T) {
+	d := NewDetector(NewMinhashLSH16(64, 0.5, 0))
+	docs := make(chan Document)
+	go func() {
+		defer close(docs)
+		for i := 0; i < 3000; i++ {
+			// Every document after the first 2000 duplicates an earlier one.
+			seed := int64(i)
+			if i >= 2000 {
+				seed = int64(i - 1500)
+			}
+			docs <- Document{i, randomSignature(64, seed)}
+		}
+	}()
+	for detection := range d.Detect(docs) {
+		i := detection.Key.(int)
+		found := false
+		for _, key := range detection.Duplicates {
+			found = found || key == i-1500
+			if key == i {
+				t.Errorf("document %d reported as its own duplicate", i)
+			}
+		}
+		if i >= 2000 && !found {
+			t.Errorf("duplicate of document %d not detected", i)
+		}
+		if i < 2000 && found {
+			t.Errorf("document %d wrongly detected", i)
+		}
+	}
+	d.Index()
+	if d.lsh.NumIndexedKeys != 3000 {
+		t.Errorf("expected 3000 indexed documents, got %d", d.lsh.NumIndexedKeys)
+	}
+}
@@ -0,0 +1,95 @@
+package minhashlsh
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// IndexDiff reports the differences between two indexes.
+type IndexDiff struct {
+	// Params describes the parameters that differ, such as "K: 4 != 8".
+	// Band entries are only compared when the parameters match.
+	Params []string
+	// OnlyA and OnlyB hold the keys only in the first or second index.
+	OnlyA []interface{}
+	OnlyB []interface{}
+	// Changed holds the keys of both indexes whose band entries differ.
+	Changed []interface{}
+}
+
+// Equal returns true if the indexes have no differences.
+func (d IndexDiff) Equal() bool {
+	return len(d.Params) == 0 && len(d.OnlyA) == 0 && len(d.OnlyB) == 0 && len(d.Changed) == 0
+}
+
+// Diff compares the keys and band entries of two indexes, indexed or not,
+// ignoring removed and soft-deleted keys, such as to validate a rebuild or
+// a migration. Lazily loaded indexes are loaded first.
+func Diff(a, b *MinhashLSH) (IndexDiff, error) {
+	var d IndexDiff
+	if err := a.Materialize(); err != nil {
+		return d, err
+	}
+	if err := b.Materialize(); err != nil {
+		return d, err
+	}
+	param := func(name string, x, y interface{}) {
+		if x != y {
+			d.Params = append(d.Params, fmt.Sprintf("%s: %v != %v", name, x, y))
+		}
+	}
+	param("K", a.K, b.K)
+	param("L", a.L, b.L)
+	param("HashValueSize", a.HashValueSize, b.HashValueSize)
+	param("salted", a.salt != 0, b.salt != 0)
+	if a.salt != 0 && b.salt != 0 {
+		param("salt", a.salt, b.salt)
+	}
+	param("TrimPolicy", a.trim, b.trim)
+
+	entriesA, entriesB := a.keyEntries(), b.keyEntries()
+	for key, entries := range entriesA {
+		other, exist := entriesB[key]
+		if !exist {
+			d.OnlyA = append(d.OnlyA, key)
+		} else if len(d.Params) == 0 && entries != other {
+			d.Changed = append(d.Changed, key)
+		}
+	}
+	for key := range entriesB {
+		if _, exist := entriesA[key]; !exist {
+			d.OnlyB = append(d.OnlyB, key)
+		}
+	}
+	return d, nil
+}
+
+// keyEntries returns the band hash keys of every visible key, sorted
+// within each band and joined, so that equal strings mean equal entries.
+func (f *MinhashLSH) keyEntries() map[interface{}]string {
+	bands := make(map[interface{}][][]string)
+	for i, table := range f.HashTables {
+		for j, e := range table {
+			if f.isHiddenAt(e.Key, j) {
+				continue
+			}
+			hashKeys := bands[e.Key]
+			if hashKeys == nil {
+				hashKeys = make([][]string, f.L)
+				bands[e.Key] = hashKeys
+			}
+			hashKeys[i] = append(hashKeys[i], e.HashKey)
+		}
+	}
+	entries := make(map[interface{}]string, len(bands))
+	for key, hashKeys := range bands {
+		joined := make([]string, len(hashKeys))
+		for i := range hashKeys {
+			sort.Strings(hashKeys[i])
+			joined[i] = strings.Join(hashKeys[i], ",")
+		}
+		entries[key] = strings.Join(joined, ";")
+	}
+	return entries
+}
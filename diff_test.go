@@ -0,0 +1,35 @@
+package minhashlsh
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_Diff(t *testing.T) {
+	a, b := testIndex(10), testIndex(10)
+	if d, err := Diff(a, b); err != nil || !d.Equal() {
+		t.Fatalf("expected equal indexes, got %+v, %v", d, err)
+	}
+	a.Remove("0")
+	b.Remove("1")
+	b.Remove("2")
+	b.Add("2", randomSignature(64, 100))
+	b.Index()
+	d, err := Diff(a, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := IndexDiff{
+		OnlyA:   []interface{}{"1"},
+		OnlyB:   []interface{}{"0"},
+		Changed: []interface{}{"2"},
+	}
+	if !reflect.DeepEqual(d, expected) {
+		t.Errorf("expected %+v, got %+v", expected, d)
+	}
+
+	c := NewMinhashLSH32(64, 0.5, 0)
+	if d, _ := Diff(a, c); !reflect.DeepEqual(d.Params, []string{"HashValueSize: 2 != 4"}) {
+		t.Errorf("unexpected parameter differences %v", d.Params)
+	}
+}
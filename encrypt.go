@@ -0,0 +1,206 @@
+package minhashlsh
+
+import (
+	"bufio"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+// The serialized index is encrypted in chunks so that neither Save nor Load
+// need to hold the whole ciphertext in memory. Each chunk is sealed with
+// AES-GCM using a nonce made of a random per-file prefix, the chunk counter
+// and a flag marking the final chunk, which detects reordered, dropped
+// and truncated chunks. The file header and the section number are
+// authenticated with every chunk, which detects sections swapped or copied
+// from another file, and a tampered header.
+const (
+	encryptionChunkSize = 64 * 1024
+	noncePrefixSize     = 7
+)
+
+var (
+	// ErrEncryptionKeyRequired is returned by Load when the index file is
+	// encrypted but no key was supplied.
+	ErrEncryptionKeyRequired = errors.New("minhashlsh: index is encrypted, encryption key required")
+	// ErrNotEncrypted is returned by Load when an encryption key was supplied
+	// but the index file is not encrypted.
+	ErrNotEncrypted = errors.New("minhashlsh: index is not encrypted")
+)
+
+// WithEncryptionKey encrypts the saved index using AES-GCM, or decrypts it
+// on load. The key must be 16, 24 or 32 bytes long,
+// selecting AES-128, AES-192 or AES-256 respectively.
+func WithEncryptionKey(key []byte) PersistOption {
+	return func(c *persistConfig) {
+		c.encryptionKey = key
+	}
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// associatedData returns the data authenticated with the chunks of
+// a section: the file header followed by the section number.
+func (c *persistConfig) associatedData(section int) []byte {
+	ad := make([]byte, len(c.header)+4)
+	copy(ad, c.header)
+	binary.LittleEndian.PutUint32(ad[len(c.header):], uint32(section))
+	return ad
+}
+
+// setChunkNonce fills the nonce for the chunk with the given counter.
+func setChunkNonce(nonce []byte, counter uint32, last bool) {
+	binary.BigEndian.PutUint32(nonce[noncePrefixSize:], counter)
+	nonce[len(nonce)-1] = 0
+	if last {
+		nonce[len(nonce)-1] = 1
+	}
+}
+
+// encryptWriter encrypts everything written to it, the final chunk is
+// written on Close.
+type encryptWriter struct {
+	w       io.Writer
+	aead    cipher.AEAD
+	nonce   []byte
+	ad      []byte
+	counter uint32
+	buf     []byte
+	out     []byte
+}
+
+func newEncryptWriter(w io.Writer, key, ad []byte) (*encryptWriter, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce[:noncePrefixSize]); err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(nonce[:noncePrefixSize]); err != nil {
+		return nil, err
+	}
+	return &encryptWriter{
+		w:     w,
+		aead:  aead,
+		nonce: nonce,
+		ad:    ad,
+		buf:   make([]byte, 0, encryptionChunkSize),
+		out:   make([]byte, 0, encryptionChunkSize+aead.Overhead()),
+	}, nil
+}
+
+func (e *encryptWriter) Write(p []byte) (int, error) {
+	var n int
+	for len(p) > 0 {
+		if len(e.buf) == cap(e.buf) {
+			if err := e.flush(false); err != nil {
+				return n, err
+			}
+		}
+		m := copy(e.buf[len(e.buf):cap(e.buf)], p)
+		e.buf = e.buf[:len(e.buf)+m]
+		p = p[m:]
+		n += m
+	}
+	return n, nil
+}
+
+func (e *encryptWriter) flush(last bool) error {
+	if e.counter == ^uint32(0) {
+		return errors.New("minhashlsh: index too large to encrypt")
+	}
+	setChunkNonce(e.nonce, e.counter, last)
+	e.out = e.aead.Seal(e.out[:0], e.nonce, e.buf, e.ad)
+	e.counter++
+	e.buf = e.buf[:0]
+	_, err := e.w.Write(e.out)
+	return err
+}
+
+// Close writes the final chunk. It does not close the underlying writer.
+func (e *encryptWriter) Close() error {
+	return e.flush(true)
+}
+
+// decryptReader decrypts and authenticates the chunks written by
+// encryptWriter.
+type decryptReader struct {
+	r       *bufio.Reader
+	aead    cipher.AEAD
+	nonce   []byte
+	ad      []byte
+	counter uint32
+	in      []byte
+	plain   []byte
+	done    bool
+}
+
+func newDecryptReader(r *bufio.Reader, key, ad []byte) (*decryptReader, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(r, nonce[:noncePrefixSize]); err != nil {
+		return nil, &CorruptIndexError{"missing encryption nonce"}
+	}
+	return &decryptReader{
+		r:     r,
+		aead:  aead,
+		nonce: nonce,
+		ad:    ad,
+		in:    make([]byte, encryptionChunkSize+aead.Overhead()),
+	}, nil
+}
+
+func (d *decryptReader) Read(p []byte) (int, error) {
+	for len(d.plain) == 0 {
+		if d.done {
+			return 0, io.EOF
+		}
+		if err := d.next(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, d.plain)
+	d.plain = d.plain[n:]
+	return n, nil
+}
+
+func (d *decryptReader) next() error {
+	n, err := io.ReadFull(d.r, d.in)
+	var last bool
+	switch err {
+	case nil:
+		if _, err := d.r.Peek(1); err == io.EOF {
+			last = true
+		} else if err != nil {
+			return err
+		}
+	case io.ErrUnexpectedEOF:
+		last = true
+	case io.EOF:
+		return &CorruptIndexError{"truncated encrypted data"}
+	default:
+		return err
+	}
+	setChunkNonce(d.nonce, d.counter, last)
+	d.plain, err = d.aead.Open(d.in[:0], d.nonce, d.in[:n], d.ad)
+	if err != nil {
+		return &CorruptIndexError{"decryption failed: wrong key or damaged data"}
+	}
+	d.counter++
+	d.done = last
+	return nil
+}
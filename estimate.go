@@ -0,0 +1,81 @@
+package minhashlsh
+
+import "math"
+
+// SimilarityEstimate is the estimated Jaccard similarity of a candidate
+// with a query, with a confidence interval.
+type SimilarityEstimate struct {
+	Key        interface{}
+	Similarity float64
+	Lower      float64
+	Upper      float64
+}
+
+// QueryEstimates returns the candidate keys given the query signature with
+// their estimated similarity, computed from their stored signatures, and its
+// Wilson score interval at the given confidence level, such as 0.95.
+// The interval accounts for the number of hash values compared, so
+// downstream consumers can threshold with statistical awareness.
+// ErrNoSignatures is returned unless the index stores signatures.
+func (f *MinhashLSH) QueryEstimates(sig []uint64, confidence float64) ([]SimilarityEstimate, error) {
+	if f.signatures == nil {
+		return nil, ErrNoSignatures
+	}
+	z := normalQuantile(confidence)
+	words := f.HashValueSize / wordSize(f.HashValueSize)
+	results := make([]SimilarityEstimate, 0)
+	for _, key := range f.queryVerified(sig) {
+		stored, exist := f.signatures[key]
+		if !exist {
+			continue
+		}
+		equal, n := countEqual(sig, stored, words)
+		e := SimilarityEstimate{Key: key}
+		if n > 0 {
+			e.Similarity = float64(equal) / float64(n)
+			e.Lower, e.Upper = wilson(e.Similarity, float64(n), z)
+		}
+		results = append(results, e)
+	}
+	return results, nil
+}
+
+// countEqual counts the equal hash values of two signatures over their
+// common length, each hash value being the given number of words.
+func countEqual(a, b []uint64, words int) (equal, n int) {
+	size := minInt(len(a), len(b)) / words * words
+	for i := 0; i < size; i += words {
+		same := true
+		for j := i; j < i+words; j++ {
+			same = same && a[j] == b[j]
+		}
+		if same {
+			equal++
+		}
+	}
+	return equal, size / words
+}
+
+// wilson returns the Wilson score interval of a proportion p observed
+// over n trials.
+func wilson(p, n, z float64) (lower, upper float64) {
+	z2 := z * z
+	center := (p + z2/(2*n)) / (1 + z2/n)
+	margin := z / (1 + z2/n) * math.Sqrt(p*(1-p)/n+z2/(4*n*n))
+	return math.Max(0, center-margin), math.Min(1, center+margin)
+}
+
+// normalQuantile returns z such that a standard normal variable lies
+// within [-z, z] with the given probability.
+func normalQuantile(confidence float64) float64 {
+	lo, hi := 0.0, 10.0
+	for i := 0; i < 64; i++ {
+		mid := (lo + hi) / 2
+		if math.Erf(mid/math.Sqrt2) < confidence {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	return (lo + hi) / 2
+}
@@ -0,0 +1,37 @@
+package minhashlsh
+
+import (
+	"math"
+	"testing"
+)
+
+func Test_QueryEstimates(t *testing.T) {
+	f := NewMinhashLSH16(128, 0.5, 0, WithSignatureStorage())
+	sig := randomSignature(128, 1)
+	f.Add("key", sig)
+	f.Index()
+	query := append([]uint64(nil), sig...)
+	copy(query[64:], randomSignature(64, 2))
+	estimates, err := f.QueryEstimates(query, 0.95)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(estimates) != 1 {
+		t.Fatalf("expected one candidate, got %v", estimates)
+	}
+	e := estimates[0]
+	if e.Similarity != 0.5 {
+		t.Errorf("expected a similarity of 0.5, got %f", e.Similarity)
+	}
+	// The Wilson interval of 64 successes out of 128 at 95%.
+	if math.Abs(e.Lower-0.4142) > 1e-3 || math.Abs(e.Upper-0.5858) > 1e-3 {
+		t.Errorf("unexpected interval [%f, %f]", e.Lower, e.Upper)
+	}
+
+	if _, err := testIndex(1).QueryEstimates(sig, 0.95); err != ErrNoSignatures {
+		t.Errorf("expected ErrNoSignatures, got %v", err)
+	}
+	if z := normalQuantile(0.95); math.Abs(z-1.96) > 1e-3 {
+		t.Errorf("expected z = 1.96, got %f", z)
+	}
+}
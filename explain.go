@@ -0,0 +1,52 @@
+package minhashlsh
+
+// BandMatch is a band in which a candidate collided with the query.
+type BandMatch struct {
+	Band int
+	// HashKey is the shared band hash key, in raw bytes.
+	HashKey string
+}
+
+// Explanation reports why a key is a candidate of a query.
+type Explanation struct {
+	Key     interface{}
+	Matches []BandMatch
+}
+
+// QueryExplain returns the candidate keys given the query signature,
+// along with the bands in which each of them matched the query,
+// in the order the candidates were found.
+// It is meant for debugging unexpected candidates, such as false positives.
+func (f *MinhashLSH) QueryExplain(sig []uint64) []Explanation {
+	hashKeys := f.hashKeys(sig)
+	positions := make(map[interface{}]int)
+	results := make([]Explanation, 0)
+	for i := 0; i < f.L; i++ {
+		for _, e := range f.bucket(i, hashKeys[i]) {
+			if f.isHidden(e.Key) {
+				continue
+			}
+			pos, exist := positions[e.Key]
+			if exist && pos < 0 {
+				continue
+			}
+			if !exist && !f.verified(sig, e.Key) {
+				// Rejected by the verifier.
+				positions[e.Key] = -1
+				continue
+			}
+			if !exist {
+				pos = len(results)
+				positions[e.Key] = pos
+				results = append(results, Explanation{Key: e.Key})
+			}
+			// A key added more than once appears repeatedly in a bucket.
+			matches := results[pos].Matches
+			if len(matches) > 0 && matches[len(matches)-1].Band == i {
+				continue
+			}
+			results[pos].Matches = append(matches, BandMatch{i, hashKeys[i]})
+		}
+	}
+	return results
+}
@@ -0,0 +1,34 @@
+package minhashlsh
+
+import "testing"
+
+func Test_QueryExplain(t *testing.T) {
+	f := NewMinhashLSH16(64, 0.5, 2)
+	sig1 := randomSignature(64, 1)
+	sig2 := randomSignature(64, 2)
+	// sig2 shares the first band with sig1.
+	copy(sig2[:f.K], sig1[:f.K])
+	f.Add("sig1", sig1)
+	f.Add("sig2", sig2)
+	f.Index()
+
+	results := f.QueryExplain(sig1)
+	if len(results) != 2 {
+		t.Fatal(results)
+	}
+	for _, r := range results {
+		switch r.Key {
+		case "sig1":
+			if len(r.Matches) != f.L {
+				t.Errorf("sig1 should match in all bands, got %v", r.Matches)
+			}
+		case "sig2":
+			if len(r.Matches) != 1 || r.Matches[0].Band != 0 {
+				t.Errorf("sig2 should only match in band 0, got %v", r.Matches)
+			}
+			if r.Matches[0].HashKey != f.HashKeyFunc(sig1[:f.K]) {
+				t.Error("unexpected shared hash key")
+			}
+		}
+	}
+}
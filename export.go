@@ -0,0 +1,101 @@
+package minhashlsh
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// Pair is a pair of similar keys.
+type Pair struct {
+	Key1 interface{} `json:"key1"`
+	Key2 interface{} `json:"key2"`
+}
+
+// Exporter writes similar pairs or clusters of similar keys, numbering the
+// clusters in the order they are written. Flush must be called once
+// everything is written.
+type Exporter interface {
+	WritePair(p Pair) error
+	WriteCluster(keys []interface{}) error
+	Flush() error
+}
+
+type csvExporter struct {
+	w       *csv.Writer
+	cluster int
+}
+
+// NewCSVExporter returns an Exporter writing CSV rows of the form
+// key1,key2 for pairs and clusterID,key for each key of a cluster.
+func NewCSVExporter(w io.Writer) Exporter {
+	return &csvExporter{w: csv.NewWriter(w)}
+}
+
+func (e *csvExporter) WritePair(p Pair) error {
+	return e.w.Write([]string{fmt.Sprint(p.Key1), fmt.Sprint(p.Key2)})
+}
+
+func (e *csvExporter) WriteCluster(keys []interface{}) error {
+	id := fmt.Sprint(e.cluster)
+	e.cluster++
+	for _, key := range keys {
+		if err := e.w.Write([]string{id, fmt.Sprint(key)}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *csvExporter) Flush() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+type jsonlExporter struct {
+	w       *bufio.Writer
+	enc     *json.Encoder
+	cluster int
+}
+
+// NewJSONLExporter returns an Exporter writing JSON Lines,
+// {"key1":...,"key2":...} for pairs and {"cluster":...,"keys":[...]}
+// for clusters.
+func NewJSONLExporter(w io.Writer) Exporter {
+	bw := bufio.NewWriter(w)
+	return &jsonlExporter{w: bw, enc: json.NewEncoder(bw)}
+}
+
+func (e *jsonlExporter) WritePair(p Pair) error {
+	return e.enc.Encode(p)
+}
+
+func (e *jsonlExporter) WriteCluster(keys []interface{}) error {
+	c := struct {
+		Cluster int           `json:"cluster"`
+		Keys    []interface{} `json:"keys"`
+	}{e.cluster, keys}
+	e.cluster++
+	return e.enc.Encode(c)
+}
+
+func (e *jsonlExporter) Flush() error {
+	return e.w.Flush()
+}
+
+// ExportPairs writes all pairs received from the channel and flushes the
+// exporter. It returns the first error, after draining the channel.
+func ExportPairs(e Exporter, pairs <-chan Pair) error {
+	var err error
+	for p := range pairs {
+		if err == nil {
+			err = e.WritePair(p)
+		}
+	}
+	if err != nil {
+		return err
+	}
+	return e.Flush()
+}
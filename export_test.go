@@ -0,0 +1,31 @@
+package minhashlsh
+
+import (
+	"bytes"
+	"testing"
+)
+
+func Test_Exporters(t *testing.T) {
+	var buf bytes.Buffer
+	e := NewCSVExporter(&buf)
+	e.WritePair(Pair{"a", "b"})
+	e.WriteCluster([]interface{}{1, 2})
+	if err := e.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != "a,b\n0,1\n0,2\n" {
+		t.Fatalf("unexpected CSV output %q", buf.String())
+	}
+
+	buf.Reset()
+	e = NewJSONLExporter(&buf)
+	pairs := make(chan Pair, 1)
+	pairs <- Pair{"a", 1}
+	close(pairs)
+	if err := ExportPairs(e, pairs); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != "{\"key1\":\"a\",\"key2\":1}\n" {
+		t.Fatalf("unexpected JSONL output %q", buf.String())
+	}
+}
@@ -0,0 +1,386 @@
+package minhashlsh
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+// formatVersion is the version of the index file format, stored in the
+// file header after the magic number. Files with other versions are
+// rejected by Load.
+//
+// All integers are little-endian. The parameters section holds
+//
+//	K              uint32
+//	L              uint32
+//	HashValueSize  uint32
+//	NumIndexedKeys uint64
+//	Salt           uint64, only if the salted flag is set
+//	Trim           uint8, the TrimPolicy, only if the trimmed flag is set
+//
+// The section of each band holds the hash table entries:
+//
+//	count    uint64
+//	hashKeys count x [K*HashValueSize]byte
+//	keys     count x (tag uint8, value)
+//
+// The value of a key depends on its tag: 8 bytes for integers and floats,
+// an uvarint length followed by the bytes for strings, and an uvarint
+// length followed by a gob encoding of the key for other types.
+// Such gob-encoded keys are not guaranteed to be stable across versions
+// of their types, and must be registered with gob.Register.
+// Keys encoded by a KeyCodec are an uvarint length followed by the bytes
+// given by the codec.
+const formatVersion uint8 = 1
+
+// Key type tags.
+const (
+	keyString uint8 = iota + 1
+	keyInt
+	keyInt64
+	keyInt32
+	keyUint64
+	keyUint32
+	keyFloat64
+	keyGob
+	keyCodec
+)
+
+// readChunkSize bounds the memory allocated ahead of reading data of a size
+// given by the file, so a corrupted size cannot exhaust memory.
+const readChunkSize = 1 << 20
+
+const maxInt = int(^uint(0) >> 1)
+
+func encodeParams(w io.Writer, header indexHeader) error {
+	buf := make([]byte, 20, 29)
+	binary.LittleEndian.PutUint32(buf, uint32(header.K))
+	binary.LittleEndian.PutUint32(buf[4:], uint32(header.L))
+	binary.LittleEndian.PutUint32(buf[8:], uint32(header.HashValueSize))
+	binary.LittleEndian.PutUint64(buf[12:], uint64(header.NumIndexedKeys))
+	if header.Salt != 0 {
+		var salt [8]byte
+		binary.LittleEndian.PutUint64(salt[:], header.Salt)
+		buf = append(buf, salt[:]...)
+	}
+	if header.Trim != TrimLowBits {
+		buf = append(buf, uint8(header.Trim))
+	}
+	_, err := w.Write(buf)
+	return err
+}
+
+func decodeParams(r io.Reader, flags uint8) (indexHeader, error) {
+	size := 20
+	if flags&flagSalted != 0 {
+		size += 8
+	}
+	if flags&flagTrimmed != 0 {
+		size++
+	}
+	buf := make([]byte, size)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return indexHeader{}, err
+	}
+	var salt uint64
+	extra := buf[20:]
+	if flags&flagSalted != 0 {
+		if salt = binary.LittleEndian.Uint64(extra); salt == 0 {
+			return indexHeader{}, errors.New("invalid salt")
+		}
+		extra = extra[8:]
+	}
+	trim := TrimLowBits
+	if flags&flagTrimmed != 0 {
+		if trim = TrimPolicy(extra[0]); trim == TrimLowBits || !trim.valid() {
+			return indexHeader{}, errInvalidTrimPolicy
+		}
+	}
+	numIndexedKeys := binary.LittleEndian.Uint64(buf[12:])
+	if numIndexedKeys > uint64(maxInt) {
+		return indexHeader{}, errors.New("invalid number of indexed keys")
+	}
+	return indexHeader{
+		K:              int(binary.LittleEndian.Uint32(buf)),
+		L:              int(binary.LittleEndian.Uint32(buf[4:])),
+		HashValueSize:  int(binary.LittleEndian.Uint32(buf[8:])),
+		NumIndexedKeys: int(numIndexedKeys),
+		Salt:           salt,
+		Trim:           trim,
+	}, nil
+}
+
+// encodeTable writes a hash table, leaving out the entries hidden at
+// their position, unless hidden is nil.
+func encodeTable(w io.Writer, table hashTable, width int, codec KeyCodec, hidden func(key interface{}, j int) bool) error {
+	bw := bufio.NewWriter(w)
+	var buf [binary.MaxVarintLen64]byte
+	count := len(table)
+	if hidden != nil {
+		count = 0
+		for i := range table {
+			if !hidden(table[i].Key, i) {
+				count++
+			}
+		}
+	}
+	binary.LittleEndian.PutUint64(buf[:], uint64(count))
+	bw.Write(buf[:8])
+	for i := range table {
+		if hidden != nil && hidden(table[i].Key, i) {
+			continue
+		}
+		if len(table[i].HashKey) != width {
+			return fmt.Errorf("minhashlsh: hash key of width %d, expected %d",
+				len(table[i].HashKey), width)
+		}
+		bw.WriteString(table[i].HashKey)
+	}
+	if codec != nil {
+		return encodeCodecKeys(bw, table, codec, hidden)
+	}
+	for i := range table {
+		if hidden != nil && hidden(table[i].Key, i) {
+			continue
+		}
+		if err := encodeKey(bw, table[i].Key, buf[:]); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+func encodeKey(w *bufio.Writer, key interface{}, buf []byte) error {
+	var tag uint8
+	var value uint64
+	switch k := key.(type) {
+	case string:
+		w.WriteByte(keyString)
+		w.Write(buf[:binary.PutUvarint(buf, uint64(len(k)))])
+		_, err := w.WriteString(k)
+		return err
+	case int:
+		tag, value = keyInt, uint64(k)
+	case int64:
+		tag, value = keyInt64, uint64(k)
+	case int32:
+		tag, value = keyInt32, uint64(k)
+	case uint64:
+		tag, value = keyUint64, k
+	case uint32:
+		tag, value = keyUint32, uint64(k)
+	case float64:
+		tag, value = keyFloat64, math.Float64bits(k)
+	default:
+		return encodeGobKey(w, key, buf)
+	}
+	w.WriteByte(tag)
+	binary.LittleEndian.PutUint64(buf, value)
+	_, err := w.Write(buf[:8])
+	return err
+}
+
+// encodeGobKey is kept out of encodeKey, as gob needs a pointer to the key,
+// which would make every key escape to the heap.
+func encodeGobKey(w *bufio.Writer, key interface{}, buf []byte) error {
+	var b bytes.Buffer
+	if err := gob.NewEncoder(&b).Encode(&key); err != nil {
+		return err
+	}
+	w.WriteByte(keyGob)
+	w.Write(buf[:binary.PutUvarint(buf, uint64(b.Len()))])
+	_, err := w.Write(b.Bytes())
+	return err
+}
+
+func encodeCodecKeys(w *bufio.Writer, table hashTable, codec KeyCodec, hidden func(key interface{}, j int) bool) error {
+	var buf []byte
+	var size [binary.MaxVarintLen64]byte
+	for i := range table {
+		if hidden != nil && hidden(table[i].Key, i) {
+			continue
+		}
+		var err error
+		if buf, err = codec.AppendKey(buf[:0], table[i].Key); err != nil {
+			return err
+		}
+		w.WriteByte(keyCodec)
+		w.Write(size[:binary.PutUvarint(size[:], uint64(len(buf)))])
+		w.Write(buf)
+	}
+	return w.Flush()
+}
+
+// decodeTableContent reads a hash table with hash keys of the given width.
+// The hash keys are substrings of a single string holding all of them.
+func decodeTableContent(r *checksumReader, width int, codec KeyCodec) (hashTable, error) {
+	var buf [8]byte
+	if _, err := io.ReadFull(r, buf[:]); err != nil {
+		return nil, err
+	}
+	count := binary.LittleEndian.Uint64(buf[:])
+	if width <= 0 || count > uint64(maxInt/width) {
+		return nil, errors.New("invalid hash table size")
+	}
+	hashKeys, err := readBytes(r, count*uint64(width))
+	if err != nil {
+		return nil, err
+	}
+	keys := string(hashKeys)
+	table := make(hashTable, 0, minInt(int(count), readChunkSize))
+	for i := 0; i < int(count); i++ {
+		key, err := decodeKey(r, codec)
+		if err != nil {
+			return nil, err
+		}
+		table = append(table, entry{keys[i*width : (i+1)*width], key})
+	}
+	return table, nil
+}
+
+func decodeKey(r *checksumReader, codec KeyCodec) (interface{}, error) {
+	tag, err := r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	if hasLength(tag) {
+		n, err := binary.ReadUvarint(r)
+		if err != nil {
+			return nil, err
+		}
+		b, err := readBytes(r, n)
+		if err != nil {
+			return nil, err
+		}
+		if tag == keyString {
+			return string(b), nil
+		}
+		return decodeBytesKey(tag, b, codec)
+	}
+	var buf [8]byte
+	if _, err := io.ReadFull(r, buf[:]); err != nil {
+		return nil, err
+	}
+	return decodeFixedKey(tag, binary.LittleEndian.Uint64(buf[:]))
+}
+
+// hasLength reports whether keys with the tag are length-prefixed.
+func hasLength(tag uint8) bool {
+	return tag == keyString || tag == keyGob || tag == keyCodec
+}
+
+// decodeBytesKey decodes a gob or codec encoded key.
+func decodeBytesKey(tag uint8, b []byte, codec KeyCodec) (interface{}, error) {
+	if tag == keyCodec {
+		if codec == nil {
+			return nil, ErrKeyCodecRequired
+		}
+		return codec.DecodeKey(b)
+	}
+	var key interface{}
+	if err := gob.NewDecoder(bytes.NewReader(b)).Decode(&key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// decodeFixedKey decodes a key of a fixed size type.
+func decodeFixedKey(tag uint8, value uint64) (interface{}, error) {
+	switch tag {
+	case keyInt:
+		return int(value), nil
+	case keyInt64:
+		return int64(value), nil
+	case keyInt32:
+		return int32(value), nil
+	case keyUint64:
+		return value, nil
+	case keyUint32:
+		return uint32(value), nil
+	case keyFloat64:
+		return math.Float64frombits(value), nil
+	}
+	return nil, fmt.Errorf("unknown key type %d", tag)
+}
+
+// decodeTableString decodes a hash table from the content of its section.
+// The hash keys and string keys are substrings of the content.
+func decodeTableString(content string, width int, codec KeyCodec) (hashTable, error) {
+	if len(content) < 8 {
+		return nil, io.ErrUnexpectedEOF
+	}
+	count := binary.LittleEndian.Uint64([]byte(content[:8]))
+	pos := 8
+	if width <= 0 || count > uint64((len(content)-pos)/width) {
+		return nil, errors.New("invalid hash table size")
+	}
+	keys := content[pos : pos+int(count)*width]
+	pos += len(keys)
+	table := make(hashTable, count)
+	for i := range table {
+		if pos >= len(content) {
+			return nil, io.ErrUnexpectedEOF
+		}
+		tag := content[pos]
+		pos++
+		var key interface{}
+		var err error
+		if hasLength(tag) {
+			n, size := binary.Uvarint([]byte(content[pos:minInt(pos+binary.MaxVarintLen64, len(content))]))
+			if size <= 0 || n > uint64(len(content)-pos-size) {
+				return nil, io.ErrUnexpectedEOF
+			}
+			pos += size
+			value := content[pos : pos+int(n)]
+			pos += int(n)
+			if tag == keyString {
+				key = value
+			} else if key, err = decodeBytesKey(tag, []byte(value), codec); err != nil {
+				return nil, err
+			}
+		} else {
+			if len(content)-pos < 8 {
+				return nil, io.ErrUnexpectedEOF
+			}
+			if key, err = decodeFixedKey(tag, binary.LittleEndian.Uint64([]byte(content[pos:pos+8]))); err != nil {
+				return nil, err
+			}
+			pos += 8
+		}
+		table[i] = entry{keys[i*width : (i+1)*width], key}
+	}
+	if pos != len(content) {
+		return nil, errors.New("unexpected data after keys")
+	}
+	return table, nil
+}
+
+// readBytes reads n bytes, growing the buffer as data arrives.
+func readBytes(r io.Reader, n uint64) ([]byte, error) {
+	if n <= readChunkSize {
+		b := make([]byte, n)
+		_, err := io.ReadFull(r, b)
+		return b, err
+	}
+	var b bytes.Buffer
+	if _, err := io.CopyN(&b, r, int64(n)); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
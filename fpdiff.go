@@ -0,0 +1,64 @@
+package minhashlsh
+
+// FingerprintDiff is the difference between the token sets of two
+// documents, such as near-duplicates found by a query: the tokens only in
+// the new document, the tokens only in the old one, and the exact Jaccard
+// similarity of the sets.
+type FingerprintDiff struct {
+	Added      [][]byte
+	Removed    [][]byte
+	Similarity float64
+}
+
+// DiffTokens returns the difference from the old token set to the new one,
+// with the added and removed tokens in the order of their first occurrence.
+// Duplicate tokens are ignored.
+func DiffTokens(old, new [][]byte) FingerprintDiff {
+	oldSet, newSet := tokenSet(old), tokenSet(new)
+	var d FingerprintDiff
+	d.Added = missingTokens(new, oldSet)
+	d.Removed = missingTokens(old, newSet)
+	if union := len(oldSet) + len(d.Added); union > 0 {
+		d.Similarity = float64(len(newSet)-len(d.Added)) / float64(union)
+	}
+	return d
+}
+
+func tokenSet(tokens [][]byte) map[string]bool {
+	set := make(map[string]bool, len(tokens))
+	for _, token := range tokens {
+		set[string(token)] = true
+	}
+	return set
+}
+
+// missingTokens returns the distinct tokens not in the set, in order.
+func missingTokens(tokens [][]byte, set map[string]bool) [][]byte {
+	var missing [][]byte
+	seen := make(map[string]bool)
+	for _, token := range tokens {
+		if !set[string(token)] && !seen[string(token)] {
+			seen[string(token)] = true
+			missing = append(missing, token)
+		}
+	}
+	return missing
+}
+
+// DiffShingles returns the difference from the k-byte shingles of the old
+// data to the ones of the new data, reporting the passages that changed
+// between near-duplicates rather than only their similarity.
+func DiffShingles(old, new []byte, k int) FingerprintDiff {
+	return DiffTokens(shingles(old, k), shingles(new, k))
+}
+
+func shingles(data []byte, k int) [][]byte {
+	if k <= 0 || len(data) < k {
+		return nil
+	}
+	tokens := make([][]byte, 0, len(data)-k+1)
+	for i := 0; i+k <= len(data); i++ {
+		tokens = append(tokens, data[i:i+k])
+	}
+	return tokens
+}
@@ -0,0 +1,45 @@
+package minhashlsh
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func Test_DiffTokens(t *testing.T) {
+	old := [][]byte{[]byte("a"), []byte("b"), []byte("c"), []byte("b")}
+	new := [][]byte{[]byte("d"), []byte("a"), []byte("c"), []byte("d"), []byte("e")}
+	d := DiffTokens(old, new)
+	if !reflect.DeepEqual(d.Added, [][]byte{[]byte("d"), []byte("e")}) {
+		t.Errorf("unexpected added tokens %q", d.Added)
+	}
+	if !reflect.DeepEqual(d.Removed, [][]byte{[]byte("b")}) {
+		t.Errorf("unexpected removed tokens %q", d.Removed)
+	}
+	if d.Similarity != Jaccard(old, new) {
+		t.Errorf("expected similarity %f, got %f", Jaccard(old, new), d.Similarity)
+	}
+	if d := DiffTokens(nil, nil); d.Added != nil || d.Removed != nil || d.Similarity != 0 {
+		t.Errorf("unexpected diff of empty sets %+v", d)
+	}
+}
+
+func Test_DiffShingles(t *testing.T) {
+	d := DiffShingles([]byte("the quick brown fox"), []byte("the quick red fox"), 4)
+	var added, removed []string
+	for _, s := range d.Added {
+		added = append(added, string(s))
+	}
+	for _, s := range d.Removed {
+		removed = append(removed, string(s))
+	}
+	if !reflect.DeepEqual(added, []string{"ck r", "k re", " red", "red ", "ed f", "d fo"}) {
+		t.Errorf("unexpected added shingles %q", added)
+	}
+	if !reflect.DeepEqual(removed, []string{"ck b", "k br", " bro", "brow", "rown", "own ", "wn f", "n fo"}) {
+		t.Errorf("unexpected removed shingles %q", removed)
+	}
+	if math.Abs(d.Similarity-8.0/22) > 1e-9 {
+		t.Errorf("expected similarity %f, got %f", 8.0/22, d.Similarity)
+	}
+}
@@ -0,0 +1,119 @@
+package minhashlsh
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"math"
+)
+
+// SketchSequences pushes the canonical k-mers of the DNA sequences of a FASTA
+// or FASTQ input to the Minhash, as Mash does: the lexicographically
+// smaller of each k-mer and its reverse complement, so both strands of
+// a genome give the same sketch. Bases are case insensitive, k-mers with
+// other bases than A, C, G and T are skipped, and no k-mer spans two
+// records. Use MashDistance to compare the signatures.
+func SketchSequences(r io.Reader, k int, mh *Minhash) error {
+	if k <= 0 {
+		return errors.New("minhashlsh: k-mer size must be positive")
+	}
+	br := bufio.NewReader(r)
+	s := &kmerScanner{k: k, push: mh.Push}
+	fastq := false
+	for lineNumber := 0; ; lineNumber++ {
+		line, err := br.ReadBytes('\n')
+		line = bytes.TrimRight(line, "\r\n")
+		if lineNumber == 0 && len(line) > 0 {
+			switch line[0] {
+			case '>':
+			case '@':
+				fastq = true
+			default:
+				return errors.New("minhashlsh: input is neither FASTA nor FASTQ")
+			}
+		}
+		switch {
+		case fastq && lineNumber%4 == 0, !fastq && len(line) > 0 && line[0] == '>':
+			s.reset()
+		case fastq && lineNumber%4 == 1, !fastq:
+			s.write(line)
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// kmerScanner emits the canonical k-mers of a sequence written line by line.
+type kmerScanner struct {
+	k      int
+	push   func(kmer []byte)
+	window []byte
+	rc     []byte
+}
+
+func (s *kmerScanner) reset() {
+	s.window = s.window[:0]
+}
+
+func (s *kmerScanner) write(line []byte) {
+	for _, c := range line {
+		switch c {
+		case 'A', 'C', 'G', 'T':
+		case 'a', 'c', 'g', 't':
+			c -= 'a' - 'A'
+		default:
+			s.reset()
+			continue
+		}
+		if len(s.window) == s.k {
+			copy(s.window, s.window[1:])
+			s.window = s.window[:s.k-1]
+		}
+		s.window = append(s.window, c)
+		if len(s.window) == s.k {
+			s.push(s.canonical())
+		}
+	}
+}
+
+// canonical returns the smaller of the window and its reverse complement.
+func (s *kmerScanner) canonical() []byte {
+	s.rc = s.rc[:0]
+	for i := len(s.window) - 1; i >= 0; i-- {
+		s.rc = append(s.rc, complement(s.window[i]))
+	}
+	if bytes.Compare(s.rc, s.window) < 0 {
+		return s.rc
+	}
+	return s.window
+}
+
+func complement(base byte) byte {
+	switch base {
+	case 'A':
+		return 'T'
+	case 'C':
+		return 'G'
+	case 'G':
+		return 'C'
+	}
+	return 'A'
+}
+
+// MashDistance estimates the Mash distance of two genomes from the
+// signatures of their k-mers computed by SketchSequences: with j their
+// estimated Jaccard similarity, -ln(2j/(1+j))/k, an estimate of the
+// mutation rate between them. Disjoint sketches are at distance 1.
+func MashDistance(sig1, sig2 []uint64, k int) float64 {
+	equal, n := countEqual(sig1, sig2, 1)
+	if equal == 0 {
+		return 1
+	}
+	j := float64(equal) / float64(n)
+	return -math.Log(2*j/(1+j)) / float64(k)
+}
@@ -0,0 +1,52 @@
+package minhashlsh
+
+import (
+	"math"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func Test_SketchSequences(t *testing.T) {
+	var kmers []string
+	s := &kmerScanner{k: 3, push: func(kmer []byte) { kmers = append(kmers, string(kmer)) }}
+	s.write([]byte("acG"))
+	s.write([]byte("TNAAA"))
+	// ACG, CGT matches ACG reversed, AAA is TTT reversed.
+	if strings.Join(kmers, ",") != "ACG,ACG,AAA" {
+		t.Errorf("unexpected canonical k-mers %v", kmers)
+	}
+
+	r := rand.New(rand.NewSource(1))
+	genome := make([]byte, 10000)
+	for i := range genome {
+		genome[i] = "ACGT"[r.Intn(4)]
+	}
+	reverse := make([]byte, len(genome))
+	for i, c := range genome {
+		reverse[len(genome)-1-i] = complement(c)
+	}
+	mutated := append([]byte(nil), genome...)
+	for i := 0; i < len(mutated); i += 100 {
+		mutated[i] = complement(mutated[i])
+	}
+	sketch := func(input string) []uint64 {
+		mh := NewMinhash(1, 256)
+		if err := SketchSequences(strings.NewReader(input), 21, mh); err != nil {
+			t.Fatal(err)
+		}
+		return mh.Signature()
+	}
+	fasta := sketch(">seq1\n" + string(genome[:5000]) + "\n" + string(genome[5000:]) + "\n")
+	fastq := sketch("@read\n" + string(reverse) + "\n+\n" + strings.Repeat("I", len(reverse)) + "\n")
+	if d := MashDistance(fasta, fastq, 21); d != 0 {
+		t.Errorf("expected both strands at distance 0, got %f", d)
+	}
+	// One mutation every 100 bases.
+	if d := MashDistance(fasta, sketch(">m\n"+string(mutated)), 21); math.Abs(d-0.01) > 0.005 {
+		t.Errorf("expected a distance of about 0.01, got %f", d)
+	}
+	if err := SketchSequences(strings.NewReader("ACGT"), 21, NewMinhash(1, 8)); err == nil {
+		t.Error("expected an error for an input without header")
+	}
+}
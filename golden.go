@@ -0,0 +1,69 @@
+package minhashlsh
+
+import "fmt"
+
+// GoldenVector is the expected signature of a Minhash with the given seed
+// and number of hash functions after pushing the given values in order.
+type GoldenVector struct {
+	Seed      int64
+	NumHash   int
+	Values    []string
+	Signature []uint64
+}
+
+// goldenVectors are signatures computed by this version. Their hash values
+// must never change, as saved indexes and stored signatures depend on them.
+var goldenVectors = []GoldenVector{
+	{
+		Seed:    0,
+		NumHash: 8,
+		Values:  []string{"minhash", "lsh", "golden"},
+		Signature: []uint64{
+			0x2300a7cc51e969bb, 0x5f20b070188e467a, 0x199acaf1a90f16a1, 0x50dd1527d8c72f8c,
+			0x1034ee170034c387, 0x2eb9c5adbc1413d4, 0x06cf113c575a706d, 0x3455de97593901b0,
+		},
+	},
+	{
+		Seed:    42,
+		NumHash: 8,
+		Values:  []string{"minhash", "lsh", "golden"},
+		Signature: []uint64{
+			0x09cae4caffa8f5a1, 0xb28ee6178684c62a, 0x5b52e7640d6096b3, 0x040c43ccbc335944,
+			0x2c22c9acaae0a3b7, 0x4bf1eda70098d0c4, 0x7c4fd56c883b389d, 0x8493b2848a334ef8,
+		},
+	},
+}
+
+// GoldenVectors returns reference signatures of Minhash, identical on all
+// platforms and in all versions, so that other implementations can be
+// checked against them.
+func GoldenVectors() []GoldenVector {
+	vectors := make([]GoldenVector, len(goldenVectors))
+	for i, v := range goldenVectors {
+		v.Values = append([]string(nil), v.Values...)
+		v.Signature = append([]uint64(nil), v.Signature...)
+		vectors[i] = v
+	}
+	return vectors
+}
+
+// CheckGoldenVectors computes the golden vectors with Minhash and returns
+// an error if any signature differs, such as after building with
+// an incompatible version of a dependency. Applications relying on
+// signatures computed elsewhere or earlier can call it at startup.
+func CheckGoldenVectors() error {
+	for _, v := range goldenVectors {
+		m := NewMinhash(v.Seed, v.NumHash)
+		for _, value := range v.Values {
+			m.Push([]byte(value))
+		}
+		sig := m.Signature()
+		for i := range v.Signature {
+			if sig[i] != v.Signature[i] {
+				return fmt.Errorf("minhashlsh: signature of seed %d differs from its golden vector at hash value %d: %#x, expected %#x",
+					v.Seed, i, sig[i], v.Signature[i])
+			}
+		}
+	}
+	return nil
+}
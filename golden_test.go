@@ -0,0 +1,14 @@
+package minhashlsh
+
+import "testing"
+
+func Test_GoldenVectors(t *testing.T) {
+	if err := CheckGoldenVectors(); err != nil {
+		t.Fatal(err)
+	}
+	vectors := GoldenVectors()
+	vectors[0].Signature[0]++
+	if err := CheckGoldenVectors(); err != nil {
+		t.Error("modifying the returned vectors changed the golden vectors")
+	}
+}
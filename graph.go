@@ -0,0 +1,71 @@
+package minhashlsh
+
+import "sort"
+
+// Neighbor is a key adjacent to another in a similarity graph, with their
+// estimated similarity.
+type Neighbor struct {
+	Key        interface{}
+	Similarity float64
+}
+
+type neighbors []Neighbor
+
+func (n neighbors) Len() int           { return len(n) }
+func (n neighbors) Swap(i, j int)      { n[i], n[j] = n[j], n[i] }
+func (n neighbors) Less(i, j int) bool { return n[i].Similarity > n[j].Similarity }
+
+// SimilarityGraph maps keys to their neighbors, by decreasing similarity.
+type SimilarityGraph map[interface{}][]Neighbor
+
+// BuildSimilarityGraph returns the graph of the indexed keys, linking the
+// keys colliding in a band whose similarity estimated from their stored
+// signatures is at least the threshold, as input to graph algorithms such
+// as connected components or community detection. Like Join, the buckets
+// of the sorted hash tables are scanned instead of querying every key.
+// Every indexed key is in the graph, with no neighbors if it has none.
+// ErrNoSignatures is returned unless the index stores signatures.
+func (f *MinhashLSH) BuildSimilarityGraph(threshold float64) (SimilarityGraph, error) {
+	if f.signatures == nil {
+		return nil, ErrNoSignatures
+	}
+	words := f.HashValueSize / wordSize(f.HashValueSize)
+	graph := make(SimilarityGraph)
+	seen := make(map[Pair]bool)
+	for band := 0; band < f.L; band++ {
+		table := f.table(band)[:f.NumIndexedKeys]
+		forEachBucket(table, func(bucket hashTable) {
+			for i, a := range bucket {
+				if f.isHidden(a.Key) {
+					continue
+				}
+				if _, exist := graph[a.Key]; !exist {
+					graph[a.Key] = nil
+				}
+				for _, b := range bucket[:i] {
+					p := Pair{b.Key, a.Key}
+					if f.isHidden(b.Key) || a.Key == b.Key || seen[p] || seen[Pair{a.Key, b.Key}] {
+						continue
+					}
+					seen[p] = true
+					sigA, existA := f.signatures[a.Key]
+					sigB, existB := f.signatures[b.Key]
+					if !existA || !existB {
+						continue
+					}
+					equal, n := countEqual(sigA, sigB, words)
+					if n == 0 || float64(equal) < threshold*float64(n) {
+						continue
+					}
+					s := float64(equal) / float64(n)
+					graph[a.Key] = append(graph[a.Key], Neighbor{b.Key, s})
+					graph[b.Key] = append(graph[b.Key], Neighbor{a.Key, s})
+				}
+			}
+		})
+	}
+	for _, adjacent := range graph {
+		sort.Stable(neighbors(adjacent))
+	}
+	return graph, nil
+}
@@ -0,0 +1,44 @@
+package minhashlsh
+
+import "testing"
+
+func Test_BuildSimilarityGraph(t *testing.T) {
+	f := NewMinhashLSH16(64, 0.5, 0)
+	if _, err := f.BuildSimilarityGraph(0.5); err != ErrNoSignatures {
+		t.Errorf("expected ErrNoSignatures, got %v", err)
+	}
+	f = NewMinhashLSH16(64, 0.5, 0, WithSignatureStorage())
+	base := randomSignature(64, 1)
+	near := append([]uint64(nil), base...)
+	for i := 0; i < 8; i++ {
+		near[i] = 0
+	}
+	far := append([]uint64(nil), base...)
+	for i := 0; i < 24; i++ {
+		far[i] = 1
+	}
+	f.Add("base", base)
+	f.Add("near", near)
+	f.Add("far", far)
+	f.Add("other", randomSignature(64, 2))
+	f.Index()
+	graph, err := f.BuildSimilarityGraph(0.5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(graph) != 4 || len(graph["other"]) != 0 {
+		t.Fatalf("unexpected graph %v", graph)
+	}
+	adjacent := graph["base"]
+	if len(adjacent) != 2 || adjacent[0] != (Neighbor{"near", 56.0 / 64}) || adjacent[1] != (Neighbor{"far", 40.0 / 64}) {
+		t.Errorf("unexpected neighbors of base %v", adjacent)
+	}
+	if adjacent := graph["far"]; len(adjacent) != 2 || adjacent[0].Key != "base" {
+		t.Errorf("unexpected neighbors of far %v", adjacent)
+	}
+	// near and far share 40 values.
+	graph, _ = f.BuildSimilarityGraph(0.8)
+	if len(graph["far"]) != 0 || len(graph["near"]) != 1 {
+		t.Errorf("unexpected graph at 0.8 %v", graph)
+	}
+}
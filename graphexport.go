@@ -0,0 +1,202 @@
+package minhashlsh
+
+import (
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+)
+
+// GraphExporter is an Exporter of graphs, whose pairs are edges and
+// clusters groups of nodes, that can also write weighted edges and isolated
+// nodes.
+type GraphExporter interface {
+	Exporter
+	WriteNode(key interface{}) error
+	WriteEdge(p Pair, similarity float64) error
+}
+
+// ExportGraph writes a similarity graph, each edge once, and flushes the
+// exporter. Nodes are written in the order of their keys formatted by
+// fmt.Sprint, so the output is deterministic.
+func ExportGraph(e GraphExporter, g SimilarityGraph) error {
+	keys := make([]interface{}, 0, len(g))
+	for key := range g {
+		keys = append(keys, key)
+	}
+	sort.Sort(byString(keys))
+	written := make(map[interface{}]bool)
+	for _, key := range keys {
+		if err := e.WriteNode(key); err != nil {
+			return err
+		}
+		written[key] = true
+		for _, n := range g[key] {
+			if !written[n.Key] {
+				continue
+			}
+			if err := e.WriteEdge(Pair{n.Key, key}, n.Similarity); err != nil {
+				return err
+			}
+		}
+	}
+	return e.Flush()
+}
+
+type byString []interface{}
+
+func (s byString) Len() int           { return len(s) }
+func (s byString) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s byString) Less(i, j int) bool { return fmt.Sprint(s[i]) < fmt.Sprint(s[j]) }
+
+type dotExporter struct {
+	w       *bufio.Writer
+	started bool
+	cluster int
+}
+
+// NewDOTExporter returns a GraphExporter writing an undirected graph in the
+// DOT language of Graphviz, with the similarity of edges as their weight
+// and clusters as subgraphs named cluster_<ID>, drawn as boxes by dot.
+func NewDOTExporter(w io.Writer) GraphExporter {
+	return &dotExporter{w: bufio.NewWriter(w)}
+}
+
+func (e *dotExporter) start() {
+	if !e.started {
+		e.started = true
+		e.w.WriteString("graph similar {\n")
+	}
+}
+
+func dotID(key interface{}) string {
+	return strconv.Quote(fmt.Sprint(key))
+}
+
+func (e *dotExporter) WriteNode(key interface{}) error {
+	e.start()
+	_, err := fmt.Fprintf(e.w, "\t%s;\n", dotID(key))
+	return err
+}
+
+func (e *dotExporter) WritePair(p Pair) error {
+	e.start()
+	_, err := fmt.Fprintf(e.w, "\t%s -- %s;\n", dotID(p.Key1), dotID(p.Key2))
+	return err
+}
+
+func (e *dotExporter) WriteEdge(p Pair, similarity float64) error {
+	e.start()
+	_, err := fmt.Fprintf(e.w, "\t%s -- %s [weight=%g];\n", dotID(p.Key1), dotID(p.Key2), similarity)
+	return err
+}
+
+func (e *dotExporter) WriteCluster(keys []interface{}) error {
+	e.start()
+	fmt.Fprintf(e.w, "\tsubgraph cluster_%d {\n", e.cluster)
+	e.cluster++
+	for _, key := range keys {
+		fmt.Fprintf(e.w, "\t\t%s;\n", dotID(key))
+	}
+	_, err := e.w.WriteString("\t}\n")
+	return err
+}
+
+func (e *dotExporter) Flush() error {
+	e.start()
+	e.w.WriteString("}\n")
+	return e.w.Flush()
+}
+
+type graphMLExporter struct {
+	w       *bufio.Writer
+	started bool
+	cluster int
+	nodes   map[interface{}]string
+}
+
+// NewGraphMLExporter returns a GraphExporter writing an undirected GraphML
+// graph, as read by Gephi, with the keys as the label of nodes, the
+// similarity of edges as their weight and the ID of the cluster of nodes
+// as their cluster attribute. Nodes are declared when first written or
+// linked; a node keeps the cluster it was declared with.
+func NewGraphMLExporter(w io.Writer) GraphExporter {
+	return &graphMLExporter{w: bufio.NewWriter(w), nodes: make(map[interface{}]string)}
+}
+
+func (e *graphMLExporter) start() {
+	if !e.started {
+		e.started = true
+		e.w.WriteString(xml.Header + `<graphml xmlns="http://graphml.graphdrawing.org/xmlns">
+  <key id="label" for="node" attr.name="label" attr.type="string"/>
+  <key id="cluster" for="node" attr.name="cluster" attr.type="int"/>
+  <key id="weight" for="edge" attr.name="weight" attr.type="double"/>
+  <graph edgedefault="undirected">
+`)
+	}
+}
+
+// node returns the ID of the node of a key, declaring it in the given
+// cluster, or none if negative, unless it is declared already.
+func (e *graphMLExporter) node(key interface{}, cluster int) (string, error) {
+	e.start()
+	if id, exist := e.nodes[key]; exist {
+		return id, nil
+	}
+	id := "n" + strconv.Itoa(len(e.nodes))
+	e.nodes[key] = id
+	fmt.Fprintf(e.w, `    <node id="%s"><data key="label">`, id)
+	if err := xml.EscapeText(e.w, []byte(fmt.Sprint(key))); err != nil {
+		return "", err
+	}
+	e.w.WriteString("</data>")
+	if cluster >= 0 {
+		fmt.Fprintf(e.w, `<data key="cluster">%d</data>`, cluster)
+	}
+	_, err := e.w.WriteString("</node>\n")
+	return id, err
+}
+
+func (e *graphMLExporter) WriteNode(key interface{}) error {
+	_, err := e.node(key, -1)
+	return err
+}
+
+func (e *graphMLExporter) WritePair(p Pair) error {
+	return e.writeEdge(p, "")
+}
+
+func (e *graphMLExporter) WriteEdge(p Pair, similarity float64) error {
+	return e.writeEdge(p, fmt.Sprintf(`<data key="weight">%g</data>`, similarity))
+}
+
+func (e *graphMLExporter) writeEdge(p Pair, data string) error {
+	source, err := e.node(p.Key1, -1)
+	if err != nil {
+		return err
+	}
+	target, err := e.node(p.Key2, -1)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(e.w, "    <edge source=\"%s\" target=\"%s\">%s</edge>\n", source, target, data)
+	return err
+}
+
+func (e *graphMLExporter) WriteCluster(keys []interface{}) error {
+	for _, key := range keys {
+		if _, err := e.node(key, e.cluster); err != nil {
+			return err
+		}
+	}
+	e.cluster++
+	return nil
+}
+
+func (e *graphMLExporter) Flush() error {
+	e.start()
+	e.w.WriteString("  </graph>\n</graphml>\n")
+	return e.w.Flush()
+}
@@ -0,0 +1,69 @@
+package minhashlsh
+
+import (
+	"bytes"
+	"encoding/xml"
+	"testing"
+)
+
+func Test_DOTExporter(t *testing.T) {
+	var buf bytes.Buffer
+	g := SimilarityGraph{
+		"a":       {{"b", 0.75}},
+		"b":       {{"a", 0.75}},
+		`say "c"`: nil,
+	}
+	if err := ExportGraph(NewDOTExporter(&buf), g); err != nil {
+		t.Fatal(err)
+	}
+	expected := "graph similar {\n\t\"a\";\n\t\"b\";\n\t\"a\" -- \"b\" [weight=0.75];\n\t\"say \\\"c\\\"\";\n}\n"
+	if buf.String() != expected {
+		t.Errorf("unexpected DOT output %q", buf.String())
+	}
+
+	buf.Reset()
+	e := NewDOTExporter(&buf)
+	e.WriteCluster([]interface{}{1, 2})
+	if err := e.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != "graph similar {\n\tsubgraph cluster_0 {\n\t\t\"1\";\n\t\t\"2\";\n\t}\n}\n" {
+		t.Errorf("unexpected DOT output %q", buf.String())
+	}
+}
+
+func Test_GraphMLExporter(t *testing.T) {
+	var buf bytes.Buffer
+	e := NewGraphMLExporter(&buf)
+	e.WriteCluster([]interface{}{"a<b", 2})
+	e.WritePair(Pair{"a<b", 3})
+	e.WriteEdge(Pair{2, 3}, 0.5)
+	if err := e.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	var doc struct {
+		Nodes []struct {
+			ID   string `xml:"id,attr"`
+			Data []struct {
+				Key   string `xml:"key,attr"`
+				Value string `xml:",chardata"`
+			} `xml:"data"`
+		} `xml:"graph>node"`
+		Edges []struct {
+			Source string `xml:"source,attr"`
+			Target string `xml:"target,attr"`
+			Weight string `xml:"data"`
+		} `xml:"graph>edge"`
+	}
+	if err := xml.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("invalid GraphML: %v\n%s", err, buf.String())
+	}
+	if len(doc.Nodes) != 3 || doc.Nodes[0].Data[0].Value != "a<b" || doc.Nodes[0].Data[1].Value != "0" ||
+		len(doc.Nodes[2].Data) != 1 {
+		t.Errorf("unexpected nodes %+v", doc.Nodes)
+	}
+	if len(doc.Edges) != 2 || doc.Edges[0].Source != "n0" || doc.Edges[0].Target != "n2" ||
+		doc.Edges[1].Weight != "0.5" {
+		t.Errorf("unexpected edges %+v", doc.Edges)
+	}
+}
@@ -0,0 +1,138 @@
+package minhashlsh
+
+import "math"
+
+// BandGroup is a group of L bands of K hash values each. A key is
+// a candidate of the group if it collides with the query in at least
+// MinMatches of its bands; a MinMatches of 0 or 1 means any band.
+type BandGroup struct {
+	K, L       int
+	MinMatches int
+}
+
+// GroupedIndex is an index made of groups of bands with their own K and
+// acceptance rule, such as a few strict bands plus many lenient ones, to
+// shape the probability of becoming a candidate beyond what a single K and L
+// allow. Each group uses the next K*L values of the signatures, and a key is
+// a candidate if it is a candidate of any group.
+type GroupedIndex struct {
+	groups  []BandGroup
+	offsets []int
+	indexes []*MinhashLSH
+}
+
+// NewGroupedIndex creates an index of the band groups with hash values
+// of hashValueSize bytes.
+func NewGroupedIndex(hashValueSize, initSize int, groups ...BandGroup) *GroupedIndex {
+	if initSize <= 0 {
+		initSize = defaultInitSize
+	}
+	g := &GroupedIndex{groups: groups}
+	offset := 0
+	for _, group := range groups {
+		hashTables := make([]hashTable, group.L)
+		for i := range hashTables {
+			hashTables[i] = make(hashTable, 0, initSize)
+		}
+		lsh := &MinhashLSH{
+			K:             group.K,
+			L:             group.L,
+			HashValueSize: hashValueSize,
+			HashTables:    hashTables,
+			HashKeyFunc:   hashKeyFuncGen(hashValueSize),
+			appendKey:     appendKeyFuncGen(hashValueSize),
+		}
+		g.offsets = append(g.offsets, offset)
+		g.indexes = append(g.indexes, lsh)
+		offset += lsh.SignatureSize()
+	}
+	g.offsets = append(g.offsets, offset)
+	return g
+}
+
+// SignatureSize returns the number of values of signatures used by the index.
+func (g *GroupedIndex) SignatureSize() int {
+	return g.offsets[len(g.offsets)-1]
+}
+
+// Add a key with its signature. The key won't be searchable until Index
+// is called.
+func (g *GroupedIndex) Add(key interface{}, sig []uint64) {
+	for i, lsh := range g.indexes {
+		lsh.Add(key, sig[g.offsets[i]:g.offsets[i+1]])
+	}
+}
+
+// Index makes all the keys added searchable.
+func (g *GroupedIndex) Index() {
+	for _, lsh := range g.indexes {
+		lsh.Index()
+	}
+}
+
+// Query returns candidate keys given the query signature.
+func (g *GroupedIndex) Query(sig []uint64) []interface{} {
+	set := make(map[interface{}]bool)
+	for i, lsh := range g.indexes {
+		groupSig := sig[g.offsets[i]:g.offsets[i+1]]
+		if g.groups[i].MinMatches <= 1 {
+			for _, key := range lsh.query(groupSig).keys {
+				set[key] = true
+			}
+			continue
+		}
+		matches := make(map[interface{}]int)
+		for band, hashKey := range lsh.hashKeys(groupSig) {
+			bandKeys := make(map[interface{}]bool)
+			for _, e := range lsh.bucket(band, hashKey) {
+				bandKeys[e.Key] = true
+			}
+			for key := range bandKeys {
+				matches[key]++
+			}
+		}
+		for key, n := range matches {
+			if n >= g.groups[i].MinMatches {
+				set[key] = true
+			}
+		}
+	}
+	results := make([]interface{}, 0, len(set))
+	for key := range set {
+		results = append(results, key)
+	}
+	return results
+}
+
+// Probability returns the probability that a key of Jaccard similarity s
+// with the query is a candidate.
+func (g *GroupedIndex) Probability(s float64) float64 {
+	miss := 1.0
+	for _, group := range g.groups {
+		miss *= 1 - groupProbability(group, s)
+	}
+	return 1 - miss
+}
+
+// groupProbability returns the probability that at least MinMatches of the
+// L bands of a group collide for a Jaccard similarity s.
+func groupProbability(group BandGroup, s float64) float64 {
+	p := math.Pow(s, float64(group.K))
+	minMatches := group.MinMatches
+	if minMatches < 1 {
+		minMatches = 1
+	}
+	var prob float64
+	for m := minMatches; m <= group.L; m++ {
+		prob += binomial(group.L, m) * math.Pow(p, float64(m)) * math.Pow(1-p, float64(group.L-m))
+	}
+	return prob
+}
+
+func binomial(n, k int) float64 {
+	c := 1.0
+	for i := 1; i <= k; i++ {
+		c = c * float64(n-k+i) / float64(i)
+	}
+	return c
+}
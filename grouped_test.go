@@ -0,0 +1,47 @@
+package minhashlsh
+
+import (
+	"math"
+	"testing"
+)
+
+func Test_GroupedIndex(t *testing.T) {
+	g := NewGroupedIndex(4, 0, BandGroup{K: 8, L: 2}, BandGroup{K: 2, L: 8, MinMatches: 3})
+	if g.SignatureSize() != 32 {
+		t.Fatalf("expected a signature size of 32, got %d", g.SignatureSize())
+	}
+	sig := randomSignature(32, 1)
+	g.Add("key", sig)
+	g.Index()
+	if !contains(g.Query(sig), "key") {
+		t.Error("identical signature not found")
+	}
+
+	// Only two lenient bands match: not enough for the second group.
+	query := randomSignature(32, 2)
+	copy(query[16:20], sig[16:20])
+	if contains(g.Query(query), "key") {
+		t.Error("key found with too few matching lenient bands")
+	}
+	copy(query[20:22], sig[20:22])
+	if !contains(g.Query(query), "key") {
+		t.Error("key not found with enough matching lenient bands")
+	}
+	// A single strict band matches.
+	query = randomSignature(32, 3)
+	copy(query[8:16], sig[8:16])
+	if !contains(g.Query(query), "key") {
+		t.Error("key not found with a matching strict band")
+	}
+
+	if p := g.Probability(1); math.Abs(p-1) > 1e-9 {
+		t.Errorf("expected a probability of 1 for identical sets, got %f", p)
+	}
+	if p := g.Probability(0); p != 0 {
+		t.Errorf("expected a probability of 0 for disjoint sets, got %f", p)
+	}
+	single := NewGroupedIndex(4, 0, BandGroup{K: 4, L: 8})
+	if p, expected := single.Probability(0.5), 1-math.Pow(1-math.Pow(0.5, 4), 8); math.Abs(p-expected) > 1e-9 {
+		t.Errorf("expected %f, got %f", expected, p)
+	}
+}
@@ -0,0 +1,71 @@
+package minhashlsh
+
+// defaultInitSize is the initial capacity of the hash tables when no
+// initSize is given.
+const defaultInitSize = 1024
+
+// GrowthPolicy returns the new capacity of a full hash table
+// given its current capacity.
+type GrowthPolicy func(capacity int) int
+
+// GeometricGrowth multiplies the capacity by factor, but grows by at most
+// maxStep entries at once, so very large tables do not over-allocate.
+// A non-positive maxStep means no limit.
+func GeometricGrowth(factor float64, maxStep int) GrowthPolicy {
+	return func(capacity int) int {
+		step := int(float64(capacity) * (factor - 1))
+		if maxStep > 0 && step > maxStep {
+			step = maxStep
+		}
+		if step < 1 {
+			step = 1
+		}
+		return capacity + step
+	}
+}
+
+// DefaultGrowth doubles the capacity of hash tables, by at most 16M
+// entries at a time.
+var DefaultGrowth = GeometricGrowth(2, 1<<24)
+
+// WithGrowthPolicy sets the policy used to grow the hash tables
+// as keys are added.
+func WithGrowthPolicy(p GrowthPolicy) Option {
+	return func(f *MinhashLSH) {
+		f.growth = p
+	}
+}
+
+// grow returns a copy of the hash table with room for at least n more
+// entries.
+func (f *MinhashLSH) grow(h hashTable, n int) hashTable {
+	policy := f.growth
+	if policy == nil {
+		policy = DefaultGrowth
+	}
+	capacity := cap(h)
+	for capacity < len(h)+n {
+		if next := policy(capacity); next > capacity {
+			capacity = next
+		} else {
+			capacity++
+		}
+	}
+	grown := make(hashTable, len(h), capacity)
+	copy(grown, h)
+	return grown
+}
+
+// Reserve grows the hash tables so that n more keys can be added without
+// reallocation, which avoids repeated growth when the number of keys
+// to add is known in advance.
+func (f *MinhashLSH) Reserve(n int) {
+	f.materialize()
+	for i := range f.HashTables {
+		if cap(f.HashTables[i])-len(f.HashTables[i]) < n {
+			grown := make(hashTable, len(f.HashTables[i]), len(f.HashTables[i])+n)
+			copy(grown, f.HashTables[i])
+			f.HashTables[i] = grown
+		}
+	}
+}
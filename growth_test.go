@@ -0,0 +1,36 @@
+package minhashlsh
+
+import (
+	"strconv"
+	"testing"
+)
+
+func Test_GeometricGrowth(t *testing.T) {
+	p := GeometricGrowth(2, 100)
+	if c := p(10); c != 20 {
+		t.Errorf("expected 20, got %d", c)
+	}
+	if c := p(1000); c != 1100 {
+		t.Errorf("expected 1100, got %d", c)
+	}
+	if c := p(0); c != 1 {
+		t.Errorf("expected 1, got %d", c)
+	}
+}
+
+func Test_GrowthPolicy(t *testing.T) {
+	f := NewMinhashLSH16(64, 0.5, 0, WithGrowthPolicy(GeometricGrowth(2, 1000)))
+	if cap(f.HashTables[0]) != defaultInitSize {
+		t.Fatal(cap(f.HashTables[0]))
+	}
+	for i := 0; i < 3000; i++ {
+		f.Add(strconv.Itoa(i), randomSignature(64, int64(i)))
+	}
+	if c := cap(f.HashTables[0]); c != 3024 {
+		t.Fatalf("expected capacity 3024, got %d", c)
+	}
+	f.Reserve(10000)
+	if c := cap(f.HashTables[0]); c != 13000 {
+		t.Fatalf("expected capacity 13000, got %d", c)
+	}
+}
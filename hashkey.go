@@ -0,0 +1,100 @@
+package minhashlsh
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// HashKeyEncoding is the text representation of band hash keys used
+// outside of the index, such as keys in external stores or in logs.
+// Hash keys are raw bytes internally.
+type HashKeyEncoding uint8
+
+// Hash key encodings.
+const (
+	// HashKeyRaw keeps the raw bytes.
+	HashKeyRaw HashKeyEncoding = iota
+	// HashKeyHex encodes the bytes in lowercase hexadecimal.
+	HashKeyHex
+	// HashKeyBase64 encodes the bytes in URL-safe base64 without padding.
+	HashKeyBase64
+	// HashKeyUint64s lists the hash values of the band in decimal,
+	// separated by commas.
+	HashKeyUint64s
+)
+
+// WithHashKeyEncoding sets the encoding of hash keys returned by
+// EncodeHashKey and BandHashKeys.
+func WithHashKeyEncoding(e HashKeyEncoding) Option {
+	return func(f *MinhashLSH) {
+		f.hashKeyEncoding = e
+	}
+}
+
+// BandHashKeys returns the encoded hash key of each band of the signature.
+func (f *MinhashLSH) BandHashKeys(sig []uint64) []string {
+	hs := f.hashKeys(sig)
+	for i := range hs {
+		hs[i] = f.EncodeHashKey(hs[i])
+	}
+	return hs
+}
+
+// EncodeHashKey encodes a raw hash key with the encoding of the index.
+func (f *MinhashLSH) EncodeHashKey(hashKey string) string {
+	switch f.hashKeyEncoding {
+	case HashKeyHex:
+		return hex.EncodeToString([]byte(hashKey))
+	case HashKeyBase64:
+		return base64.RawURLEncoding.EncodeToString([]byte(hashKey))
+	case HashKeyUint64s:
+		size := wordSize(f.HashValueSize)
+		values := make([]string, 0, f.bandSize())
+		var buf [8]byte
+		for i := 0; i+size <= len(hashKey); i += size {
+			copy(buf[:], hashKey[i:i+size])
+			values = append(values, strconv.FormatUint(binary.LittleEndian.Uint64(buf[:]), 10))
+		}
+		return strings.Join(values, ",")
+	}
+	return hashKey
+}
+
+// DecodeHashKey returns the raw hash key of an encoded hash key.
+func (f *MinhashLSH) DecodeHashKey(s string) (string, error) {
+	var b []byte
+	var err error
+	switch f.hashKeyEncoding {
+	case HashKeyHex:
+		b, err = hex.DecodeString(s)
+	case HashKeyBase64:
+		b, err = base64.RawURLEncoding.DecodeString(s)
+	case HashKeyUint64s:
+		values := strings.Split(s, ",")
+		if len(values) != f.bandSize() {
+			return "", errors.New("minhashlsh: wrong number of hash values in hash key")
+		}
+		sig := make([]uint64, len(values))
+		for i, v := range values {
+			if sig[i], err = strconv.ParseUint(v, 10, 8*wordSize(f.HashValueSize)); err != nil {
+				return "", err
+			}
+		}
+		// The values are the ones of the hash key, already salted and
+		// trimmed, so they are encoded as they are.
+		return string(appendKeyFuncGen(f.HashValueSize)(nil, sig)), nil
+	default:
+		return s, nil
+	}
+	if err != nil {
+		return "", err
+	}
+	if len(b) != f.K*f.HashValueSize {
+		return "", errors.New("minhashlsh: wrong hash key size")
+	}
+	return string(b), nil
+}
@@ -0,0 +1,48 @@
+package minhashlsh
+
+import "testing"
+
+func Test_HashKeyEncoding(t *testing.T) {
+	sig := randomSignature(64, 1)
+	for _, e := range []HashKeyEncoding{HashKeyRaw, HashKeyHex, HashKeyBase64, HashKeyUint64s} {
+		f := NewMinhashLSH32(64, 0.5, 1, WithHashKeyEncoding(e))
+		raw := f.hashKeys(sig)
+		for i, s := range f.BandHashKeys(sig) {
+			decoded, err := f.DecodeHashKey(s)
+			if err != nil {
+				t.Fatalf("encoding %d: %v", e, err)
+			}
+			if decoded != raw[i] {
+				t.Errorf("encoding %d: band %d decoded to %q, expected %q", e, i, decoded, raw[i])
+			}
+		}
+	}
+}
+
+func Test_HashKeyUint64sSalt(t *testing.T) {
+	sig := randomSignature(64, 1)
+	for _, opt := range []Option{WithSalt(42), WithTrimPolicy(TrimFold)} {
+		f := NewMinhashLSH32(64, 0.5, 1, WithHashKeyEncoding(HashKeyUint64s), opt)
+		raw := f.hashKeys(sig)
+		for i, s := range f.BandHashKeys(sig) {
+			decoded, err := f.DecodeHashKey(s)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if decoded != raw[i] {
+				t.Errorf("band %d decoded to %q, expected %q", i, decoded, raw[i])
+			}
+		}
+	}
+}
+
+func Test_HashKeyUint64s(t *testing.T) {
+	f := NewMinhashLSH16(4, 0.9, 1, WithHashKeyEncoding(HashKeyUint64s))
+	f.K = 2
+	if s := f.EncodeHashKey(f.HashKeyFunc([]uint64{1, 0x10002})); s != "1,2" {
+		t.Errorf("expected 1,2, got %s", s)
+	}
+	if _, err := f.DecodeHashKey("1,70000"); err == nil {
+		t.Error("expected an error for a value out of range")
+	}
+}
@@ -0,0 +1,73 @@
+package minhashlsh
+
+import "time"
+
+// Hooks are functions called after the operations of an index, for custom
+// metrics, auditing or side effects. Nil hooks are skipped. Hooks are
+// called synchronously by the goroutine running the operation, so they
+// should be fast and must not modify the index.
+type Hooks struct {
+	// OnAdd is called after Add.
+	OnAdd func(AddEvent)
+	// OnIndex is called after Index.
+	OnIndex func(IndexEvent)
+	// OnQuery is called after Query.
+	OnQuery func(QueryEvent)
+}
+
+// AddEvent describes a call to Add.
+type AddEvent struct {
+	Key      interface{}
+	Duration time.Duration
+}
+
+// IndexEvent describes a call to Index. Added is the number of entries per
+// band added since the previous call, before duplicates are collapsed by
+// WithDeduplication, and Indexed the number of entries per band searchable
+// after it.
+type IndexEvent struct {
+	Added    int
+	Indexed  int
+	Duration time.Duration
+}
+
+// QueryEvent describes a call to Query.
+type QueryEvent struct {
+	Candidates int
+	Duration   time.Duration
+}
+
+// WithHooks registers hooks on the index. Hooks registered by several
+// options are all called, in the order of the options.
+func WithHooks(h Hooks) Option {
+	return func(f *MinhashLSH) {
+		f.hooks = append(f.hooks, h)
+	}
+}
+
+func (f *MinhashLSH) onAdd(key interface{}, start time.Time) {
+	e := AddEvent{key, time.Since(start)}
+	for _, h := range f.hooks {
+		if h.OnAdd != nil {
+			h.OnAdd(e)
+		}
+	}
+}
+
+func (f *MinhashLSH) onIndex(added int, start time.Time) {
+	e := IndexEvent{added, f.NumIndexedKeys, time.Since(start)}
+	for _, h := range f.hooks {
+		if h.OnIndex != nil {
+			h.OnIndex(e)
+		}
+	}
+}
+
+func (f *MinhashLSH) onQuery(candidates int, start time.Time) {
+	e := QueryEvent{candidates, time.Since(start)}
+	for _, h := range f.hooks {
+		if h.OnQuery != nil {
+			h.OnQuery(e)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package minhashlsh
+
+import (
+	"strconv"
+	"testing"
+)
+
+func Test_Hooks(t *testing.T) {
+	var adds, indexes, queries int
+	var last IndexEvent
+	var candidates int
+	f := NewMinhashLSH16(64, 0.5, 0,
+		WithHooks(Hooks{OnAdd: func(e AddEvent) { adds++ }}),
+		WithHooks(Hooks{
+			OnIndex: func(e IndexEvent) { indexes++; last = e },
+			OnQuery: func(e QueryEvent) { queries++; candidates = e.Candidates },
+		}))
+	for i := 0; i < 5; i++ {
+		f.Add(strconv.Itoa(i), randomSignature(64, int64(i)))
+	}
+	f.Index()
+	f.Add("5", randomSignature(64, 5))
+	f.Index()
+	f.Query(randomSignature(64, 5))
+	if adds != 6 || indexes != 2 || queries != 1 {
+		t.Errorf("expected 6 adds, 2 indexes and 1 query, got %d, %d and %d", adds, indexes, queries)
+	}
+	if last.Added != 1 || last.Indexed != 6 {
+		t.Errorf("expected 1 added and 6 indexed keys, got %v", last)
+	}
+	if candidates < 1 {
+		t.Errorf("expected candidates in the query event, got %d", candidates)
+	}
+}
+
+func Test_HooksDeduplication(t *testing.T) {
+	var last IndexEvent
+	f := NewMinhashLSH16(64, 0.5, 0, WithDeduplication(),
+		WithHooks(Hooks{OnIndex: func(e IndexEvent) { last = e }}))
+	sig := randomSignature(64, 1)
+	f.Add("a", sig)
+	f.Index()
+	f.Add("a", sig)
+	f.Add("a", sig)
+	f.Index()
+	if last.Added != 2 || last.Indexed != 1 {
+		t.Errorf("expected 2 added and 1 indexed entries, got %v", last)
+	}
+}
@@ -0,0 +1,116 @@
+package minhashlsh
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// SignatureReader reads (key, signature) pairs, such as the ones produced
+// by offline sketching jobs. Read returns io.EOF when there are no more pairs.
+// Only CSV is built in: reading Parquet needs a Parquet library, which
+// would add a dependency to the package. Readers for other formats, e.g.
+// Parquet, can implement this interface to be used with AddFrom.
+type SignatureReader interface {
+	Read() (key string, sig []uint64, err error)
+}
+
+// CSVSignatureReader reads signatures from CSV files.
+// Each row holds the key followed by either one hash value per column,
+// or a single column with the hash values as a list, e.g. "[1, 2, 3]",
+// as written by pandas and Spark for array columns.
+// Hash values may be signed, as Spark and pandas store 64-bit hash values
+// as int64. A first row without valid hash values is skipped as header.
+type CSVSignatureReader struct {
+	r    *csv.Reader
+	line int
+}
+
+// NewCSVSignatureReader returns a reader of signatures from CSV data.
+func NewCSVSignatureReader(r io.Reader) *CSVSignatureReader {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	return &CSVSignatureReader{r: cr}
+}
+
+// Read returns the next key and signature.
+func (c *CSVSignatureReader) Read() (string, []uint64, error) {
+	for {
+		record, err := c.r.Read()
+		if err != nil {
+			return "", nil, err
+		}
+		c.line++
+		if len(record) < 2 {
+			return "", nil, fmt.Errorf("minhashlsh: line %d: missing signature", c.line)
+		}
+		sig, err := parseSignature(record[1:])
+		if err != nil {
+			if c.line == 1 {
+				continue
+			}
+			return "", nil, fmt.Errorf("minhashlsh: line %d: %v", c.line, err)
+		}
+		return record[0], sig, nil
+	}
+}
+
+// Line returns the number of the row of the last signature read,
+// counting from 1.
+func (c *CSVSignatureReader) Line() int {
+	return c.line
+}
+
+func parseSignature(fields []string) ([]uint64, error) {
+	if len(fields) == 1 {
+		list := strings.TrimSpace(fields[0])
+		if strings.HasPrefix(list, "[") && strings.HasSuffix(list, "]") {
+			list = strings.TrimSpace(list[1 : len(list)-1])
+			if list == "" {
+				return nil, fmt.Errorf("empty signature")
+			}
+			fields = strings.Split(list, ",")
+		}
+	}
+	sig := make([]uint64, len(fields))
+	for i, field := range fields {
+		v, err := parseHashValue(strings.TrimSpace(field))
+		if err != nil {
+			return nil, err
+		}
+		sig[i] = v
+	}
+	return sig, nil
+}
+
+func parseHashValue(s string) (uint64, error) {
+	if strings.HasPrefix(s, "-") {
+		v, err := strconv.ParseInt(s, 10, 64)
+		return uint64(v), err
+	}
+	return strconv.ParseUint(s, 10, 64)
+}
+
+// AddFrom adds all keys and signatures read from r to the index,
+// returning the number of keys added.
+// The keys won't be searchable until Index() is called.
+func (f *MinhashLSH) AddFrom(r SignatureReader) (int, error) {
+	var n int
+	for {
+		key, sig, err := r.Read()
+		if err == io.EOF {
+			return n, nil
+		}
+		if err != nil {
+			return n, err
+		}
+		if len(sig) < f.SignatureSize() {
+			return n, fmt.Errorf("minhashlsh: signature of %q has %d hash values, expected at least %d",
+				key, len(sig), f.SignatureSize())
+		}
+		f.Add(key, sig)
+		n++
+	}
+}
@@ -0,0 +1,58 @@
+package minhashlsh
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func Test_CSVSignatureReader(t *testing.T) {
+	data := "key,signature\n" +
+		"a,\"[1, 2, 3]\"\n" +
+		"b,4,5,-1\n"
+	r := NewCSVSignatureReader(strings.NewReader(data))
+	expected := map[string][]uint64{
+		"a": {1, 2, 3},
+		"b": {4, 5, ^uint64(0)},
+	}
+	for i := 0; i < len(expected); i++ {
+		key, sig, err := r.Read()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fmt.Sprint(sig) != fmt.Sprint(expected[key]) {
+			t.Errorf("%s: expected %v, got %v", key, expected[key], sig)
+		}
+		if r.Line() != i+2 {
+			t.Errorf("%s: expected line %d, got %d", key, i+2, r.Line())
+		}
+	}
+	if _, _, err := r.Read(); err == nil {
+		t.Fatal("expected io.EOF")
+	}
+}
+
+func Test_AddFrom(t *testing.T) {
+	sigs := make([]string, 10)
+	for i := range sigs {
+		sig := randomSignature(64, int64(i))
+		values := make([]string, len(sig))
+		for j, v := range sig {
+			values[j] = fmt.Sprint(v)
+		}
+		sigs[i] = fmt.Sprintf("key%d,%s", i, strings.Join(values, ","))
+	}
+	f := NewMinhashLSH16(64, 0.5, len(sigs))
+	n, err := f.AddFrom(NewCSVSignatureReader(strings.NewReader(strings.Join(sigs, "\n"))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != len(sigs) {
+		t.Fatalf("expected %d keys, got %d", len(sigs), n)
+	}
+	f.Index()
+	results := f.Query(randomSignature(64, 3))
+	if len(results) != 1 || results[0].(string) != "key3" {
+		t.Fatal(results)
+	}
+}
@@ -0,0 +1,99 @@
+package minhashlsh
+
+import (
+	"hash/fnv"
+	"sort"
+)
+
+// Jaccard computes the exact Jaccard similarity of two token sets
+// using hash set intersection. Duplicate tokens are ignored.
+func Jaccard(a, b [][]byte) float64 {
+	if len(a) < len(b) {
+		a, b = b, a
+	}
+	set := make(map[string]bool, len(a))
+	for _, token := range a {
+		set[string(token)] = true
+	}
+	var intersection int
+	seen := make(map[string]bool, len(b))
+	for _, token := range b {
+		if seen[string(token)] {
+			continue
+		}
+		seen[string(token)] = true
+		if set[string(token)] {
+			intersection++
+		}
+	}
+	union := len(set) + len(seen) - intersection
+	if union == 0 {
+		return 0
+	}
+	return float64(intersection) / float64(union)
+}
+
+type uint64Slice []uint64
+
+func (s uint64Slice) Len() int           { return len(s) }
+func (s uint64Slice) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s uint64Slice) Less(i, j int) bool { return s[i] < s[j] }
+
+// HashSet returns the sorted and deduplicated 64-bit hash values of tokens,
+// a compact representation of a set for JaccardSorted.
+func HashSet(tokens [][]byte) []uint64 {
+	h := fnv.New64a()
+	set := make([]uint64, len(tokens))
+	for i, token := range tokens {
+		h.Reset()
+		h.Write(token)
+		set[i] = h.Sum64()
+	}
+	sort.Sort(uint64Slice(set))
+	var n int
+	for i, v := range set {
+		if i == 0 || v != set[n-1] {
+			set[n] = v
+			n++
+		}
+	}
+	return set[:n]
+}
+
+// JaccardSorted computes the exact Jaccard similarity of two sets given as
+// sorted and deduplicated slices, such as the ones returned by HashSet,
+// using a sorted merge.
+func JaccardSorted(a, b []uint64) float64 {
+	var i, j, intersection int
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] < b[j]:
+			i++
+		case a[i] > b[j]:
+			j++
+		default:
+			intersection++
+			i++
+			j++
+		}
+	}
+	union := len(a) + len(b) - intersection
+	if union == 0 {
+		return 0
+	}
+	return float64(intersection) / float64(union)
+}
+
+// FilterExact returns the candidates whose exact Jaccard similarity with
+// the query set is at least threshold, removing the false positives of LSH.
+// The sets are sorted and deduplicated slices as returned by HashSet,
+// set returns the one of a candidate key.
+func FilterExact(query []uint64, candidates []interface{}, set func(key interface{}) []uint64, threshold float64) []interface{} {
+	results := make([]interface{}, 0, len(candidates))
+	for _, key := range candidates {
+		if JaccardSorted(query, set(key)) >= threshold {
+			results = append(results, key)
+		}
+	}
+	return results
+}
@@ -0,0 +1,41 @@
+package minhashlsh
+
+import (
+	"math"
+	"testing"
+)
+
+func tokens(words ...string) [][]byte {
+	t := make([][]byte, len(words))
+	for i, w := range words {
+		t[i] = []byte(w)
+	}
+	return t
+}
+
+func Test_Jaccard(t *testing.T) {
+	a := tokens("a", "b", "c", "d", "a")
+	b := tokens("c", "d", "e")
+	if j := Jaccard(a, b); math.Abs(j-0.4) > 1e-9 {
+		t.Errorf("expected 0.4, got %f", j)
+	}
+	if j := JaccardSorted(HashSet(a), HashSet(b)); math.Abs(j-0.4) > 1e-9 {
+		t.Errorf("expected 0.4, got %f", j)
+	}
+	if j := Jaccard(nil, nil); j != 0 {
+		t.Errorf("expected 0, got %f", j)
+	}
+}
+
+func Test_FilterExact(t *testing.T) {
+	sets := map[interface{}][]uint64{
+		"similar":   HashSet(tokens("a", "b", "c", "d")),
+		"different": HashSet(tokens("a", "x", "y", "z")),
+	}
+	query := HashSet(tokens("a", "b", "c"))
+	results := FilterExact(query, []interface{}{"similar", "different"},
+		func(key interface{}) []uint64 { return sets[key] }, 0.5)
+	if len(results) != 1 || results[0] != "similar" {
+		t.Fatal(results)
+	}
+}
@@ -0,0 +1,66 @@
+package minhashlsh
+
+import (
+	"errors"
+	"reflect"
+)
+
+// ErrIncompatibleIndex is returned when combining indexes with different
+// parameters.
+var ErrIncompatibleIndex = errors.New("minhashlsh: indexes have different K, L, bands, salt, trim policy or hash value size")
+
+// Join returns the candidate pairs across two indexes built with the same
+// parameters, salt and trim policy, such as the indexes of two crawls: each
+// pair holds a key of f and a key of other colliding in at least one band. The threshold is the
+// one the indexes were built for, as it determines K and L.
+// Rather than querying other with every signature of f, the sorted hash
+// tables of each band are merged, so the join runs in linear time besides
+// the pairs found. Only indexed keys are joined.
+func (f *MinhashLSH) Join(other *MinhashLSH) ([]Pair, error) {
+	if f.K != other.K || f.L != other.L || f.HashValueSize != other.HashValueSize ||
+		f.salt != other.salt || f.trim != other.trim || !reflect.DeepEqual(f.bands, other.bands) {
+		return nil, ErrIncompatibleIndex
+	}
+	seen := make(map[Pair]bool)
+	pairs := make([]Pair, 0)
+	for band := 0; band < f.L; band++ {
+		a := f.table(band)[:f.NumIndexedKeys]
+		b := other.table(band)[:other.NumIndexedKeys]
+		i, j := 0, 0
+		for i < len(a) && j < len(b) {
+			if a[i].HashKey < b[j].HashKey {
+				i++
+				continue
+			}
+			if a[i].HashKey > b[j].HashKey {
+				j++
+				continue
+			}
+			hashKey := a[i].HashKey
+			endA, endB := i, j
+			for endA < len(a) && a[endA].HashKey == hashKey {
+				endA++
+			}
+			for endB < len(b) && b[endB].HashKey == hashKey {
+				endB++
+			}
+			for _, ea := range a[i:endA] {
+				if f.isHidden(ea.Key) {
+					continue
+				}
+				for _, eb := range b[j:endB] {
+					if other.isHidden(eb.Key) {
+						continue
+					}
+					p := Pair{ea.Key, eb.Key}
+					if !seen[p] {
+						seen[p] = true
+						pairs = append(pairs, p)
+					}
+				}
+			}
+			i, j = endA, endB
+		}
+	}
+	return pairs, nil
+}
@@ -0,0 +1,65 @@
+package minhashlsh
+
+import "testing"
+
+func Test_Join(t *testing.T) {
+	f := NewMinhashLSH16(64, 0.5, 0)
+	g := NewMinhashLSH16(64, 0.5, 0)
+	for i := 0; i < 100; i++ {
+		f.Add(i, randomSignature(64, int64(i)))
+		g.Add(-i, randomSignature(64, int64(i+50)))
+	}
+	f.Index()
+	g.Index()
+	pairs, err := f.Join(g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := make(map[Pair]bool)
+	for i := 0; i < 100; i++ {
+		for _, key := range g.Query(randomSignature(64, int64(i))) {
+			expected[Pair{i, key}] = true
+		}
+	}
+	if len(pairs) != len(expected) {
+		t.Errorf("expected %d pairs, got %d", len(expected), len(pairs))
+	}
+	for _, p := range pairs {
+		if !expected[p] {
+			t.Errorf("unexpected pair %v", p)
+		}
+	}
+	if len(expected) < 50 {
+		t.Errorf("expected at least 50 pairs, got %d", len(expected))
+	}
+
+	if _, err := f.Join(NewMinhashLSH32(64, 0.5, 0)); err != ErrIncompatibleIndex {
+		t.Errorf("expected ErrIncompatibleIndex, got %v", err)
+	}
+}
+
+func Test_JoinSalt(t *testing.T) {
+	f := NewMinhashLSH16(64, 0.5, 0, WithSalt(1))
+	g := NewMinhashLSH16(64, 0.5, 0, WithSalt(2))
+	f.Add(1, randomSignature(64, 1))
+	g.Add(2, randomSignature(64, 1))
+	f.Index()
+	g.Index()
+	if _, err := f.Join(g); err != ErrIncompatibleIndex {
+		t.Errorf("expected ErrIncompatibleIndex, got %v", err)
+	}
+	h := NewMinhashLSH16(64, 0.5, 0, WithSalt(1))
+	h.Add(3, randomSignature(64, 1))
+	h.Index()
+	if pairs, err := f.Join(h); err != nil || len(pairs) != 1 {
+		t.Errorf("expected 1 pair with the same salt, got %v, %v", pairs, err)
+	}
+}
+
+func Test_JoinTrimPolicy(t *testing.T) {
+	f := NewMinhashLSH16(64, 0.5, 0)
+	g := NewMinhashLSH16(64, 0.5, 0, WithTrimPolicy(TrimFold))
+	if _, err := f.Join(g); err != ErrIncompatibleIndex {
+		t.Errorf("expected ErrIncompatibleIndex, got %v", err)
+	}
+}
@@ -0,0 +1,128 @@
+package minhashlsh
+
+import (
+	"encoding"
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// KeyCodec encodes the keys of an index, replacing the built-in encoding of
+// Save and Encode, which falls back to gob for types other than strings and
+// numbers. The same codec must be given to Load and Decode.
+type KeyCodec interface {
+	// AppendKey appends the encoding of key to buf.
+	AppendKey(buf []byte, key interface{}) ([]byte, error)
+	// DecodeKey decodes a key encoded by AppendKey.
+	DecodeKey(b []byte) (interface{}, error)
+}
+
+// ErrKeyCodecRequired is returned when loading an index with keys
+// encoded by a KeyCodec without giving one.
+var ErrKeyCodecRequired = errors.New("minhashlsh: index keys were encoded with a KeyCodec")
+
+// WithKeyCodec encodes or decodes the keys of the index with c.
+func WithKeyCodec(c KeyCodec) PersistOption {
+	return func(config *persistConfig) {
+		config.keyCodec = c
+	}
+}
+
+type keyTypeError struct {
+	codec string
+	key   interface{}
+}
+
+func (e *keyTypeError) Error() string {
+	return fmt.Sprintf("minhashlsh: %s key codec cannot encode key of type %T", e.codec, e.key)
+}
+
+type stringKeyCodec struct{}
+
+// StringKeyCodec encodes string keys as their bytes.
+var StringKeyCodec KeyCodec = stringKeyCodec{}
+
+func (stringKeyCodec) AppendKey(buf []byte, key interface{}) ([]byte, error) {
+	s, ok := key.(string)
+	if !ok {
+		return nil, &keyTypeError{"string", key}
+	}
+	return append(buf, s...), nil
+}
+
+func (stringKeyCodec) DecodeKey(b []byte) (interface{}, error) {
+	return string(b), nil
+}
+
+type int64KeyCodec struct{}
+
+// Int64KeyCodec encodes int64 keys as varints.
+var Int64KeyCodec KeyCodec = int64KeyCodec{}
+
+func (int64KeyCodec) AppendKey(buf []byte, key interface{}) ([]byte, error) {
+	v, ok := key.(int64)
+	if !ok {
+		return nil, &keyTypeError{"int64", key}
+	}
+	var b [binary.MaxVarintLen64]byte
+	return append(buf, b[:binary.PutVarint(b[:], v)]...), nil
+}
+
+func (int64KeyCodec) DecodeKey(b []byte) (interface{}, error) {
+	v, n := binary.Varint(b)
+	if n != len(b) {
+		return nil, errors.New("invalid int64 key")
+	}
+	return v, nil
+}
+
+type uuidKeyCodec struct{}
+
+// UUIDKeyCodec encodes [16]byte keys, such as UUIDs, as their 16 bytes.
+// Named UUID types need to be converted to [16]byte before being added.
+var UUIDKeyCodec KeyCodec = uuidKeyCodec{}
+
+func (uuidKeyCodec) AppendKey(buf []byte, key interface{}) ([]byte, error) {
+	id, ok := key.([16]byte)
+	if !ok {
+		return nil, &keyTypeError{"UUID", key}
+	}
+	return append(buf, id[:]...), nil
+}
+
+func (uuidKeyCodec) DecodeKey(b []byte) (interface{}, error) {
+	var id [16]byte
+	if len(b) != len(id) {
+		return nil, errors.New("invalid UUID key")
+	}
+	copy(id[:], b)
+	return id, nil
+}
+
+// binaryKeyCodec encodes keys implementing encoding.BinaryMarshaler.
+type binaryKeyCodec struct {
+	decode func([]byte) (interface{}, error)
+}
+
+// BinaryKeyCodec encodes keys with their MarshalBinary method, and decodes
+// them with decode. Byte slices cannot be keys as they are not comparable,
+// this codec serves comparable types holding binary data instead.
+func BinaryKeyCodec(decode func(b []byte) (interface{}, error)) KeyCodec {
+	return binaryKeyCodec{decode}
+}
+
+func (c binaryKeyCodec) AppendKey(buf []byte, key interface{}) ([]byte, error) {
+	m, ok := key.(encoding.BinaryMarshaler)
+	if !ok {
+		return nil, &keyTypeError{"binary", key}
+	}
+	b, err := m.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	return append(buf, b...), nil
+}
+
+func (c binaryKeyCodec) DecodeKey(b []byte) (interface{}, error) {
+	return c.decode(b)
+}
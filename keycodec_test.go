@@ -0,0 +1,51 @@
+package minhashlsh
+
+import (
+	"bytes"
+	"testing"
+)
+
+func Test_KeyCodec(t *testing.T) {
+	for _, c := range []struct {
+		codec KeyCodec
+		key   func(i int) interface{}
+	}{
+		{StringKeyCodec, func(i int) interface{} { return string(rune('a' + i)) }},
+		{Int64KeyCodec, func(i int) interface{} { return int64(-i) }},
+		{UUIDKeyCodec, func(i int) interface{} { return [16]byte{15: byte(i)} }},
+	} {
+		f := NewMinhashLSH16(64, 0.5, 10)
+		for i := 0; i < 10; i++ {
+			f.Add(c.key(i), randomSignature(64, int64(i)))
+		}
+		f.Index()
+		var buf bytes.Buffer
+		if err := f.Encode(&buf, WithKeyCodec(c.codec)); err != nil {
+			t.Fatal(err)
+		}
+		data := buf.Bytes()
+		if _, err := Decode(bytes.NewReader(data)); err != ErrKeyCodecRequired {
+			t.Errorf("expected ErrKeyCodecRequired, got %v", err)
+		}
+		loaded, err := Decode(bytes.NewReader(data), WithKeyCodec(c.codec))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < 10; i++ {
+			found := false
+			for _, key := range loaded.Query(randomSignature(64, int64(i))) {
+				found = found || key == c.key(i)
+			}
+			if !found {
+				t.Errorf("key %v not found", c.key(i))
+			}
+		}
+	}
+}
+
+func Test_KeyCodecWrongType(t *testing.T) {
+	f := testIndex(1)
+	if err := f.Encode(&bytes.Buffer{}, WithKeyCodec(Int64KeyCodec)); err == nil {
+		t.Error("expected an error encoding string keys with Int64KeyCodec")
+	}
+}
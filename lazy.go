@@ -0,0 +1,177 @@
+package minhashlsh
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"sync"
+	"sync/atomic"
+)
+
+// WithLazyLoading makes Load return as soon as the index parameters are
+// decoded, the hash table of each band is decoded on first use instead.
+// The file is kept open until the hash tables of all bands are loaded.
+// As Add and Query cannot return errors, they panic with
+// a *CorruptIndexError if a band fails to load; call Materialize
+// to load all bands with error handling.
+func WithLazyLoading() PersistOption {
+	return func(c *persistConfig) {
+		c.lazy = true
+	}
+}
+
+// lazyTables loads the hash tables of a lazily loaded index.
+type lazyTables struct {
+	r      io.ReaderAt
+	closer io.Closer
+	config *persistConfig
+	// The offsets of the band sections, followed by the end offset
+	// of the last one.
+	offsets   []uint64
+	once      []sync.Once
+	errs      []error
+	remaining int32
+}
+
+type sizedReaderAt interface {
+	io.ReaderAt
+	Size() int64
+}
+
+// readLazy reads the index parameters from r holding an index of the
+// given size. The closer, if any, is closed once all bands are loaded.
+func readLazy(r io.ReaderAt, size int64, closer io.Closer, config *persistConfig) (*MinhashLSH, error) {
+	return traceLoad(config, func() (*MinhashLSH, error) {
+		return readLazyHeader(r, size, closer, config)
+	})
+}
+
+func readLazyHeader(r io.ReaderAt, size int64, closer io.Closer, config *persistConfig) (*MinhashLSH, error) {
+	if size < int64(fileHeaderSize+footerTrailSize) {
+		return nil, &CorruptIndexError{"file too small"}
+	}
+	header := make([]byte, fileHeaderSize)
+	if _, err := r.ReadAt(header, 0); err != nil {
+		return nil, err
+	}
+	config, err := readFileHeader(header, config)
+	if err != nil {
+		return nil, err
+	}
+	offsets, err := readFooterAt(r, size)
+	if err != nil {
+		return nil, err
+	}
+	footerOffset := uint64(size) - uint64(8*len(offsets)+footerTrailSize)
+	offsets = append(offsets, footerOffset)
+	for i := 1; i < len(offsets); i++ {
+		if offsets[i] < offsets[i-1] {
+			return nil, &CorruptIndexError{"invalid section offsets"}
+		}
+	}
+
+	lshIndex, err := decodeHeader(sectionReader(r, offsets[0], offsets[1]), config)
+	if err != nil {
+		return nil, err
+	}
+	if len(offsets) != lshIndex.L+2 {
+		return nil, &CorruptIndexError{"number of sections does not match L"}
+	}
+	if _, err := lshIndex.selectBands(config.bands); err != nil {
+		return nil, err
+	}
+	lshIndex.lazy = &lazyTables{
+		r:         r,
+		closer:    closer,
+		config:    config,
+		offsets:   offsets[1:],
+		once:      make([]sync.Once, lshIndex.L),
+		errs:      make([]error, lshIndex.L),
+		remaining: int32(lshIndex.L),
+	}
+	return lshIndex, nil
+}
+
+// readFooterAt reads the section offsets from the footer at the end
+// of a file of the given size.
+func readFooterAt(r io.ReaderAt, size int64) ([]uint64, error) {
+	trail := make([]byte, footerTrailSize)
+	if _, err := r.ReadAt(trail, size-int64(footerTrailSize)); err != nil {
+		return nil, err
+	}
+	numSections := int64(binary.LittleEndian.Uint32(trail[4:]))
+	footerSize := 8*numSections + int64(footerTrailSize)
+	if footerSize > size-int64(fileHeaderSize) {
+		return nil, &CorruptIndexError{"invalid footer"}
+	}
+	footer := make([]byte, footerSize)
+	if _, err := r.ReadAt(footer, size-footerSize); err != nil {
+		return nil, err
+	}
+	return parseFooter(footer)
+}
+
+func sectionReader(r io.ReaderAt, start, end uint64) io.Reader {
+	return bufio.NewReader(io.NewSectionReader(r, int64(start), int64(end-start)))
+}
+
+// load decodes the hash table of band i unless it is loaded already.
+func (l *lazyTables) load(f *MinhashLSH, i int) error {
+	l.once[i].Do(func() {
+		section := i
+		if f.bands != nil {
+			section = f.bands[i]
+		}
+		f.HashTables[i], l.errs[i] = f.decodeTable(
+			sectionReader(l.r, l.offsets[section], l.offsets[section+1]), l.config, section)
+		if atomic.AddInt32(&l.remaining, -1) == 0 && l.closer != nil {
+			l.closer.Close()
+		}
+	})
+	return l.errs[i]
+}
+
+// table returns the hash table of band i, loading it first if the index
+// was loaded lazily.
+func (f *MinhashLSH) table(i int) hashTable {
+	if f.lazy != nil {
+		if err := f.lazy.load(f, i); err != nil {
+			panic(err)
+		}
+	}
+	return f.HashTables[i]
+}
+
+// Materialize loads the hash tables of all bands that have not been used
+// yet if the index was loaded lazily, otherwise it does nothing.
+// Bands are loaded concurrently as set by WithParallelism.
+func (f *MinhashLSH) Materialize() error {
+	if f.lazy == nil {
+		return nil
+	}
+	sem := make(chan struct{}, f.lazy.config.workers(len(f.HashTables)))
+	var wg sync.WaitGroup
+	for i := range f.HashTables {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			f.lazy.load(f, i)
+			<-sem
+		}(i)
+	}
+	wg.Wait()
+	for i := range f.HashTables {
+		if err := f.lazy.load(f, i); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// materialize is Materialize for methods that cannot return errors.
+func (f *MinhashLSH) materialize() {
+	if err := f.Materialize(); err != nil {
+		panic(err)
+	}
+}
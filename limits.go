@@ -0,0 +1,90 @@
+package minhashlsh
+
+import (
+	"errors"
+	"unsafe"
+)
+
+// ErrIndexFull is returned by TryAdd when adding a key would exceed
+// the limits of the index.
+var ErrIndexFull = errors.New("minhashlsh: index is full")
+
+// indexLimits are the hard limits of the size of an index, zero meaning
+// no limit.
+type indexLimits struct {
+	entries int
+	memory  int64
+}
+
+// WithMaxEntries limits the number of entries of each band to n: added keys,
+// whether indexed or not, and removed keys until compaction.
+// Adding more fails with ErrIndexFull.
+func WithMaxEntries(n int) Option {
+	return func(f *MinhashLSH) {
+		if f.limits == nil {
+			f.limits = &indexLimits{}
+		}
+		f.limits.entries = n
+	}
+}
+
+// WithMaxMemory limits the memory used by the hash tables, as estimated by
+// MemoryUsage, to about the given number of bytes.
+// Adding more fails with ErrIndexFull.
+func WithMaxMemory(bytes int64) Option {
+	return func(f *MinhashLSH) {
+		if f.limits == nil {
+			f.limits = &indexLimits{}
+		}
+		f.limits.memory = bytes
+	}
+}
+
+// entrySize is the size of an entry excluding its hash key bytes and key.
+const entrySize = int64(unsafe.Sizeof(entry{}))
+
+// MemoryUsage estimates the memory used by the entries of the hash tables,
+// excluding the memory referenced by keys and the spare capacity of tables.
+func (f *MinhashLSH) MemoryUsage() int64 {
+	return f.entriesMemory(len(f.table(0)))
+}
+
+func (f *MinhashLSH) entriesMemory(numEntries int) int64 {
+	return int64(f.L) * int64(numEntries) * (entrySize + int64(f.K*f.HashValueSize))
+}
+
+// TryAdd is Add returning ErrIndexFull instead of adding the key when
+// the index is at its limits, see WithMaxEntries and WithMaxMemory,
+// so callers can degrade gracefully. Add panics with ErrIndexFull
+// in that case.
+func (f *MinhashLSH) TryAdd(key interface{}, sig []uint64) error {
+	if err := f.Materialize(); err != nil {
+		return err
+	}
+	if err := f.checkLimits(); err != nil {
+		return err
+	}
+	f.Add(key, sig)
+	return nil
+}
+
+// checkLimits returns ErrIndexFull if one more key cannot be added.
+func (f *MinhashLSH) checkLimits() error {
+	return f.checkLimitsFor(1)
+}
+
+// checkLimitsFor returns ErrIndexFull if the given number of keys cannot
+// be added.
+func (f *MinhashLSH) checkLimitsFor(keys int) error {
+	if f.limits == nil {
+		return nil
+	}
+	n := len(f.table(0)) + keys
+	if f.limits.entries > 0 && n > f.limits.entries {
+		return ErrIndexFull
+	}
+	if f.limits.memory > 0 && f.entriesMemory(n) > f.limits.memory {
+		return ErrIndexFull
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package minhashlsh
+
+import "testing"
+
+func Test_TryAdd(t *testing.T) {
+	f := NewMinhashLSH16(64, 0.5, 0, WithMaxEntries(3))
+	for i := 0; i < 3; i++ {
+		if err := f.TryAdd(i, randomSignature(64, int64(i))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := f.TryAdd(3, randomSignature(64, 3)); err != ErrIndexFull {
+		t.Errorf("expected ErrIndexFull, got %v", err)
+	}
+	if len(f.HashTables[0]) != 3 {
+		t.Errorf("expected 3 entries, got %d", len(f.HashTables[0]))
+	}
+	func() {
+		defer func() {
+			if r := recover(); r != ErrIndexFull {
+				t.Errorf("expected Add to panic with ErrIndexFull, got %v", r)
+			}
+		}()
+		f.Add(3, randomSignature(64, 3))
+	}()
+
+	g := NewMinhashLSH16(64, 0.5, 0)
+	g.Add(0, randomSignature(64, 0))
+	limited := NewMinhashLSH16(64, 0.5, 0, WithMaxMemory(2*g.MemoryUsage()))
+	for i := 0; i < 2; i++ {
+		if err := limited.TryAdd(i, randomSignature(64, int64(i))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := limited.TryAdd(2, randomSignature(64, 2)); err != ErrIndexFull {
+		t.Errorf("expected ErrIndexFull, got %v", err)
+	}
+}
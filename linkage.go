@@ -0,0 +1,134 @@
+package minhashlsh
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Record is a record of an entity resolution pipeline, with its field
+// values by name.
+type Record struct {
+	Key    interface{}
+	Fields map[string]string
+}
+
+// BlockingField describes how a field of records is compared.
+type BlockingField struct {
+	Name string
+	// Shingle is the number of characters of the shingles of the values,
+	// such as 3 for names and addresses.
+	Shingle int
+	// Threshold is the Jaccard similarity of the shingles of two values
+	// above which records are likely candidates.
+	Threshold float64
+	// Normalize, if set, transforms values before shingling, such as
+	// keeping only the digits of phone numbers. Values are lowercased,
+	// and runs of spaces collapsed, by default.
+	Normalize func(string) string
+}
+
+// BlockingIndex blocks records for entity resolution: each field is sketched
+// from its character shingles and indexed on its own, and records sharing
+// at least minFields similar fields are candidate pairs, to be compared by
+// the pipeline. Records missing a field are not indexed on that field.
+type BlockingIndex struct {
+	seed      int64
+	numHash   int
+	minFields int
+	fields    []BlockingField
+	indexes   []*MinhashLSH
+	records   []Record
+	order     map[interface{}]int
+}
+
+// NewBlockingIndex creates a blocking index over the given fields, sketched
+// by Minhash with the given seed and number of hash functions.
+func NewBlockingIndex(seed int64, numHash int, minFields int, fields ...BlockingField) *BlockingIndex {
+	b := &BlockingIndex{
+		seed:      seed,
+		numHash:   numHash,
+		minFields: minFields,
+		fields:    fields,
+		order:     make(map[interface{}]int),
+	}
+	for _, field := range fields {
+		b.indexes = append(b.indexes, NewMinhashLSH32(numHash, field.Threshold, 0))
+	}
+	return b
+}
+
+// fieldSignature returns the signature of field i of a record, or nil if
+// the record has no value for it.
+func (b *BlockingIndex) fieldSignature(r Record, i int) []uint64 {
+	field := b.fields[i]
+	value, exist := r.Fields[field.Name]
+	if field.Normalize != nil {
+		value = field.Normalize(value)
+	} else {
+		value = strings.ToLower(strings.Join(strings.FieldsFunc(value, unicode.IsSpace), " "))
+	}
+	if !exist || value == "" {
+		return nil
+	}
+	runes := []rune(value)
+	mh := NewMinhash(b.seed, b.numHash)
+	if len(runes) < field.Shingle {
+		mh.Push([]byte(value))
+	}
+	for j := 0; j+field.Shingle <= len(runes); j++ {
+		mh.Push([]byte(string(runes[j : j+field.Shingle])))
+	}
+	return mh.Signature()
+}
+
+// Add adds a record. It won't be blocked with the records added later
+// until Index is called.
+func (b *BlockingIndex) Add(r Record) {
+	b.order[r.Key] = len(b.records)
+	b.records = append(b.records, r)
+	for i, lsh := range b.indexes {
+		if sig := b.fieldSignature(r, i); sig != nil {
+			lsh.Add(r.Key, sig)
+		}
+	}
+}
+
+// Index makes all the records added searchable.
+func (b *BlockingIndex) Index() {
+	for _, lsh := range b.indexes {
+		lsh.Index()
+	}
+}
+
+// Candidates returns the keys of the indexed records sharing at least
+// minFields similar fields with the record.
+func (b *BlockingIndex) Candidates(r Record) []interface{} {
+	counts := make(map[interface{}]int)
+	results := make([]interface{}, 0)
+	for i, lsh := range b.indexes {
+		sig := b.fieldSignature(r, i)
+		if sig == nil {
+			continue
+		}
+		for _, key := range lsh.query(sig).keys {
+			if counts[key]++; counts[key] == b.minFields {
+				results = append(results, key)
+			}
+		}
+	}
+	return results
+}
+
+// Pairs returns the candidate pairs of the indexed records, each once, with
+// the key of the record added first as Key1.
+func (b *BlockingIndex) Pairs() []Pair {
+	pairs := make([]Pair, 0)
+	for i, r := range b.records {
+		for _, key := range b.Candidates(r) {
+			if b.order[key] > i {
+				pairs = append(pairs, Pair{r.Key, key})
+			}
+		}
+	}
+	return pairs
+}
@@ -0,0 +1,38 @@
+package minhashlsh
+
+import (
+	"strings"
+	"testing"
+	"unicode"
+)
+
+func Test_BlockingIndex(t *testing.T) {
+	digits := func(s string) string {
+		return strings.Map(func(r rune) rune {
+			if unicode.IsDigit(r) {
+				return r
+			}
+			return -1
+		}, s)
+	}
+	b := NewBlockingIndex(1, 128, 2,
+		BlockingField{Name: "name", Shingle: 3, Threshold: 0.5},
+		BlockingField{Name: "address", Shingle: 3, Threshold: 0.5},
+		BlockingField{Name: "phone", Shingle: 4, Threshold: 0.8, Normalize: digits},
+	)
+	records := []Record{
+		{1, map[string]string{"name": "Jonathan Smith", "address": "12 Baker Street, London", "phone": "+44 20 7946 0018"}},
+		{2, map[string]string{"name": "jonathan  smith", "address": "12 Baker St, London", "phone": "(44) 20-7946-0018"}},
+		{3, map[string]string{"name": "Jonathan Smith", "address": "7 Rue de Rivoli, Paris"}},
+		{4, map[string]string{"name": "Maria Garcia", "phone": "+44 20 7946 0018"}},
+	}
+	for _, r := range records {
+		b.Add(r)
+	}
+	b.Index()
+	pairs := b.Pairs()
+	// 3 only shares the name with 1 and 2, 4 only the phone.
+	if len(pairs) != 1 || pairs[0] != (Pair{1, 2}) {
+		t.Errorf("expected the pair (1, 2), got %v", pairs)
+	}
+}
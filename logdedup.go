@@ -0,0 +1,137 @@
+package minhashlsh
+
+import (
+	"strings"
+	"unicode"
+)
+
+// LogMask replaces the variable tokens of log lines.
+const LogMask = "<*>"
+
+// MaskLogLine replaces the tokens of a log line holding numbers, such as
+// counts, durations, addresses and request IDs, and the long hexadecimal
+// tokens, such as hashes, by LogMask, leaving the template of the line.
+// Tokens are separated by spaces, and the punctuation around them is kept.
+func MaskLogLine(line string) string {
+	tokens := strings.Fields(line)
+	for i, token := range tokens {
+		end := strings.LastIndexFunc(token, isLogWordRune) + 1
+		start := strings.IndexFunc(token, isLogWordRune)
+		if start < 0 {
+			continue
+		}
+		if word := token[start:end]; isVariableToken(word) {
+			tokens[i] = token[:start] + LogMask + token[end:]
+		}
+	}
+	return strings.Join(tokens, " ")
+}
+
+func isLogWordRune(r rune) bool {
+	return !unicode.IsPunct(r) || r == '_' || r == '-'
+}
+
+func isVariableToken(word string) bool {
+	hex := len(word) >= 8
+	for _, r := range word {
+		if unicode.IsDigit(r) {
+			return true
+		}
+		hex = hex && (r >= 'a' && r <= 'f' || r >= 'A' && r <= 'F' || r == '-')
+	}
+	return hex
+}
+
+// LogGroup is a group of near-identical log lines, with the masked first
+// line of the group as its template.
+type LogGroup struct {
+	ID       int
+	Template string
+	Count    int
+}
+
+// LogDeduplicator collapses near-identical log lines in real time: lines
+// are masked by MaskLogLine, sketched from their sliding token shingles
+// with short signatures, and assigned to the group of the first line seen
+// with an estimated similarity of at least the threshold, or to a new
+// group. Only the first line of each group is indexed, so memory grows
+// with the number of groups rather than of lines.
+type LogDeduplicator struct {
+	seed       int64
+	numHash    int
+	shingle    int
+	threshold  float64
+	detector   *Detector
+	signatures [][]uint64
+	groups     []LogGroup
+}
+
+// NewLogDeduplicator creates a log deduplicator with signatures of numHash
+// hash values, such as 32, from shingles of the given number of tokens.
+func NewLogDeduplicator(seed int64, numHash, shingle int, threshold float64) *LogDeduplicator {
+	return &LogDeduplicator{
+		seed:      seed,
+		numHash:   numHash,
+		shingle:   shingle,
+		threshold: threshold,
+		detector:  NewDetector(NewMinhashLSH16(numHash, threshold, 0)),
+	}
+}
+
+// signature sketches the shingles of the tokens of a masked line. Lines
+// with fewer tokens than a shingle are sketched as a single shingle.
+func (d *LogDeduplicator) signature(tokens []string) []uint64 {
+	mh := NewMinhash(d.seed, d.numHash)
+	if len(tokens) < d.shingle {
+		mh.Push([]byte(strings.Join(tokens, " ")))
+	}
+	for i := 0; i+d.shingle <= len(tokens); i++ {
+		mh.Push([]byte(strings.Join(tokens[i:i+d.shingle], " ")))
+	}
+	return mh.Signature()
+}
+
+// Observe assigns a log line to a group, returning the ID of the group and
+// whether the line started it.
+func (d *LogDeduplicator) Observe(line string) (group int, created bool) {
+	template := MaskLogLine(line)
+	sig := d.signature(strings.Fields(template))
+	hashKeys := d.detector.lsh.hashKeys(sig)
+	best, bestEqual := -1, 0
+	for _, key := range d.detector.candidates(hashKeys) {
+		id := key.(int)
+		equal, n := countEqual(sig, d.signatures[id], 1)
+		if float64(equal) >= d.threshold*float64(n) && equal > bestEqual {
+			best, bestEqual = id, equal
+		}
+	}
+	if best >= 0 {
+		d.groups[best].Count++
+		return best, false
+	}
+	id := len(d.groups)
+	d.groups = append(d.groups, LogGroup{id, template, 1})
+	d.signatures = append(d.signatures, sig)
+	d.detector.add(id, sig, hashKeys)
+	return id, true
+}
+
+// Dedup observes the lines of a stream, passing on the lines starting a
+// group and dropping the others. The output is closed once the input is.
+func (d *LogDeduplicator) Dedup(lines <-chan string) <-chan string {
+	out := make(chan string)
+	go func() {
+		defer close(out)
+		for line := range lines {
+			if _, created := d.Observe(line); created {
+				out <- line
+			}
+		}
+	}()
+	return out
+}
+
+// Groups returns the groups of the lines observed, by order of creation.
+func (d *LogDeduplicator) Groups() []LogGroup {
+	return append([]LogGroup(nil), d.groups...)
+}
@@ -0,0 +1,53 @@
+package minhashlsh
+
+import (
+	"fmt"
+	"testing"
+)
+
+func Test_MaskLogLine(t *testing.T) {
+	for line, expected := range map[string]string{
+		"GET /users/42 took 12ms":                 "GET /<*> took <*>",
+		"connection from 10.0.0.1:5432 closed":    "connection from <*> closed",
+		"commit deadbeefcafe pushed (3 files).":   "commit <*> pushed (<*> files).",
+		"worker   started":                        "worker started",
+		"request 7f9c2ba4-e88f-11d3 failed: busy": "request <*> failed: busy",
+	} {
+		if masked := MaskLogLine(line); masked != expected {
+			t.Errorf("%q: expected %q, got %q", line, expected, masked)
+		}
+	}
+}
+
+func Test_LogDeduplicator(t *testing.T) {
+	d := NewLogDeduplicator(1, 32, 2, 0.7)
+	lines := make(chan string)
+	go func() {
+		defer close(lines)
+		for i := 0; i < 1000; i++ {
+			switch i % 3 {
+			case 0:
+				lines <- fmt.Sprintf("user %d logged in from 10.0.%d.%d", i, i%256, i%7)
+			case 1:
+				lines <- fmt.Sprintf("cache miss for key session:%x after %dms", i*7919, i%100)
+			case 2:
+				lines <- fmt.Sprintf("[worker-%d] job %d finished with status ok", i%8, i)
+			}
+		}
+		lines <- "disk quota exceeded on volume data"
+	}()
+	var kept []string
+	for line := range d.Dedup(lines) {
+		kept = append(kept, line)
+	}
+	if len(kept) != 4 {
+		t.Fatalf("expected 4 distinct lines, got %q", kept)
+	}
+	groups := d.Groups()
+	if groups[0].Template != "user <*> logged in from <*>" || groups[0].Count != 334 {
+		t.Errorf("unexpected first group %+v", groups[0])
+	}
+	if groups[3].Count != 1 {
+		t.Errorf("expected a single line in the last group, got %d", groups[3].Count)
+	}
+}
@@ -1,12 +1,15 @@
 package minhashlsh
 
 import (
-	"compress/gzip"
+	"context"
 	"encoding/binary"
-	"encoding/gob"
+	"fmt"
 	"math"
-	"os"
+	"reflect"
+	"runtime"
 	"sort"
+	"sync"
+	"time"
 )
 
 const (
@@ -15,15 +18,37 @@ const (
 
 type hashKeyFunc func([]uint64) string
 
+// appendKeyFunc appends the hash key of a band to dst, computing hash keys
+// without allocating.
+type appendKeyFunc func(dst []byte, sig []uint64) []byte
+
+// hashKeyFuncGen returns the function computing the hash keys of bands,
+// 128-bit hash values being two consecutive signature values.
 func hashKeyFuncGen(hashValueSize int) hashKeyFunc {
-	return func(sig []uint64) string {
-		s := make([]byte, hashValueSize*len(sig))
-		buf := make([]byte, 8)
-		for i, v := range sig {
-			binary.LittleEndian.PutUint64(buf, v)
-			copy(s[i*hashValueSize:(i+1)*hashValueSize], buf[:hashValueSize])
+	return stringKeyFunc(appendKeyFuncGen(hashValueSize))
+}
+
+// appendKeyFuncGen is hashKeyFuncGen appending to a buffer.
+func appendKeyFuncGen(hashValueSize int) appendKeyFunc {
+	hashValueSize = wordSize(hashValueSize)
+	return func(dst []byte, sig []uint64) []byte {
+		var buf [8]byte
+		for _, v := range sig {
+			binary.LittleEndian.PutUint64(buf[:], v)
+			dst = append(dst, buf[:hashValueSize]...)
 		}
-		return string(s)
+		return dst
+	}
+}
+
+// stringKeyFunc returns the hash key function of an appendKeyFunc,
+// building the hash keys in pooled buffers.
+func stringKeyFunc(appendKey appendKeyFunc) hashKeyFunc {
+	return func(sig []uint64) string {
+		b := acquireKeyBuffer(0)
+		defer releaseKeyBuffer(b)
+		*b = appendKey(*b, sig)
+		return string(*b)
 	}
 }
 
@@ -112,88 +137,141 @@ type MinhashLSH struct {
 	HashKeyFunc    hashKeyFunc
 	HashValueSize  int
 	NumIndexedKeys int
-}
 
-// Save MinHash LSH index
-func (minhashLsh *MinhashLSH) Save(filename string) error {
-	fi, err := os.Create(filename)
-	if err != nil {
-		return err
-	}
-	defer fi.Close()
-
-	fz := gzip.NewWriter(fi)
-	defer fz.Close()
-
-	encoder := gob.NewEncoder(fz)
-	err = encoder.Encode(*minhashLsh)
-	if err != nil {
-		return err
-	}
-
-	return nil
+	// appendKey computes the hash keys of HashKeyFunc into a buffer;
+	// queries use it when set, to avoid allocating hash keys.
+	appendKey appendKeyFunc
+	lazy      *lazyTables
+	growth    GrowthPolicy
+	tracer    Tracer
+	// removed holds the keys removed since the last compaction.
+	removed map[interface{}]struct{}
+	// stale maps removed keys added again to the length of the hash tables
+	// when they were added, before which their entries are dropped by the
+	// next Index or Compact.
+	stale map[interface{}]int
+	// positions maps keys to their entries, see WithKeyPositions.
+	positions         map[interface{}][]position
+	numRemovedEntries int
+
+	hashKeyEncoding HashKeyEncoding
+	minhashSeed     *int64
+	signatures      map[interface{}][]uint64
+	// bands maps the hash tables of a partially loaded index to the bands
+	// of signatures, see WithBands.
+	bands         []int
+	cardinalities map[interface{}]float64
+	// latestVersions maps logical keys to their latest version,
+	// nil when they need to be found again, which Index does if versions
+	// were added.
+	latestVersions map[interface{}]int64
+	versioned      bool
+	softDeleted    map[interface{}]struct{}
+	bucketCap      *bucketCap
+	salt           uint64
+	trim           TrimPolicy
+	dedupe         bool
+	limits         *indexLimits
+	// indexCost is the duration per entry of the last Index.
+	indexCost time.Duration
+	cache     *queryCache
+	negCache  *negativeCache
+	changes   *changeFeed
+	hooks     []Hooks
+	verifier  Verifier
+
+	candidateCounts *candidateCounts
+
+	// numHash is the number of hash values the index was created for,
+	// and optionErr the first invalid option, both only used by New.
+	numHash   int
+	optionErr error
 }
 
-// Load MinHash LSH index
-func Load(filename string) (*MinhashLSH, error) {
-
-	fi, err := os.Open(filename)
-	if err != nil {
-		return nil, err
-	}
-	defer fi.Close()
+// Option configures a MinhashLSH when it is created.
+type Option func(*MinhashLSH)
 
-	fz, err := gzip.NewReader(fi)
-	if err != nil {
-		return nil, err
-	}
-	defer fz.Close()
-
-	decoder := gob.NewDecoder(fz)
-	lshIndex := new(MinhashLSH)
-	err = decoder.Decode(lshIndex)
-	if err != nil {
-		return nil, err
-	}
-
-	lshIndex.HashKeyFunc = hashKeyFuncGen(lshIndex.HashValueSize)
-
-	return lshIndex, nil
-}
-
-func newMinhashLSH(threshold float64, numHash, hashValueSize, initSize int) *MinhashLSH {
+func newMinhashLSH(threshold float64, numHash, hashValueSize, initSize int, opts []Option) *MinhashLSH {
 	k, l, _, _ := optimalKL(numHash, threshold)
+	if initSize <= 0 {
+		initSize = defaultInitSize
+	}
 	hashTables := make([]hashTable, l)
 	for i := range hashTables {
 		hashTables[i] = make(hashTable, 0, initSize)
 	}
-	return &MinhashLSH{
+	f := &MinhashLSH{
 		K:              k,
 		L:              l,
 		HashValueSize:  hashValueSize,
 		HashTables:     hashTables,
 		HashKeyFunc:    hashKeyFuncGen(hashValueSize),
+		appendKey:      appendKeyFuncGen(hashValueSize),
 		NumIndexedKeys: 0,
 	}
+	f.numHash = numHash
+	for _, opt := range opts {
+		opt(f)
+	}
+	return f
+}
+
+// New creates an index for signatures of numHash hash values of
+// hashValueSize bytes, 2, 4, 8 or 16, as the constructors of each size do,
+// returning an error for invalid parameters or options, such as WithParams
+// with K*L larger than numHash.
+// The other constructors panic with the error instead.
+func New(numHash int, threshold float64, hashValueSize, initSize int, opts ...Option) (*MinhashLSH, error) {
+	switch hashValueSize {
+	case 2, 4, 8, 16:
+	default:
+		return nil, fmt.Errorf("minhashlsh: invalid hash value size %d", hashValueSize)
+	}
+	if numHash <= 0 {
+		return nil, fmt.Errorf("minhashlsh: invalid number of hash values %d", numHash)
+	}
+	f := newMinhashLSH(threshold, numHash, hashValueSize, initSize, opts)
+	if f.optionErr != nil {
+		return nil, f.optionErr
+	}
+	return f, nil
+}
+
+// mustNew is New for the constructors of each hash value size.
+func mustNew(threshold float64, numHash, hashValueSize, initSize int, opts []Option) *MinhashLSH {
+	f := newMinhashLSH(threshold, numHash, hashValueSize, initSize, opts)
+	if f.optionErr != nil {
+		panic(f.optionErr)
+	}
+	return f
 }
 
 // NewMinhashLSH64 uses 64-bit hash values and pre-allocation of hash tables.
-func NewMinhashLSH64(numHash int, threshold float64, initSize int) *MinhashLSH {
-	return newMinhashLSH(threshold, numHash, 8, initSize)
+// If initSize is not positive, the hash tables start small and
+// grow according to the growth policy.
+func NewMinhashLSH64(numHash int, threshold float64, initSize int, opts ...Option) *MinhashLSH {
+	return mustNew(threshold, numHash, 8, initSize, opts)
 }
 
 // NewMinhashLSH32 uses 32-bit hash values and pre-allocation of hash tables.
 // MinHash signatures with 64 bit hash values will have
-// their hash values trimed.
-func NewMinhashLSH32(numHash int, threshold float64, initSize int) *MinhashLSH {
-	return newMinhashLSH(threshold, numHash, 4, initSize)
+// their hash values trimed, see WithTrimPolicy.
+func NewMinhashLSH32(numHash int, threshold float64, initSize int, opts ...Option) *MinhashLSH {
+	return mustNew(threshold, numHash, 4, initSize, opts)
 }
 
 // NewMinhashLSH16 uses 16-bit hash values and pre-allocation of hash tables.
 // MinHash signatures with 64 or 32 bit hash values will have
-// their hash values trimed.
-func NewMinhashLSH16(numHash int, threshold float64, initSize int) *MinhashLSH {
-	return newMinhashLSH(threshold, numHash, 2, initSize)
+// their hash values trimed, see WithTrimPolicy.
+func NewMinhashLSH16(numHash int, threshold float64, initSize int, opts ...Option) *MinhashLSH {
+	return mustNew(threshold, numHash, 2, initSize, opts)
+}
+
+// NewMinhashLSH128 uses 128-bit hash values, for a negligible probability of
+// collisions of band hash keys across billions of keys. Signatures are
+// computed by Minhash128, each hash value being two consecutive uint64.
+func NewMinhashLSH128(numHash int, threshold float64, initSize int, opts ...Option) *MinhashLSH {
+	return mustNew(threshold, numHash, 16, initSize, opts)
 }
 
 // NewMinhashLSH is the default constructor uses 32 bit hash value
@@ -205,63 +283,376 @@ func (f *MinhashLSH) Params() (k, l int) {
 	return f.K, f.L
 }
 
+// SignatureSize returns the number of values of signatures used by
+// the index, longer signatures are truncated to this size.
+// 128-bit hash values count as two values.
+func (f *MinhashLSH) SignatureSize() int {
+	if f.bands != nil {
+		return f.bandSize() * (f.bands[len(f.bands)-1] + 1)
+	}
+	return f.bandSize() * f.L
+}
+
+// wordSize returns the number of bytes of hash values kept from each
+// signature value.
+func wordSize(hashValueSize int) int {
+	if hashValueSize > 8 {
+		return 8
+	}
+	return hashValueSize
+}
+
+// bandSize returns the number of signature values of a band.
+func (f *MinhashLSH) bandSize() int {
+	return f.K * f.HashValueSize / wordSize(f.HashValueSize)
+}
+
 func (f *MinhashLSH) hashKeys(sig []uint64) []string {
-	hs := make([]string, f.L)
-	for i := 0; i < f.L; i++ {
-		hs[i] = f.HashKeyFunc(sig[i*f.K : (i+1)*f.K])
+	return f.bandsHashKeys(sig, f.L)
+}
+
+// bandsHashKeys returns the hash keys of the first m bands.
+func (f *MinhashLSH) bandsHashKeys(sig []uint64, m int) []string {
+	hs := make([]string, m)
+	for i := 0; i < m; i++ {
+		hs[i] = f.HashKeyFunc(f.band(sig, i))
 	}
 	return hs
 }
 
+// band returns the values of a signature hashed into the hash table i.
+func (f *MinhashLSH) band(sig []uint64, i int) []uint64 {
+	if f.bands != nil {
+		i = f.bands[i]
+	}
+	return band(sig, i, f.bandSize())
+}
+
+// band returns the values of band i of a signature.
+func band(sig []uint64, i, size int) []uint64 {
+	return sig[i*size : (i+1)*size]
+}
+
 // Add a Key with MinHash signature into the index.
 // The Key won't be searchable until Index() is called.
+// Add panics with ErrIndexFull if the index is at its limits, see TryAdd.
 func (f *MinhashLSH) Add(key interface{}, sig []uint64) {
+	if f.tracer != nil {
+		defer startSpan(f.tracer, context.Background(), "minhashlsh.Add").End()
+	}
+	if f.hooks != nil {
+		defer f.onAdd(key, time.Now())
+	}
+	f.materialize()
+	if err := f.checkLimits(); err != nil {
+		panic(err)
+	}
+	f.unremove(key)
+	f.storeSignature(key, sig)
 	// Generate hash keys
 	hs := f.hashKeys(sig)
 	// Insert keys into the hash tables by appending.
 	for i := range f.HashTables {
+		if len(f.HashTables[i]) == cap(f.HashTables[i]) {
+			f.HashTables[i] = f.grow(f.HashTables[i], 1)
+		}
 		f.HashTables[i] = append(f.HashTables[i], entry{hs[i], key})
+		if f.positions != nil {
+			f.positions[key] = append(f.positions[key], position{i, len(f.HashTables[i]) - 1})
+		}
 	}
+	f.publish(ChangeAdd, key, sig)
 }
 
 // Index makes all the keys added searchable.
+// The hash tables are sorted concurrently using up to GOMAXPROCS workers.
 func (f *MinhashLSH) Index() {
-	for i := range f.HashTables {
-		sort.Sort(f.HashTables[i])
+	f.IndexContext(context.Background())
+}
+
+// IndexContext is Index with a context for tracing.
+func (f *MinhashLSH) IndexContext(ctx context.Context) {
+	span := startSpan(f.tracer, ctx, "minhashlsh.Index")
+	defer span.End()
+	f.materialize()
+	start := time.Now()
+	added := len(f.HashTables[0]) - f.NumIndexedKeys
+	f.dropStale()
+	f.forEachBand(func(i int) {
+		sortHashTable(f.HashTables[i])
+	})
+	f.indexed(span, start, added)
+}
+
+// indexed completes Index once the hash tables are sorted, given the time
+// it started and the number of entries per band added before.
+func (f *MinhashLSH) indexed(span Span, start time.Time, added int) {
+	if f.dedupe {
+		f.collapseDuplicates()
 	}
+	f.recordIndexCost(len(f.HashTables[0]), time.Since(start))
+	f.invalidateCache()
 	f.NumIndexedKeys = len(f.HashTables[0])
+	f.indexPositions()
+	if f.versioned && f.latestVersions == nil {
+		f.latestVersions = f.findLatest()
+	}
+	f.publish(ChangeIndex, nil, nil)
+	if f.hooks != nil {
+		f.onIndex(added, start)
+	}
+	span.SetAttribute(attrKeys, int64(f.NumIndexedKeys))
+}
+
+// forEachBand calls fn for every band concurrently,
+// using up to GOMAXPROCS workers.
+func (f *MinhashLSH) forEachBand(fn func(i int)) {
+	numWorkers := runtime.GOMAXPROCS(0)
+	if numWorkers > len(f.HashTables) {
+		numWorkers = len(f.HashTables)
+	}
+	bands := make(chan int)
+	var wg sync.WaitGroup
+	wg.Add(numWorkers)
+	for w := 0; w < numWorkers; w++ {
+		go func() {
+			defer wg.Done()
+			for i := range bands {
+				fn(i)
+			}
+		}()
+	}
+	for i := range f.HashTables {
+		bands <- i
+	}
+	close(bands)
+	wg.Wait()
 }
 
-// Query returns candidate keys given the query signature.
+// Query returns candidate keys given the query signature, kept by the
+// verifier of the index if any, see WithVerifier.
 func (f *MinhashLSH) Query(sig []uint64) []interface{} {
-	set := f.query(sig)
-	results := make([]interface{}, 0, len(set))
-	for key := range set {
-		results = append(results, key)
+	return f.QueryContext(context.Background(), sig)
+}
+
+// QueryContext is Query with a context for tracing.
+func (f *MinhashLSH) QueryContext(ctx context.Context, sig []uint64) []interface{} {
+	return f.queryContext(ctx, sig, f.verifier)
+}
+
+func (f *MinhashLSH) queryContext(ctx context.Context, sig []uint64, v Verifier) []interface{} {
+	span := startSpan(f.tracer, ctx, "minhashlsh.Query")
+	var start time.Time
+	if f.hooks != nil {
+		start = time.Now()
+	}
+	var results []interface{}
+	if f.cache != nil {
+		hashKeys := f.hashKeys(sig)
+		var cached bool
+		var version uint64
+		if results, version, cached = f.cache.get(cacheKey(hashKeys)); !cached {
+			results = f.queryHashKeys(hashKeys).keys
+			f.cache.put(cacheKey(hashKeys), results, version)
+		}
+	} else {
+		results = f.query(sig).keys
+	}
+	if v != nil {
+		results = verify(v, sig, results)
+	}
+	span.SetAttribute(attrBandsProbed, int64(f.L))
+	span.SetAttribute(attrCandidates, int64(len(results)))
+	span.End()
+	if f.hooks != nil {
+		f.onQuery(len(results), start)
 	}
 	return results
 }
 
-func (f *MinhashLSH) query(sig []uint64) map[interface{}]bool {
-	// Generate hash keys.
-	hashKeys := f.hashKeys(sig)
-	results := make(map[interface{}]bool)
-	// Query hash tables using binary search.
+// QueryBands returns candidate keys given the query signature, probing only
+// the first m bands. It is faster than Query for m < L, at the cost of
+// a lower recall; m larger than L probes all bands.
+func (f *MinhashLSH) QueryBands(sig []uint64, m int) []interface{} {
+	if m > f.L {
+		m = f.L
+	}
+	keys := f.queryHashKeys(f.bandsHashKeys(sig, m)).keys
+	if f.verifier != nil {
+		keys = verify(f.verifier, sig, keys)
+	}
+	return keys
+}
+
+// QueryUpTo returns at most k candidate keys given the query signature,
+// stopping as soon as k distinct candidates are found. Bands are probed
+// in order, so the candidates returned are biased towards the first bands.
+func (f *MinhashLSH) QueryUpTo(sig []uint64, k int) []interface{} {
+	results := make([]interface{}, 0, minInt(k, 64))
+	if k <= 0 {
+		return results
+	}
+	seen := make(map[interface{}]bool)
 	for i := 0; i < f.L; i++ {
-		// Only search over the indexed keys.
-		hashTable := f.HashTables[i][:f.NumIndexedKeys]
-		hashKey := hashKeys[i]
-		k := sort.Search(len(hashTable), func(x int) bool {
-			return hashTable[x].HashKey >= hashKey
-		})
-		if k < len(hashTable) && hashTable[k].HashKey == hashKey {
-			for j := k; j < len(hashTable) && hashTable[j].HashKey == hashKey; j++ {
-				key := hashTable[j].Key
-				if _, exist := results[key]; !exist {
-					results[key] = true
-				}
+		for _, e := range f.bucket(i, f.HashKeyFunc(f.band(sig, i))) {
+			if seen[e.Key] || f.isHidden(e.Key) {
+				continue
+			}
+			seen[e.Key] = true
+			if !f.verified(sig, e.Key) {
+				continue
+			}
+			results = append(results, e.Key)
+			if len(results) == k {
+				return results
 			}
 		}
 	}
 	return results
 }
+
+// Contains reports whether any key collides with the query signature in
+// at least one band, that is whether anything similar above the threshold
+// is likely indexed. It stops at the first collision without collecting
+// candidates, for checks such as deduplication on ingest.
+func (f *MinhashLSH) Contains(sig []uint64) bool {
+	if f.negCache != nil && f.appendKey != nil {
+		return f.containsNegativeCache(sig)
+	}
+	for i := 0; i < f.L; i++ {
+		for _, e := range f.bucket(i, f.HashKeyFunc(f.band(sig, i))) {
+			if !f.isHidden(e.Key) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// PreparedQuery holds the band hash keys of a query signature,
+// so they are computed only once for queries repeated many times.
+type PreparedQuery struct {
+	sig      []uint64
+	hashKeys []string
+	// The parameters of the index that prepared the query.
+	k, l, hashValueSize int
+	salt                uint64
+	trim                TrimPolicy
+	bands               []int
+}
+
+// Prepare computes the band hash keys of a query signature. They are the
+// hash keys of the index, salted and trimmed as set by WithSalt and
+// WithTrimPolicy, so the prepared query can only be used with the index
+// that prepared it, or indexes with the same K, L, hash value size, salt,
+// trim policy and loaded bands.
+func (f *MinhashLSH) Prepare(sig []uint64) PreparedQuery {
+	return PreparedQuery{append([]uint64(nil), sig...), f.hashKeys(sig), f.K, f.L, f.HashValueSize, f.salt, f.trim, f.bands}
+}
+
+// QueryPrepared returns candidate keys given a prepared query.
+// It panics with ErrIncompatibleIndex if the query was prepared by an index
+// with other hash keys, see Prepare.
+func (f *MinhashLSH) QueryPrepared(q PreparedQuery) []interface{} {
+	if q.k != f.K || q.l != f.L || q.hashValueSize != f.HashValueSize ||
+		q.salt != f.salt || q.trim != f.trim || !reflect.DeepEqual(q.bands, f.bands) {
+		panic(ErrIncompatibleIndex)
+	}
+	keys := f.queryHashKeys(q.hashKeys).keys
+	if f.verifier != nil {
+		keys = verify(f.verifier, q.sig, keys)
+	}
+	return keys
+}
+
+func (f *MinhashLSH) query(sig []uint64) *candidateSet {
+	if f.appendKey == nil {
+		// Generate hash keys.
+		return f.queryHashKeys(f.hashKeys(sig))
+	}
+	// Build each hash key in a pooled buffer instead.
+	results := &candidateSet{keys: make([]interface{}, 0)}
+	b := acquireKeyBuffer(0)
+	defer releaseKeyBuffer(b)
+	if f.negCache != nil {
+		var width int
+		*b, width = f.negativeHashKeys((*b)[:0], sig)
+		if f.negCache.has(*b) {
+			return results
+		}
+		for i := 0; i < f.L; i++ {
+			f.collect(results, f.bucketBytes(i, (*b)[i*width:(i+1)*width]))
+		}
+		if results.len() == 0 {
+			f.negCache.put(*b)
+		}
+		f.countCandidates(results)
+		return results
+	}
+	for i := 0; i < f.L; i++ {
+		*b = f.appendKey((*b)[:0], f.band(sig, i))
+		f.collect(results, f.bucketBytes(i, *b))
+	}
+	f.countCandidates(results)
+	return results
+}
+
+// queryHashKeys queries the bands of the given hash keys.
+func (f *MinhashLSH) queryHashKeys(hashKeys []string) *candidateSet {
+	results := &candidateSet{keys: make([]interface{}, 0)}
+	// Query hash tables using binary search.
+	for i := range hashKeys {
+		f.collect(results, f.bucket(i, hashKeys[i]))
+	}
+	f.countCandidates(results)
+	return results
+}
+
+// collect adds the visible keys of a bucket to the results.
+func (f *MinhashLSH) collect(results *candidateSet, bucket hashTable) {
+	step := f.bucketCap.step(len(bucket))
+	for j := 0; j < len(bucket); j += step {
+		e := bucket[j]
+		if !f.isHidden(e.Key) {
+			results.add(e.Key)
+		}
+	}
+}
+
+func (f *MinhashLSH) countCandidates(results *candidateSet) {
+	if f.candidateCounts != nil {
+		for _, key := range results.keys {
+			f.candidateCounts.add(key)
+		}
+	}
+}
+
+// bucket returns the indexed entries of band i having the given hash key.
+func (f *MinhashLSH) bucket(i int, hashKey string) hashTable {
+	// Only search over the indexed keys.
+	hashTable := f.table(i)[:f.NumIndexedKeys]
+	k := sort.Search(len(hashTable), func(x int) bool {
+		return hashTable[x].HashKey >= hashKey
+	})
+	// Oversized buckets are bounded by a second search, not by stepping
+	// through their entries.
+	rest := hashTable[k:]
+	j := sort.Search(len(rest), func(x int) bool {
+		return rest[x].HashKey > hashKey
+	})
+	return rest[:j]
+}
+
+// bucketBytes is bucket for a hash key in a buffer. Converting the buffer
+// in comparisons does not allocate.
+func (f *MinhashLSH) bucketBytes(i int, hashKey []byte) hashTable {
+	hashTable := f.table(i)[:f.NumIndexedKeys]
+	k := sort.Search(len(hashTable), func(x int) bool {
+		return hashTable[x].HashKey >= string(hashKey)
+	})
+	rest := hashTable[k:]
+	j := sort.Search(len(rest), func(x int) bool {
+		return rest[x].HashKey > string(hashKey)
+	})
+	return rest[:j]
+}
@@ -17,3 +17,16 @@ func Benchmark_Insert10000(b *testing.B) {
 	}
 	f.Index()
 }
+
+func Benchmark_QueryCandidates(b *testing.B) {
+	f := NewMinhashLSH16(64, 0.5, 10000)
+	sig := randomSignature(64, 1)
+	for i := 0; i < 10000; i++ {
+		f.Add(strconv.Itoa(i), sig)
+	}
+	f.Index()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		f.Query(sig)
+	}
+}
@@ -2,6 +2,7 @@ package minhashlsh
 
 import (
 	"math/rand"
+	"reflect"
 	"testing"
 )
 
@@ -98,3 +99,80 @@ func Test_MinhashLSH2(t *testing.T) {
 		t.Fail()
 	}
 }
+
+func Test_QueryPrepared(t *testing.T) {
+	f := testIndex(50)
+	for i := 0; i < 50; i++ {
+		sig := randomSignature(64, int64(i))
+		q := f.Prepare(sig)
+		if !reflect.DeepEqual(f.query(sig), f.queryHashKeys(q.hashKeys)) {
+			t.Errorf("prepared query %d differs", i)
+		}
+		if len(f.QueryPrepared(q)) != len(f.Query(sig)) {
+			t.Errorf("prepared query %d returned a different number of keys", i)
+		}
+	}
+}
+
+func Test_QueryPreparedIncompatible(t *testing.T) {
+	f := testIndex(10)
+	salted := NewMinhashLSH16(64, 0.5, 0, WithSalt(1))
+	q := salted.Prepare(randomSignature(64, 1))
+	defer func() {
+		if r := recover(); r != ErrIncompatibleIndex {
+			t.Errorf("expected a panic with ErrIncompatibleIndex, got %v", r)
+		}
+	}()
+	f.QueryPrepared(q)
+}
+
+func Test_QueryBands(t *testing.T) {
+	f := testIndex(50)
+	sig := randomSignature(64, 7)
+	if len(f.QueryBands(sig, f.L+1)) != len(f.Query(sig)) {
+		t.Error("probing all bands differs from Query")
+	}
+	if len(f.QueryBands(sig, 0)) != 0 {
+		t.Error("probing no bands returned keys")
+	}
+	found := false
+	for _, key := range f.QueryBands(sig, 1) {
+		found = found || key == "7"
+	}
+	if !found {
+		t.Error("key not found probing the first band")
+	}
+}
+
+func Test_QueryUpTo(t *testing.T) {
+	f := NewMinhashLSH16(64, 0.5, 10)
+	sig := randomSignature(64, 1)
+	for i := 0; i < 10; i++ {
+		f.Add(i, sig)
+	}
+	f.Index()
+	for _, k := range []int{0, 1, 5, 10, 20} {
+		results := f.QueryUpTo(sig, k)
+		expected := k
+		if expected > 10 {
+			expected = 10
+		}
+		if len(results) != expected {
+			t.Errorf("QueryUpTo(%d) returned %d keys", k, len(results))
+		}
+	}
+}
+
+func Test_Contains(t *testing.T) {
+	f := testIndex(10)
+	if !f.Contains(randomSignature(64, 3)) {
+		t.Error("indexed signature not contained")
+	}
+	f.Remove("3")
+	if f.Contains(randomSignature(64, 3)) {
+		t.Error("removed key reported as contained")
+	}
+	if !f.Contains(randomSignature(64, 4)) {
+		t.Error("indexed signature not contained")
+	}
+}
@@ -0,0 +1,60 @@
+package minhashlsh
+
+// Merge adds the indexed keys of other to the index and indexes them, such
+// as to combine indexes built in parallel over parts of a corpus. The
+// indexes must have the same parameters, salt and trim policy, so their
+// hash keys are comparable. Removed and soft-deleted keys of other are
+// left out, and the stored signatures of other are copied if both indexes
+// store signatures. Keys in both indexes keep the entries of both, so
+// either signature finds them, and keys removed from the index are added
+// again, as by Add. ErrIndexFull is returned, and nothing is
+// merged, if the keys would exceed the limits of the index.
+func (f *MinhashLSH) Merge(other *MinhashLSH) error {
+	if f.K != other.K || f.L != other.L || f.HashValueSize != other.HashValueSize ||
+		f.salt != other.salt || f.trim != other.trim || f.bands != nil || other.bands != nil {
+		return ErrIncompatibleIndex
+	}
+	if err := f.Materialize(); err != nil {
+		return err
+	}
+	if err := other.Materialize(); err != nil {
+		return err
+	}
+	added := 0
+	for _, e := range other.HashTables[0][:other.NumIndexedKeys] {
+		if !other.isHidden(e.Key) {
+			added++
+		}
+	}
+	if err := f.checkLimitsFor(added); err != nil {
+		return err
+	}
+	// Keys removed from the index are added again, their previous
+	// entries are dropped by Index.
+	for _, e := range other.HashTables[0][:other.NumIndexedKeys] {
+		if !other.isHidden(e.Key) {
+			f.unremove(e.Key)
+		}
+	}
+	for i := range f.HashTables {
+		for _, e := range other.HashTables[i][:other.NumIndexedKeys] {
+			if !other.isHidden(e.Key) {
+				f.HashTables[i] = append(f.HashTables[i], e)
+			}
+		}
+	}
+	for _, e := range other.HashTables[0][:other.NumIndexedKeys] {
+		if !other.isHidden(e.Key) {
+			f.publish(ChangeAdd, e.Key, other.signatures[e.Key])
+		}
+	}
+	if f.signatures != nil {
+		for key, sig := range other.signatures {
+			if !other.isHidden(key) {
+				f.signatures[key] = sig
+			}
+		}
+	}
+	f.Index()
+	return nil
+}
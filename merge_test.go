@@ -0,0 +1,69 @@
+package minhashlsh
+
+import (
+	"strconv"
+	"testing"
+)
+
+func Test_Merge(t *testing.T) {
+	f := NewMinhashLSH16(64, 0.5, 0, WithSignatureStorage())
+	g := NewMinhashLSH16(64, 0.5, 0, WithSignatureStorage())
+	for i := 0; i < 100; i++ {
+		if i < 50 {
+			f.Add(strconv.Itoa(i), randomSignature(64, int64(i)))
+		} else {
+			g.Add(strconv.Itoa(i), randomSignature(64, int64(i)))
+		}
+	}
+	f.Index()
+	g.Index()
+	g.Remove("60")
+	g.SoftDelete("70")
+	if err := f.Merge(g); err != nil {
+		t.Fatal(err)
+	}
+	if f.NumIndexedKeys != 98 {
+		t.Errorf("expected 98 indexed keys, got %d", f.NumIndexedKeys)
+	}
+	for i := 0; i < 100; i++ {
+		found := contains(f.Query(randomSignature(64, int64(i))), strconv.Itoa(i))
+		if found != (i != 60 && i != 70) {
+			t.Errorf("key %d: found %v", i, found)
+		}
+	}
+	if _, exist := f.Signature("99"); !exist {
+		t.Error("signature of a merged key not copied")
+	}
+
+	if err := f.Merge(NewMinhashLSH32(64, 0.5, 0)); err != ErrIncompatibleIndex {
+		t.Errorf("expected ErrIncompatibleIndex, got %v", err)
+	}
+	if err := f.Merge(NewMinhashLSH16(64, 0.5, 0, WithSalt(1))); err != ErrIncompatibleIndex {
+		t.Errorf("expected ErrIncompatibleIndex for a salted index, got %v", err)
+	}
+	full := NewMinhashLSH16(64, 0.5, 0, WithMaxEntries(120))
+	full.Merge(f)
+	if err := full.Merge(g); err != ErrIndexFull || full.NumIndexedKeys != 98 {
+		t.Errorf("expected ErrIndexFull with 98 keys, got %v with %d", err, full.NumIndexedKeys)
+	}
+}
+
+func Test_MergeRemoved(t *testing.T) {
+	a := NewMinhashLSH16(64, 0.5, 0)
+	b := NewMinhashLSH16(64, 0.5, 0)
+	sig := randomSignature(64, 1)
+	a.Add("k", sig)
+	a.Index()
+	a.Remove("k")
+	b.Add("k", sig)
+	b.Index()
+	if err := a.Merge(b); err != nil {
+		t.Fatal(err)
+	}
+	if !contains(a.Query(sig), "k") {
+		t.Error("key removed from the index not found after merging it again")
+	}
+	if a.NumIndexedKeys != 1 {
+		t.Errorf("expected 1 indexed key, got %d", a.NumIndexedKeys)
+	}
+}
@@ -3,6 +3,7 @@ package minhashlsh
 import (
 	"encoding/binary"
 	"hash/fnv"
+	"math"
 	"math/rand"
 
 	minwise "github.com/dgryski/go-minhash"
@@ -15,11 +16,45 @@ const hashValueSize = 8
 type Minhash struct {
 	mw   *minwise.MinWise
 	seed int64
+	// h1 and h2 are the hash functions of mw.
+	h1, h2 func([]byte) uint64
+	// family and mins replace mw for other hash families than the default.
+	family hashFamily
+	mins   []uint64
+}
+
+// MinhashOption configures a Minhash.
+type MinhashOption func(*minhashConfig)
+
+type minhashConfig struct {
+	family func(seed int64, numHash int) hashFamily
+}
+
+// hashFamily computes the hash values of the values pushed to a Minhash.
+type hashFamily interface {
+	// push lowers the minimums with the hash values of b.
+	push(b []byte, mins []uint64)
+	// equal returns true if o computes the same hash values.
+	equal(o hashFamily) bool
 }
 
 // NewMinhash initialize a MinHash object with a seed and the number of
 // hash functions.
-func NewMinhash(seed int64, numHash int) *Minhash {
+//
+// The signatures only depend on the seed: the first two values of
+// rand.NewSource(seed).Int63, written as big-endian uint64, prefix each
+// value pushed to give two FNV-1a 64-bit hashes h1 and h2, and the i-th hash
+// value is the minimum of h1 + i*h2 modulo 2^64 over the values pushed.
+// This derivation is fixed, see GoldenVectors. Options select other
+// families of hash functions.
+func NewMinhash(seed int64, numHash int, opts ...MinhashOption) *Minhash {
+	var c minhashConfig
+	for _, opt := range opts {
+		opt(&c)
+	}
+	if c.family != nil {
+		return newMinhashFamily(seed, c.family(seed, numHash), numHash)
+	}
 	r := rand.New(rand.NewSource(seed))
 	b := binary.BigEndian
 	b1 := make([]byte, hashValueSize)
@@ -43,20 +78,38 @@ func NewMinhash(seed int64, numHash int) *Minhash {
 	return &Minhash{
 		mw:   minwise.NewMinWise(h1, h2, numHash),
 		seed: int64(seed),
+		h1:   h1,
+		h2:   h2,
 	}
 }
 
 // Push a new value to the MinHash object.
 // The value should be serialized to byte slice.
 func (m *Minhash) Push(b []byte) {
+	if m.family != nil {
+		m.family.push(b, m.mins)
+		return
+	}
 	m.mw.Push(b)
 }
 
 // Signature exports the MinHash as a list of hash values.
 func (m *Minhash) Signature() []uint64 {
+	if m.family != nil {
+		return append([]uint64(nil), m.mins...)
+	}
 	return m.mw.Signature()
 }
 
+// SignatureTo appends the hash values of the MinHash to dst[:0], so that
+// signature slices can be reused, see AcquireSignature.
+func (m *Minhash) SignatureTo(dst []uint64) []uint64 {
+	if m.family != nil {
+		return append(dst[:0], m.mins...)
+	}
+	return append(dst[:0], m.mw.Signature()...)
+}
+
 // Merge combines the signature of the other Minhash
 // with this one, making this one carry the signature of
 // the union.
@@ -64,5 +117,50 @@ func (m *Minhash) Merge(o *Minhash) {
 	if m.seed != o.seed {
 		panic("Cannot merge Minhash with different seed")
 	}
+	if m.family != nil || o.family != nil {
+		if m.family == nil || o.family == nil || !m.family.equal(o.family) || len(m.mins) != len(o.mins) {
+			panic("Cannot merge Minhash with different hash functions")
+		}
+		for i, v := range o.mins {
+			if v < m.mins[i] {
+				m.mins[i] = v
+			}
+		}
+		return
+	}
 	m.mw.Merge(o.mw)
 }
+
+// Reset empties the Minhash to sketch another set, keeping its hash
+// functions, which is cheaper than creating a new Minhash.
+// Signatures returned before are not modified.
+func (m *Minhash) Reset() {
+	if m.family == nil {
+		m.mw = minwise.NewMinWise(m.h1, m.h2, len(m.mw.Signature()))
+		return
+	}
+	for i := range m.mins {
+		m.mins[i] = math.MaxUint64
+	}
+}
+
+func newMinhashFamily(seed int64, family hashFamily, numHash int) *Minhash {
+	m := &Minhash{seed: seed, family: family, mins: make([]uint64, numHash)}
+	for i := range m.mins {
+		m.mins[i] = math.MaxUint64
+	}
+	return m
+}
+
+// TruncateSignature returns the first numHash hash values of a signature.
+// The i-th hash value of a signature only depends on the seed and i,
+// so the result is identical to the signature of a Minhash created
+// with the same seed and numHash hash functions. One signature can thus feed
+// indexes requiring different signature sizes.
+// The returned signature shares the hash values of sig.
+func TruncateSignature(sig []uint64, numHash int) []uint64 {
+	if numHash > len(sig) {
+		panic("Cannot truncate signature to a larger size")
+	}
+	return sig[:numHash:numHash]
+}
@@ -0,0 +1,102 @@
+package minhashlsh
+
+import (
+	"encoding/binary"
+	"math"
+	"math/rand"
+)
+
+// Minhash128 is a MinHash with 128-bit hash values, for indexes created by
+// NewMinhashLSH128. Each hash value is computed from two pairs of 64-bit
+// hash functions by double hashing, and compared as a (high, low) pair.
+type Minhash128 struct {
+	// states holds the FNV-1a state of each hash function after hashing
+	// its seed, from which every value is hashed without a hasher.
+	states [4]uint64
+	// mins holds the high and low halves of each hash value.
+	mins []uint64
+	seed int64
+}
+
+// NewMinhash128 initializes a 128-bit MinHash with a seed and the number
+// of hash functions.
+func NewMinhash128(seed int64, numHash int) *Minhash128 {
+	r := rand.New(rand.NewSource(seed))
+	m := &Minhash128{mins: make([]uint64, 2*numHash), seed: seed}
+	var buf [8]byte
+	for i := range m.states {
+		binary.BigEndian.PutUint64(buf[:], uint64(r.Int63()))
+		m.states[i] = fnv64a(fnvOffset64, buf[:])
+	}
+	for i := range m.mins {
+		m.mins[i] = math.MaxUint64
+	}
+	return m
+}
+
+// Push a new value to the MinHash object.
+// The value should be serialized to byte slice.
+func (m *Minhash128) Push(b []byte) {
+	var h [4]uint64
+	for i := range h {
+		h[i] = fnv64a(m.states[i], b)
+	}
+	for i := 0; i < len(m.mins)/2; i++ {
+		hi := h[0] + uint64(i)*h[1]
+		lo := h[2] + uint64(i)*h[3]
+		if hi < m.mins[2*i] || (hi == m.mins[2*i] && lo < m.mins[2*i+1]) {
+			m.mins[2*i], m.mins[2*i+1] = hi, lo
+		}
+	}
+}
+
+// Signature exports the MinHash as a list of hash values, each made of
+// two consecutive uint64, the high half first.
+func (m *Minhash128) Signature() []uint64 {
+	return append([]uint64(nil), m.mins...)
+}
+
+// Merge combines the signature of the other Minhash128
+// with this one, making this one carry the signature of
+// the union.
+func (m *Minhash128) Merge(o *Minhash128) {
+	if m.seed != o.seed || len(m.mins) != len(o.mins) {
+		panic("Cannot merge Minhash with different seed or size")
+	}
+	for i := 0; i < len(m.mins); i += 2 {
+		if o.mins[i] < m.mins[i] || (o.mins[i] == m.mins[i] && o.mins[i+1] < m.mins[i+1]) {
+			m.mins[i], m.mins[i+1] = o.mins[i], o.mins[i+1]
+		}
+	}
+}
+
+// Similarity128 estimates the Jaccard similarity of two sets from their
+// 128-bit signatures.
+func Similarity128(sig1, sig2 []uint64) float64 {
+	if len(sig1) != len(sig2) || len(sig1) == 0 {
+		return 0
+	}
+	var equal int
+	for i := 0; i < len(sig1); i += 2 {
+		if sig1[i] == sig2[i] && sig1[i+1] == sig2[i+1] {
+			equal++
+		}
+	}
+	return float64(equal) / float64(len(sig1)/2)
+}
+
+// FNV-1a parameters of hash/fnv.
+const (
+	fnvOffset64 = 14695981039346656037
+	fnvPrime64  = 1099511628211
+)
+
+// fnv64a continues the FNV-1a hash of state with b, as fnv.New64a would
+// after writing the data hashed into state.
+func fnv64a(state uint64, b []byte) uint64 {
+	for _, c := range b {
+		state ^= uint64(c)
+		state *= fnvPrime64
+	}
+	return state
+}
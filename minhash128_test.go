@@ -0,0 +1,65 @@
+package minhashlsh
+
+import (
+	"hash/fnv"
+	"math/rand"
+	"strconv"
+	"testing"
+)
+
+func randomTokens(n int, seed int64) [][]byte {
+	r := rand.New(rand.NewSource(seed))
+	tokens := make([][]byte, n)
+	for i := range tokens {
+		tokens[i] = []byte(strconv.FormatInt(r.Int63(), 36))
+	}
+	return tokens
+}
+
+func Test_Minhash128(t *testing.T) {
+	tokens := randomTokens(110, 1)
+	a, b := NewMinhash128(1, 128), NewMinhash128(1, 128)
+	for i := 0; i < 100; i++ {
+		a.Push(tokens[i])
+		b.Push(tokens[i+10])
+	}
+	// The Jaccard similarity is 90/110.
+	if s := Similarity128(a.Signature(), b.Signature()); s < 0.7 || s > 0.95 {
+		t.Errorf("similarity estimate %f far from 0.82", s)
+	}
+
+	f := NewMinhashLSH128(128, 0.5, 0, WithMinhashSeed(1))
+	if f.SignatureSize() != 2*f.K*f.L {
+		t.Errorf("expected a signature size of %d, got %d", 2*f.K*f.L, f.SignatureSize())
+	}
+	f.Add("a", a.Signature())
+	f.Index()
+	if !contains(f.Query(b.Signature()), "a") {
+		t.Error("similar set not found")
+	}
+	if !contains(f.QuerySet(tokens[:100]), "a") {
+		t.Error("identical set not found by QuerySet")
+	}
+
+	a.Merge(b)
+	if s := Similarity128(a.Signature(), b.Signature()); s < 0.7 {
+		t.Errorf("merged signature too different: %f", s)
+	}
+}
+
+func Test_Minhash128Fnv(t *testing.T) {
+	h := fnv.New64a()
+	h.Write([]byte("seed"))
+	h.Write([]byte("value"))
+	if got := fnv64a(fnv64a(fnvOffset64, []byte("seed")), []byte("value")); got != h.Sum64() {
+		t.Errorf("expected %x, got %x", h.Sum64(), got)
+	}
+	if raceEnabled {
+		return
+	}
+	m := NewMinhash128(1, 64)
+	b := []byte("value")
+	if allocs := testing.AllocsPerRun(100, func() { m.Push(b) }); allocs > 0 {
+		t.Errorf("expected no allocations, got %.0f", allocs)
+	}
+}
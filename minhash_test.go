@@ -67,3 +67,19 @@ func BenchmarkMinWise256(b *testing.B) {
 func BenchmarkMinWise512(b *testing.B) {
 	benchmark(512, b.N, b)
 }
+
+func TestTruncateSignature(t *testing.T) {
+	words := []string{"one", "two", "three", "four", "five"}
+	long := NewMinhash(1, 256)
+	short := NewMinhash(1, 64)
+	for _, w := range words {
+		long.Push([]byte(w))
+		short.Push([]byte(w))
+	}
+	truncated := TruncateSignature(long.Signature(), 64)
+	for i, v := range short.Signature() {
+		if truncated[i] != v {
+			t.Fatalf("hash value %d differs: %d != %d", i, truncated[i], v)
+		}
+	}
+}
@@ -0,0 +1,28 @@
+package minhashlsh
+
+// MultiCandidate is a candidate key of a multi-signature query, with
+// the positions of the query signatures it is a candidate of.
+type MultiCandidate struct {
+	Key        interface{}
+	Signatures []int
+}
+
+// QueryMulti queries the index with several signatures of an item, such as
+// its title and body sketched separately, and returns the union of their
+// candidate keys, each key once, in the order they were found.
+func (f *MinhashLSH) QueryMulti(sigs ...[]uint64) []MultiCandidate {
+	positions := make(map[interface{}]int)
+	results := make([]MultiCandidate, 0)
+	for s, sig := range sigs {
+		for _, key := range f.queryVerified(sig) {
+			pos, exist := positions[key]
+			if !exist {
+				pos = len(results)
+				positions[key] = pos
+				results = append(results, MultiCandidate{Key: key})
+			}
+			results[pos].Signatures = append(results[pos].Signatures, s)
+		}
+	}
+	return results
+}
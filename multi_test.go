@@ -0,0 +1,33 @@
+package minhashlsh
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_QueryMulti(t *testing.T) {
+	f := NewMinhashLSH16(64, 0.5, 0)
+	title, body := randomSignature(64, 1), randomSignature(64, 2)
+	f.Add("title", title)
+	f.Add("body", body)
+	f.Add("both", title)
+	f.Add("both", body)
+	f.Add("other", randomSignature(64, 3))
+	f.Index()
+	results := f.QueryMulti(title, body)
+	attribution := make(map[interface{}][]int)
+	for _, c := range results {
+		if _, exist := attribution[c.Key]; exist {
+			t.Errorf("key %v returned twice", c.Key)
+		}
+		attribution[c.Key] = c.Signatures
+	}
+	expected := map[interface{}][]int{
+		"title": {0},
+		"body":  {1},
+		"both":  {0, 1},
+	}
+	if !reflect.DeepEqual(attribution, expected) {
+		t.Errorf("expected %v, got %v", expected, attribution)
+	}
+}
@@ -0,0 +1,113 @@
+package minhashlsh
+
+import "sync"
+
+// negativeCache is a set of the band hash keys of queries without
+// candidates, evicted in insertion order.
+type negativeCache struct {
+	mu     sync.Mutex
+	ring   []string
+	next   int
+	items  map[string]struct{}
+	hits   uint64
+	misses uint64
+}
+
+// WithNegativeCache remembers the band hash keys of the last size distinct
+// queries that found no candidates, so queries such as the dedup checks of
+// mostly unique streams, whose signatures keep colliding in all their bands
+// with earlier unanswered ones, skip the binary searches of every band.
+// Query and Contains use the cache, which is cleared by Index and with the
+// query cache.
+func WithNegativeCache(size int) Option {
+	return func(f *MinhashLSH) {
+		f.negCache = newNegativeCache(size)
+	}
+}
+
+// NegativeCacheStats returns the number of queries answered by the
+// negative cache and the number of queries that were not.
+func (f *MinhashLSH) NegativeCacheStats() (hits, misses uint64) {
+	if f.negCache == nil {
+		return 0, 0
+	}
+	f.negCache.mu.Lock()
+	defer f.negCache.mu.Unlock()
+	return f.negCache.hits, f.negCache.misses
+}
+
+func newNegativeCache(size int) *negativeCache {
+	return &negativeCache{ring: make([]string, size), items: make(map[string]struct{})}
+}
+
+func (c *negativeCache) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i := range c.ring {
+		c.ring[i] = ""
+	}
+	c.items = make(map[string]struct{})
+}
+
+// has reports whether the hash keys found no candidates, without
+// allocating.
+func (c *negativeCache) has(hashKeys []byte) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, exist := c.items[string(hashKeys)]
+	if exist {
+		c.hits++
+	} else {
+		c.misses++
+	}
+	return exist
+}
+
+func (c *negativeCache) put(hashKeys []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.ring) == 0 {
+		return
+	}
+	if _, exist := c.items[string(hashKeys)]; exist {
+		return
+	}
+	key := string(hashKeys)
+	delete(c.items, c.ring[c.next])
+	c.ring[c.next] = key
+	c.items[key] = struct{}{}
+	c.next = (c.next + 1) % len(c.ring)
+}
+
+// negativeHashKeys appends the hash keys of all the bands of a signature
+// to dst, for the negative cache, returning the width of a hash key.
+func (f *MinhashLSH) negativeHashKeys(dst []byte, sig []uint64) ([]byte, int) {
+	width := 0
+	for i := 0; i < f.L; i++ {
+		dst = f.appendKey(dst, f.band(sig, i))
+		if i == 0 {
+			width = len(dst)
+		}
+	}
+	return dst, width
+}
+
+// containsNegativeCache is Contains using the negative cache.
+func (f *MinhashLSH) containsNegativeCache(sig []uint64) bool {
+	b := acquireKeyBuffer(0)
+	defer releaseKeyBuffer(b)
+	var width int
+	*b, width = f.negativeHashKeys((*b)[:0], sig)
+	if f.negCache.has(*b) {
+		return false
+	}
+	for i := 0; i < f.L; i++ {
+		for _, e := range f.bucketBytes(i, (*b)[i*width:(i+1)*width]) {
+			if !f.isHidden(e.Key) {
+				return true
+			}
+		}
+	}
+	f.negCache.put(*b)
+	return false
+}
@@ -0,0 +1,39 @@
+package minhashlsh
+
+import "testing"
+
+func Test_NegativeCache(t *testing.T) {
+	f := NewMinhashLSH16(64, 0.5, 0, WithNegativeCache(2))
+	for i := 0; i < 10; i++ {
+		f.Add(i, randomSignature(64, int64(i)))
+	}
+	f.Index()
+	sigs := [][]uint64{randomSignature(64, 100), randomSignature(64, 101), randomSignature(64, 102)}
+	for _, sig := range sigs[:2] {
+		if len(f.Query(sig)) != 0 || f.Contains(sig) {
+			t.Fatal("unexpected candidates")
+		}
+	}
+	// Each signature missed once, then hit.
+	if hits, misses := f.NegativeCacheStats(); hits != 2 || misses != 2 {
+		t.Errorf("expected 2 hits and 2 misses, got %d and %d", hits, misses)
+	}
+	if !contains(f.Query(randomSignature(64, 3)), 3) || !f.Contains(randomSignature(64, 3)) {
+		t.Error("key 3 not found")
+	}
+	// The third signature evicts the first.
+	f.Query(sigs[2])
+	f.Query(sigs[0])
+	if hits, misses := f.NegativeCacheStats(); hits != 2 || misses != 6 {
+		t.Errorf("expected 2 hits and 6 misses, got %d and %d", hits, misses)
+	}
+
+	f.Add("new", sigs[1])
+	if f.Contains(sigs[1]) {
+		t.Error("key found before Index")
+	}
+	f.Index()
+	if !contains(f.Query(sigs[1]), "new") || !f.Contains(sigs[1]) {
+		t.Error("negative cache not cleared by Index")
+	}
+}
@@ -0,0 +1,53 @@
+package minhashlsh
+
+// Novelty reports the number of candidate near-neighbors of a document
+// among the documents seen before it, and whether it is novel.
+type Novelty struct {
+	Key        interface{}
+	Candidates int
+	Novel      bool
+}
+
+// NoveltyDetector flags the documents of a stream unlike anything seen
+// before, for novelty or anomaly detection: documents with at most
+// maxCandidates candidates among the documents seen before are novel.
+// Every document is added, so a new kind of document is only novel until
+// more of its kind are seen.
+type NoveltyDetector struct {
+	detector      *Detector
+	maxCandidates int
+}
+
+// NewNoveltyDetector creates a novelty detector adding documents to lsh,
+// which may already hold indexed documents. With maxCandidates at 0, only
+// documents without any candidate are novel.
+func NewNoveltyDetector(lsh *MinhashLSH, maxCandidates int) *NoveltyDetector {
+	return &NoveltyDetector{NewDetector(lsh), maxCandidates}
+}
+
+// Observe checks whether a document is novel, and adds it.
+func (d *NoveltyDetector) Observe(key interface{}, sig []uint64) Novelty {
+	n := len(d.detector.Observe(key, sig))
+	return Novelty{key, n, n <= d.maxCandidates}
+}
+
+// Flag observes the documents of a stream, passing on the ones that are
+// novel in order. The output is closed once the input is.
+func (d *NoveltyDetector) Flag(docs <-chan Document) <-chan Novelty {
+	out := make(chan Novelty)
+	go func() {
+		defer close(out)
+		for doc := range docs {
+			if novelty := d.Observe(doc.Key, doc.Signature); novelty.Novel {
+				out <- novelty
+			}
+		}
+	}()
+	return out
+}
+
+// Index merges the pending documents into the index, for example before
+// saving it.
+func (d *NoveltyDetector) Index() {
+	d.detector.Index()
+}
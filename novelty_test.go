@@ -0,0 +1,30 @@
+package minhashlsh
+
+import "testing"
+
+func Test_NoveltyDetector(t *testing.T) {
+	d := NewNoveltyDetector(NewMinhashLSH16(64, 0.5, 0), 1)
+	docs := make(chan Document)
+	go func() {
+		defer close(docs)
+		for i := 0; i < 100; i++ {
+			// Documents repeat 10 kinds, but for document 50.
+			seed := int64(i % 10)
+			if i == 50 {
+				seed = 1000
+			}
+			docs <- Document{i, randomSignature(64, seed)}
+		}
+	}()
+	var novel []interface{}
+	for novelty := range d.Flag(docs) {
+		novel = append(novel, novelty.Key)
+	}
+	// The second document of each kind has a single candidate.
+	if len(novel) != 21 || novel[20] != 50 {
+		t.Errorf("unexpected novel documents %v", novel)
+	}
+	if n := d.Observe(100, randomSignature(64, 3)); n.Novel || n.Candidates != 10 {
+		t.Errorf("unexpected novelty %+v", n)
+	}
+}
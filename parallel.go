@@ -0,0 +1,148 @@
+package minhashlsh
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"runtime"
+)
+
+// WithParallelism sets the number of sections compressed concurrently by
+// Save, and decompressed concurrently by Load, runtime.GOMAXPROCS(0) up to
+// the number of bands by default. Each band is a section, so saving and
+// loading large indexes scales with the number of cores up to the number
+// of bands. It costs memory: with n > 1, up to n compressed sections are
+// buffered in full, that is about n/L of the size of the saved index,
+// besides the index itself. With 1, sections are compressed and
+// decompressed in the calling goroutine and streamed, in a small constant
+// amount of memory.
+func WithParallelism(n int) PersistOption {
+	return func(c *persistConfig) {
+		c.parallelism = n
+	}
+}
+
+// workers returns the number of the n sections processed concurrently.
+func (c *persistConfig) workers(n int) int {
+	if c.parallelism > 0 {
+		return c.parallelism
+	}
+	return minInt(runtime.GOMAXPROCS(0), n)
+}
+
+type sectionResult struct {
+	content []byte
+	err     error
+}
+
+// writeSections writes n sections numbered from first with the contents
+// written by encode, compressing them concurrently and writing them in
+// order. start is called before writing each section.
+func writeSections(w io.Writer, config *persistConfig, first, n int, start func(i int), encode func(i int, w io.Writer) error) error {
+	workers := config.workers(n)
+	if workers <= 1 {
+		for i := 0; i < n; i++ {
+			start(i)
+			if err := writeSection(w, config, first+i, func(w io.Writer) error {
+				return encode(i, w)
+			}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	results := make([]chan sectionResult, n)
+	next := 0
+	compress := func() {
+		if next == n {
+			return
+		}
+		i := next
+		results[i] = make(chan sectionResult, 1)
+		go func() {
+			var buf bytes.Buffer
+			err := writeSection(&buf, config, first+i, func(w io.Writer) error {
+				return encode(i, w)
+			})
+			results[i] <- sectionResult{buf.Bytes(), err}
+		}()
+		next++
+	}
+	for next < workers && next < n {
+		compress()
+	}
+	for i := range results {
+		r := <-results[i]
+		if r.err != nil {
+			return r.err
+		}
+		start(i)
+		if _, err := w.Write(r.content); err != nil {
+			return err
+		}
+		compress()
+	}
+	return nil
+}
+
+// readSections reads n sections, passing the readers of their content to
+// decode, which may be called concurrently for different sections.
+// start is called before reading each section.
+func readSections(r io.Reader, config *persistConfig, n int, start func(i int), decode func(i int, r io.Reader) error) error {
+	workers := config.workers(n)
+	if workers <= 1 {
+		for i := 0; i < n; i++ {
+			start(i)
+			if err := decode(i, r); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	errs := make([]chan error, n)
+	// waited is the oldest section in flight.
+	waited := 0
+	for i := 0; i < n; i++ {
+		if i-waited == workers {
+			if err := <-errs[waited]; err != nil {
+				return err
+			}
+			waited++
+		}
+		start(i)
+		raw, err := readRawSection(r)
+		if err != nil {
+			return err
+		}
+		errs[i] = make(chan error, 1)
+		go func(i int) {
+			errs[i] <- decode(i, bytes.NewReader(raw))
+		}(i)
+	}
+	for ; waited < n; waited++ {
+		if err := <-errs[waited]; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readRawSection returns the frames of the next section of r as written,
+// to be decoded independently.
+func readRawSection(r io.Reader) ([]byte, error) {
+	var buf bytes.Buffer
+	var size [4]byte
+	for {
+		if _, err := io.ReadFull(r, size[:]); err != nil {
+			return nil, &CorruptIndexError{"truncated section"}
+		}
+		buf.Write(size[:])
+		n := binary.LittleEndian.Uint32(size[:])
+		if n == 0 {
+			return buf.Bytes(), nil
+		}
+		if _, err := io.CopyN(&buf, r, int64(n)); err != nil {
+			return nil, &CorruptIndexError{"truncated section"}
+		}
+	}
+}
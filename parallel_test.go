@@ -0,0 +1,77 @@
+package minhashlsh
+
+import (
+	"bytes"
+	"io/ioutil"
+	"reflect"
+	"runtime"
+	"testing"
+)
+
+func Test_SaveLoadParallel(t *testing.T) {
+	f := testIndex(1000)
+	key := []byte("0123456789abcdef")
+	var sequential bytes.Buffer
+	if err := f.Encode(&sequential, WithParallelism(1)); err != nil {
+		t.Fatal(err)
+	}
+	for _, n := range []int{0, 2, 4, 64} {
+		var buf bytes.Buffer
+		if err := f.Encode(&buf, WithParallelism(n)); err != nil {
+			t.Fatal(err)
+		}
+		// Sections compress the same whatever the parallelism.
+		if !bytes.Equal(buf.Bytes(), sequential.Bytes()) {
+			t.Fatalf("parallelism %d: encoding differs", n)
+		}
+		g, err := Decode(&buf, WithParallelism(n))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(g.HashTables, f.HashTables) {
+			t.Fatalf("parallelism %d: hash tables differ", n)
+		}
+
+		filename, cleanup := tempFilename(t)
+		defer cleanup()
+		if err := f.Save(filename, WithParallelism(n), WithEncryptionKey(key)); err != nil {
+			t.Fatal(err)
+		}
+		for _, opts := range [][]PersistOption{nil, {WithLazyLoading()}} {
+			g, err := Load(filename, append(opts, WithParallelism(n), WithEncryptionKey(key))...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := g.Materialize(); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(g.HashTables, f.HashTables) {
+				t.Fatalf("parallelism %d: loaded hash tables differ", n)
+			}
+		}
+
+		data, err := ioutil.ReadFile(filename)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, corrupted := range [][]byte{data[:len(data)/2], data[:len(data)-20]} {
+			_, err := Decode(bytes.NewReader(corrupted), WithParallelism(n), WithEncryptionKey(key))
+			if _, ok := err.(*CorruptIndexError); !ok {
+				t.Errorf("parallelism %d: expected a *CorruptIndexError, got %v", n, err)
+			}
+		}
+	}
+}
+
+func Test_ParallelismDefault(t *testing.T) {
+	config := newPersistConfig(nil)
+	if n := config.workers(1000); n != runtime.GOMAXPROCS(0) {
+		t.Errorf("expected GOMAXPROCS workers, got %d", n)
+	}
+	if n := config.workers(1); n != 1 {
+		t.Errorf("expected a worker per section at most, got %d", n)
+	}
+	if n := newPersistConfig([]PersistOption{WithParallelism(3)}).workers(1); n != 3 {
+		t.Errorf("expected the parallelism set, got %d", n)
+	}
+}
@@ -0,0 +1,145 @@
+package minhashlsh
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrPartitionSealed is returned when adding a key to a partition that has
+// been sealed already.
+var ErrPartitionSealed = errors.New("minhashlsh: partition is sealed")
+
+// Partitions maintains time partitions of an index, such as one per day.
+// Keys are added to the partition of their time; when a newer partition is
+// started, the older ones are sealed: indexed and saved to a blob store under
+// the name given by PartitionName. Queries are routed to the partitions
+// overlapping a lookback window, the partitions out of it are dropped from
+// memory and can be loaded again with Load.
+type Partitions struct {
+	period   time.Duration
+	lookback time.Duration
+	newIndex func() *MinhashLSH
+	store    BlobStore
+	opts     []PersistOption
+	// The partitions in memory, from the oldest to the newest.
+	parts []*partition
+}
+
+type partition struct {
+	start  time.Time
+	lsh    *MinhashLSH
+	dirty  bool
+	sealed bool
+}
+
+// NewPartitions creates partitions of the given period, each an index
+// created by newIndex, querying the partitions of the lookback window.
+// Sealed partitions are saved to store with the persist options.
+func NewPartitions(period, lookback time.Duration, newIndex func() *MinhashLSH,
+	store BlobStore, opts ...PersistOption) *Partitions {
+	return &Partitions{
+		period:   period,
+		lookback: lookback,
+		newIndex: newIndex,
+		store:    store,
+		opts:     opts,
+	}
+}
+
+// PartitionName returns the name in the blob store of the partition
+// starting at start.
+func PartitionName(start time.Time) string {
+	return start.UTC().Format("20060102T150405Z")
+}
+
+// Add a key with its signature at time t, rolling over to a new partition
+// if t is past the current one. The key won't be searchable until Index is
+// called. ErrPartitionSealed is returned if the partition of t is sealed.
+func (p *Partitions) Add(key interface{}, sig []uint64, t time.Time) error {
+	start := t.Truncate(p.period)
+	var part *partition
+	if n := len(p.parts); n > 0 && !p.parts[n-1].start.Before(start) {
+		for _, q := range p.parts {
+			if q.start.Equal(start) {
+				part = q
+			}
+		}
+		if part == nil || part.sealed {
+			return ErrPartitionSealed
+		}
+	} else {
+		if err := p.Seal(); err != nil {
+			return err
+		}
+		p.evict(t)
+		part = &partition{start: start, lsh: p.newIndex()}
+		p.parts = append(p.parts, part)
+	}
+	part.lsh.Add(key, sig)
+	part.dirty = true
+	return nil
+}
+
+// Index makes all the keys added searchable.
+func (p *Partitions) Index() {
+	for _, part := range p.parts {
+		if part.dirty {
+			part.lsh.Index()
+			part.dirty = false
+		}
+	}
+}
+
+// Seal seals all the partitions in memory, such as before shutting down.
+func (p *Partitions) Seal() error {
+	for _, part := range p.parts {
+		if part.sealed {
+			continue
+		}
+		part.lsh.Index()
+		part.dirty = false
+		if err := part.lsh.SaveTo(p.store, PartitionName(part.start), p.opts...); err != nil {
+			return err
+		}
+		part.sealed = true
+	}
+	return nil
+}
+
+// evict drops the sealed partitions out of the lookback window ending at now.
+func (p *Partitions) evict(now time.Time) {
+	i := 0
+	for i < len(p.parts) && p.parts[i].sealed && p.outOfLookback(p.parts[i], now) {
+		p.parts[i] = nil
+		i++
+	}
+	p.parts = p.parts[i:]
+}
+
+func (p *Partitions) outOfLookback(part *partition, now time.Time) bool {
+	return !part.start.Add(p.period).After(now.Add(-p.lookback))
+}
+
+// Query returns the candidate keys given the query signature among the
+// partitions overlapping the lookback window ending at now.
+func (p *Partitions) Query(sig []uint64, now time.Time) []interface{} {
+	results := make([]interface{}, 0)
+	seen := make(map[interface{}]bool)
+	for _, part := range p.parts {
+		if p.outOfLookback(part, now) {
+			continue
+		}
+		for _, key := range part.lsh.query(sig).keys {
+			if !seen[key] {
+				seen[key] = true
+				results = append(results, key)
+			}
+		}
+	}
+	return results
+}
+
+// Load loads the sealed partition starting at start from the blob store.
+func (p *Partitions) Load(start time.Time, opts ...PersistOption) (*MinhashLSH, error) {
+	return LoadFrom(p.store, PartitionName(start.Truncate(p.period)), opts...)
+}
@@ -0,0 +1,57 @@
+package minhashlsh
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+)
+
+func Test_Partitions(t *testing.T) {
+	dir, err := ioutil.TempDir("", "minhashlsh")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	p := NewPartitions(24*time.Hour, 48*time.Hour, func() *MinhashLSH {
+		return NewMinhashLSH16(64, 0.5, 0)
+	}, DirStore(dir))
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	day := func(i int) time.Time { return start.Add(time.Duration(i) * 24 * time.Hour) }
+	for i := 0; i < 5; i++ {
+		if err := p.Add(i, randomSignature(64, int64(i)), day(i).Add(time.Hour)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := p.Add(100, randomSignature(64, 100), day(3)); err != ErrPartitionSealed {
+		t.Errorf("expected ErrPartitionSealed, got %v", err)
+	}
+	p.Index()
+	now := day(4).Add(2 * time.Hour)
+	for i := 0; i < 5; i++ {
+		found := false
+		for _, key := range p.Query(randomSignature(64, int64(i)), now) {
+			found = found || key == i
+		}
+		if found != (i >= 2) {
+			t.Errorf("key of day %d found: %v", i, found)
+		}
+	}
+	if len(p.parts) > 3 {
+		t.Errorf("expected old partitions to be evicted, %d in memory", len(p.parts))
+	}
+
+	old, err := p.Load(day(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results := old.Query(randomSignature(64, 0)); len(results) != 1 || results[0] != 0 {
+		t.Errorf("expected key 0 in the sealed partition, got %v", results)
+	}
+	if err := p.Seal(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.Load(day(4)); err != nil {
+		t.Errorf("current partition not saved: %v", err)
+	}
+}
@@ -0,0 +1,34 @@
+package minhashlsh
+
+import "time"
+
+// PendingWork describes the work left for the next Index, so pipelines can
+// throttle producers or index at sensible points.
+type PendingWork struct {
+	// Entries is the number of keys added but not indexed yet.
+	Entries int
+	// SortedEntries is the number of entries the next Index sorts
+	// in each band, the indexed ones included.
+	SortedEntries int
+	// EstimatedDuration extrapolates the duration of the next Index from
+	// the duration of the previous one, zero if the index was never indexed.
+	EstimatedDuration time.Duration
+}
+
+// Pending returns the work left for the next Index.
+func (f *MinhashLSH) Pending() PendingWork {
+	n := len(f.table(0))
+	return PendingWork{
+		Entries:           n - f.NumIndexedKeys,
+		SortedEntries:     n,
+		EstimatedDuration: f.indexCost * time.Duration(n),
+	}
+}
+
+// recordIndexCost records the duration of an Index sorting n entries
+// per band.
+func (f *MinhashLSH) recordIndexCost(n int, d time.Duration) {
+	if n > 0 {
+		f.indexCost = d / time.Duration(n)
+	}
+}
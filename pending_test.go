@@ -0,0 +1,24 @@
+package minhashlsh
+
+import "testing"
+
+func Test_Pending(t *testing.T) {
+	f := NewMinhashLSH16(64, 0.5, 0)
+	for i := 0; i < 100; i++ {
+		f.Add(i, randomSignature(64, int64(i)))
+	}
+	if p := f.Pending(); p.Entries != 100 || p.SortedEntries != 100 || p.EstimatedDuration != 0 {
+		t.Errorf("unexpected pending work before indexing %+v", p)
+	}
+	f.Index()
+	for i := 100; i < 150; i++ {
+		f.Add(i, randomSignature(64, int64(i)))
+	}
+	p := f.Pending()
+	if p.Entries != 50 || p.SortedEntries != 150 {
+		t.Errorf("unexpected pending work %+v", p)
+	}
+	if f.indexCost > 0 && p.EstimatedDuration != 150*f.indexCost {
+		t.Errorf("expected an estimate of %v, got %v", 150*f.indexCost, p.EstimatedDuration)
+	}
+}
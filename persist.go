@@ -0,0 +1,379 @@
+package minhashlsh
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+)
+
+// PersistOption configures how an index is saved and loaded.
+type PersistOption func(*persistConfig)
+
+type persistConfig struct {
+	encryptionKey []byte
+	compressor    Compressor
+	lazy          bool
+	tracer        Tracer
+	keyCodec      KeyCodec
+	zeroCopy      bool
+	parallelism   int
+	bands         []int
+	// flags is set from the file header when loading.
+	flags uint8
+	// header is the file header, authenticated with encrypted sections.
+	header []byte
+}
+
+func newPersistConfig(opts []PersistOption) *persistConfig {
+	c := &persistConfig{}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Index files start with a file header made of a magic number,
+// the format version, a flags byte and the compression ID.
+// It is followed by the sections holding the index parameters and
+// the hash table of each band, and a footer with the offsets of all sections:
+//
+//	offsets     (1 + L) x uint64
+//	checksum    uint32, CRC-32 of the offsets
+//	numSections uint32
+//	magic       [4]byte
+var indexMagic = [4]byte{'M', 'H', 'L', 'I'}
+
+const (
+	fileHeaderSize  = len(indexMagic) + 3
+	footerTrailSize = 4 + 4 + len(indexMagic)
+)
+
+const (
+	flagEncrypted uint8 = 1 << iota
+	flagSalted
+	flagTrimmed
+)
+
+// indexHeader is the content of the first section of an index file.
+type indexHeader struct {
+	K              int
+	L              int
+	HashValueSize  int
+	NumIndexedKeys int
+	Salt           uint64
+	Trim           TrimPolicy
+}
+
+// countingWriter tracks the number of bytes written.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// countingReader tracks the number of bytes read.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// Encode writes the index to w, see Save.
+func (minhashLsh *MinhashLSH) Encode(w io.Writer, opts ...PersistOption) error {
+	return minhashLsh.write(w, newPersistConfig(opts))
+}
+
+func (minhashLsh *MinhashLSH) write(w io.Writer, config *persistConfig) error {
+	tracer := config.tracer
+	if tracer == nil {
+		tracer = minhashLsh.tracer
+	}
+	span := startSpan(tracer, context.Background(), "minhashlsh.Save")
+	defer span.End()
+	if minhashLsh.bands != nil {
+		return errPartialIndex
+	}
+	if err := minhashLsh.Materialize(); err != nil {
+		return err
+	}
+	// Hidden entries are skipped rather than compacted, so saving leaves
+	// the index unchanged.
+	hidden := minhashLsh.hiddenFilter()
+	numIndexedKeys := minhashLsh.numVisibleIndexed(hidden)
+	span.SetAttribute(attrBands, int64(minhashLsh.L))
+	span.SetAttribute(attrKeys, int64(numIndexedKeys))
+	var flags uint8
+	if config.encryptionKey != nil {
+		flags |= flagEncrypted
+	}
+	if minhashLsh.salt != 0 {
+		flags |= flagSalted
+	}
+	if minhashLsh.trim != TrimLowBits {
+		flags |= flagTrimmed
+	}
+	if config.compressor == nil {
+		config.compressor = GzipCompressor(gzip.DefaultCompression)
+	}
+	cw := &countingWriter{w: w}
+	config.header = append(indexMagic[:len(indexMagic):len(indexMagic)], formatVersion, flags, config.compressor.ID())
+	if _, err := cw.Write(config.header); err != nil {
+		return err
+	}
+
+	offsets := make([]uint64, 0, 1+minhashLsh.L)
+	offsets = append(offsets, uint64(cw.n))
+	header := indexHeader{
+		K:              minhashLsh.K,
+		L:              minhashLsh.L,
+		HashValueSize:  minhashLsh.HashValueSize,
+		NumIndexedKeys: numIndexedKeys,
+		Salt:           minhashLsh.salt,
+		Trim:           minhashLsh.trim,
+	}
+	if err := writeSection(cw, config, 0, func(w io.Writer) error {
+		return encodeParams(w, header)
+	}); err != nil {
+		return err
+	}
+	width := minhashLsh.K * minhashLsh.HashValueSize
+	if err := writeSections(cw, config, 1, minhashLsh.L, func(int) {
+		offsets = append(offsets, uint64(cw.n))
+	}, func(i int, w io.Writer) error {
+		return encodeTable(w, minhashLsh.HashTables[i], width, config.keyCodec, hidden)
+	}); err != nil {
+		return err
+	}
+	return writeFooter(cw, offsets)
+}
+
+func writeFooter(w io.Writer, offsets []uint64) error {
+	footer := make([]byte, 8*len(offsets)+footerTrailSize)
+	for i, offset := range offsets {
+		binary.LittleEndian.PutUint64(footer[8*i:], offset)
+	}
+	trail := footer[8*len(offsets):]
+	binary.LittleEndian.PutUint32(trail, crc32.Checksum(footer[:8*len(offsets)], checksumTable))
+	binary.LittleEndian.PutUint32(trail[4:], uint32(len(offsets)))
+	copy(trail[8:], indexMagic[:])
+	_, err := w.Write(footer)
+	return err
+}
+
+// Decode reads an index written by Encode or Save from r.
+// A *CorruptIndexError is returned if the content is damaged.
+// Lazy loading requires r to implement io.ReaderAt and a Size method,
+// such as *bytes.Reader and *io.SectionReader.
+func Decode(r io.Reader, opts ...PersistOption) (*MinhashLSH, error) {
+	config := newPersistConfig(opts)
+	if config.lazy {
+		ra, ok := r.(sizedReaderAt)
+		if !ok {
+			return nil, errors.New("minhashlsh: lazy loading requires an io.ReaderAt with a Size method")
+		}
+		return readLazy(ra, ra.Size(), nil, config)
+	}
+	return read(r, config)
+}
+
+// WithZeroCopy decodes the hash table of each band from a single buffer
+// holding the whole band: its hash keys and string keys reference the buffer
+// instead of being copied one by one, so loading large indexes allocates far
+// less. The buffer of a band is kept in memory as long as any of its keys
+// is referenced, even after the keys are removed from the index.
+func WithZeroCopy() PersistOption {
+	return func(c *persistConfig) {
+		c.zeroCopy = true
+	}
+}
+
+// traceLoad traces the loading of an index.
+func traceLoad(config *persistConfig, load func() (*MinhashLSH, error)) (*MinhashLSH, error) {
+	span := startSpan(config.tracer, context.Background(), "minhashlsh.Load")
+	defer span.End()
+	lshIndex, err := load()
+	if err == nil {
+		span.SetAttribute(attrBands, int64(lshIndex.L))
+		span.SetAttribute(attrKeys, int64(lshIndex.NumIndexedKeys))
+	}
+	return lshIndex, err
+}
+
+// readFileHeader validates the file header and completes the configuration
+// with the compressor used by the file.
+func readFileHeader(header []byte, config *persistConfig) (*persistConfig, error) {
+	var magic [len(indexMagic)]byte
+	copy(magic[:], header)
+	if magic != indexMagic {
+		return nil, &CorruptIndexError{"not an index file"}
+	}
+	if header[len(indexMagic)] != formatVersion {
+		return nil, fmt.Errorf("minhashlsh: unsupported index format version %d", header[len(indexMagic)])
+	}
+	flags := header[len(indexMagic)+1]
+	if flags&flagEncrypted != 0 && config.encryptionKey == nil {
+		return nil, ErrEncryptionKeyRequired
+	}
+	if flags&flagEncrypted == 0 && config.encryptionKey != nil {
+		return nil, ErrNotEncrypted
+	}
+	compressor, err := compressorFor(header[len(indexMagic)+2], config.compressor)
+	if err != nil {
+		return nil, err
+	}
+	resolved := *config
+	resolved.compressor = compressor
+	resolved.flags = flags
+	resolved.header = append([]byte(nil), header[:fileHeaderSize]...)
+	return &resolved, nil
+}
+
+func decodeHeader(r io.Reader, config *persistConfig) (*MinhashLSH, error) {
+	var header indexHeader
+	if err := readSection(r, config, 0, func(r *checksumReader) (err error) {
+		header, err = decodeParams(r, config.flags)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	if header.L <= 0 || header.K <= 0 || header.HashValueSize <= 0 {
+		return nil, &CorruptIndexError{"invalid index parameters"}
+	}
+	f := &MinhashLSH{
+		K:              header.K,
+		L:              header.L,
+		HashValueSize:  header.HashValueSize,
+		HashTables:     make([]hashTable, header.L),
+		HashKeyFunc:    hashKeyFuncGen(header.HashValueSize),
+		appendKey:      appendKeyFuncGen(header.HashValueSize),
+		NumIndexedKeys: header.NumIndexedKeys,
+	}
+	if header.Salt != 0 || header.Trim != TrimLowBits {
+		f.salt, f.trim = header.Salt, header.Trim
+		f.setHashKeyFunc()
+	}
+	return f, nil
+}
+
+// decodeTable decodes the hash table of the given band of the file.
+func (f *MinhashLSH) decodeTable(r io.Reader, config *persistConfig, band int) (hashTable, error) {
+	var table hashTable
+	if config.zeroCopy {
+		content, err := readSectionContent(r, config, 1+band)
+		if err != nil {
+			return nil, err
+		}
+		if table, err = decodeTableString(content, f.K*f.HashValueSize, config.keyCodec); err != nil {
+			return nil, corruptError(err)
+		}
+	} else if err := readSection(r, config, 1+band, func(r *checksumReader) (err error) {
+		table, err = decodeTableContent(r, f.K*f.HashValueSize, config.keyCodec)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	if len(table) < f.NumIndexedKeys {
+		return nil, &CorruptIndexError{"hash table smaller than the number of indexed keys"}
+	}
+	return table, nil
+}
+
+func read(r io.Reader, config *persistConfig) (*MinhashLSH, error) {
+	return traceLoad(config, func() (*MinhashLSH, error) {
+		return readSequential(r, config)
+	})
+}
+
+func readSequential(r io.Reader, config *persistConfig) (*MinhashLSH, error) {
+	cr := &countingReader{r: bufio.NewReader(r)}
+	header := make([]byte, fileHeaderSize)
+	if _, err := io.ReadFull(cr, header); err != nil {
+		return nil, &CorruptIndexError{"missing header"}
+	}
+	config, err := readFileHeader(header, config)
+	if err != nil {
+		return nil, err
+	}
+
+	offsets := []uint64{uint64(cr.n)}
+	lshIndex, err := decodeHeader(cr, config)
+	if err != nil {
+		return nil, err
+	}
+	numBands := lshIndex.L
+	positions, err := lshIndex.selectBands(config.bands)
+	if err != nil {
+		return nil, err
+	}
+	if err := readSections(cr, config, numBands, func(int) {
+		offsets = append(offsets, uint64(cr.n))
+	}, func(band int, r io.Reader) (err error) {
+		i := band
+		if positions != nil {
+			j, selected := positions[band]
+			if !selected {
+				return skipSection(r)
+			}
+			i = j
+		}
+		lshIndex.HashTables[i], err = lshIndex.decodeTable(r, config, band)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	footer := make([]byte, 8*len(offsets)+footerTrailSize)
+	if _, err := io.ReadFull(cr, footer); err != nil {
+		return nil, &CorruptIndexError{"missing footer"}
+	}
+	stored, err := parseFooter(footer)
+	if err != nil {
+		return nil, err
+	}
+	for i := range offsets {
+		if i >= len(stored) || stored[i] != offsets[i] {
+			return nil, &CorruptIndexError{"section offsets mismatch"}
+		}
+	}
+	if n, _ := cr.Read(make([]byte, 1)); n != 0 {
+		return nil, &CorruptIndexError{"unexpected data after footer"}
+	}
+	return lshIndex, nil
+}
+
+// parseFooter returns the section offsets stored in the footer.
+func parseFooter(footer []byte) ([]uint64, error) {
+	if len(footer) < footerTrailSize {
+		return nil, &CorruptIndexError{"missing footer"}
+	}
+	trail := footer[len(footer)-footerTrailSize:]
+	var magic [len(indexMagic)]byte
+	copy(magic[:], trail[8:])
+	numSections := int(binary.LittleEndian.Uint32(trail[4:]))
+	if magic != indexMagic || len(footer) != 8*numSections+footerTrailSize {
+		return nil, &CorruptIndexError{"invalid footer"}
+	}
+	if binary.LittleEndian.Uint32(trail) != crc32.Checksum(footer[:8*numSections], checksumTable) {
+		return nil, &CorruptIndexError{"footer checksum mismatch"}
+	}
+	offsets := make([]uint64, numSections)
+	for i := range offsets {
+		offsets[i] = binary.LittleEndian.Uint64(footer[8*i:])
+	}
+	return offsets, nil
+}
@@ -0,0 +1,52 @@
+package minhashlsh
+
+import "os"
+
+// Save MinHash LSH index.
+// The index parameters and the hash table of each band are encoded
+// and compressed as separate sections, each followed by a CRC-32 checksum.
+// See formatVersion for the encoding.
+// Entries are streamed to the file one by one, so saving only needs a small
+// constant amount of memory besides the index itself, unless bands are
+// compressed concurrently, see WithParallelism.
+// Removed and soft-deleted keys are left out, without compacting the index.
+func (minhashLsh *MinhashLSH) Save(filename string, opts ...PersistOption) error {
+	fi, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	if err := minhashLsh.write(fi, newPersistConfig(opts)); err != nil {
+		fi.Close()
+		return err
+	}
+	return fi.Close()
+}
+
+// Load MinHash LSH index.
+// A *CorruptIndexError is returned if the file content is damaged.
+func Load(filename string, opts ...PersistOption) (*MinhashLSH, error) {
+	config := newPersistConfig(opts)
+	fi, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	if config.lazy {
+		return loadLazy(fi, config)
+	}
+	defer fi.Close()
+	return read(fi, config)
+}
+
+func loadLazy(fi *os.File, config *persistConfig) (*MinhashLSH, error) {
+	stat, err := fi.Stat()
+	if err != nil {
+		fi.Close()
+		return nil, err
+	}
+	lshIndex, err := readLazy(fi, stat.Size(), fi, config)
+	if err != nil {
+		fi.Close()
+		return nil, err
+	}
+	return lshIndex, nil
+}
@@ -0,0 +1,318 @@
+package minhashlsh
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/gob"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strconv"
+	"testing"
+)
+
+func testIndex(numKeys int) *MinhashLSH {
+	f := NewMinhashLSH16(64, 0.5, numKeys)
+	for i := 0; i < numKeys; i++ {
+		f.Add(strconv.Itoa(i), randomSignature(64, int64(i)))
+	}
+	f.Index()
+	return f
+}
+
+func tempFilename(t *testing.T) (string, func()) {
+	dir, err := ioutil.TempDir("", "minhashlsh")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return filepath.Join(dir, "index"), func() { os.RemoveAll(dir) }
+}
+
+func Test_SaveLoad(t *testing.T) {
+	filename, cleanup := tempFilename(t)
+	defer cleanup()
+	f := testIndex(100)
+	if err := f.Save(filename); err != nil {
+		t.Fatal(err)
+	}
+	g, err := Load(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.K != f.K || g.L != f.L || g.NumIndexedKeys != f.NumIndexedKeys {
+		t.Fatal("loaded index has different parameters")
+	}
+	sig := randomSignature(64, 7)
+	results := g.Query(sig)
+	if len(results) != 1 || results[0].(string) != "7" {
+		t.Fatal(results)
+	}
+}
+
+func Test_LoadCorrupted(t *testing.T) {
+	filename, cleanup := tempFilename(t)
+	defer cleanup()
+	if err := testIndex(100).Save(filename); err != nil {
+		t.Fatal(err)
+	}
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	corrupted := map[string][]byte{
+		"truncated": data[:len(data)/2],
+		"bit-flip":  append([]byte{}, data...),
+	}
+	corrupted["bit-flip"][len(data)/2] ^= 0x10
+	for name, content := range corrupted {
+		if err := ioutil.WriteFile(filename, content, 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Load(filename); err == nil {
+			t.Errorf("%s: expected an error", name)
+		} else if _, ok := err.(*CorruptIndexError); !ok {
+			t.Errorf("%s: unexpected error type %T: %v", name, err, err)
+		}
+	}
+}
+
+func Test_SaveLoadEncrypted(t *testing.T) {
+	filename, cleanup := tempFilename(t)
+	defer cleanup()
+	key := []byte("0123456789abcdef0123456789abcdef")
+	// Use enough keys for the encrypted data to span several chunks.
+	f := testIndex(5000)
+	if err := f.Save(filename, WithEncryptionKey(key)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(filename); err != ErrEncryptionKeyRequired {
+		t.Fatalf("expected ErrEncryptionKeyRequired, got %v", err)
+	}
+	wrongKey := []byte("fedcba9876543210fedcba9876543210")
+	if _, err := Load(filename, WithEncryptionKey(wrongKey)); err == nil {
+		t.Fatal("expected an error loading with the wrong key")
+	} else if _, ok := err.(*CorruptIndexError); !ok {
+		t.Fatalf("unexpected error type %T: %v", err, err)
+	}
+	g, err := Load(filename, WithEncryptionKey(key))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.NumIndexedKeys != f.NumIndexedKeys {
+		t.Fatal("loaded index has different number of keys")
+	}
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filename, data[:len(data)-encryptionChunkSize/2], 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(filename, WithEncryptionKey(key)); err == nil {
+		t.Fatal("expected an error loading truncated file")
+	}
+}
+
+func Test_LoadEncryptedSwappedSections(t *testing.T) {
+	key := []byte("0123456789abcdef")
+	f := testIndex(100)
+	var buf bytes.Buffer
+	if err := f.Encode(&buf, WithEncryptionKey(key)); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+	footerSize := 8*(1+f.L) + footerTrailSize
+	offsets, err := parseFooter(data[len(data)-footerSize:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Swap the sections of bands 0 and 1, with a valid footer.
+	band0 := data[offsets[1]:offsets[2]]
+	band1 := data[offsets[2]:offsets[3]]
+	var swapped bytes.Buffer
+	swapped.Write(data[:offsets[1]])
+	swapped.Write(band1)
+	swapped.Write(band0)
+	swapped.Write(data[offsets[3] : len(data)-footerSize])
+	offsets[2] = offsets[1] + uint64(len(band1))
+	if err := writeFooter(&swapped, offsets); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Decode(&swapped, WithEncryptionKey(key)); err == nil {
+		t.Fatal("expected an error loading swapped sections")
+	} else if _, ok := err.(*CorruptIndexError); !ok {
+		t.Fatalf("unexpected error type %T: %v", err, err)
+	}
+
+	// The file header is authenticated too.
+	tampered := append([]byte(nil), data...)
+	tampered[len(indexMagic)+1] |= flagTrimmed
+	if _, err := Decode(bytes.NewReader(tampered), WithEncryptionKey(key)); err == nil {
+		t.Error("expected an error loading a tampered header")
+	}
+}
+
+func Test_SaveLoadCompression(t *testing.T) {
+	filename, cleanup := tempFilename(t)
+	defer cleanup()
+	f := testIndex(100)
+	for _, c := range []Compressor{NoCompression, GzipCompressor(gzip.BestSpeed),
+		GzipCompressor(gzip.BestCompression), ZstdCompressor(1), ZstdCompressor(19)} {
+		if err := f.Save(filename, WithCompression(c)); err != nil {
+			t.Fatal(err)
+		}
+		// The compression is recorded in the file, so Load needs no options.
+		g, err := Load(filename)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if g.NumIndexedKeys != f.NumIndexedKeys {
+			t.Fatal("loaded index has different number of keys")
+		}
+	}
+
+	// Damaged zstd data is reported as a corrupt index.
+	var buf bytes.Buffer
+	if err := f.Encode(&buf, WithCompression(ZstdCompressor(3))); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+	data[fileHeaderSize+20] ^= 0xff
+	if _, err := Decode(bytes.NewReader(data)); err == nil {
+		t.Error("expected an error loading damaged zstd data")
+	} else if _, ok := err.(*CorruptIndexError); !ok {
+		t.Errorf("unexpected error type %T: %v", err, err)
+	}
+}
+
+func Test_LoadLazy(t *testing.T) {
+	filename, cleanup := tempFilename(t)
+	defer cleanup()
+	f := testIndex(100)
+	if err := f.Save(filename, WithEncryptionKey([]byte("0123456789abcdef"))); err != nil {
+		t.Fatal(err)
+	}
+	g, err := Load(filename, WithLazyLoading(), WithEncryptionKey([]byte("0123456789abcdef")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range g.HashTables {
+		if g.HashTables[i] != nil {
+			t.Fatal("hash tables should not be loaded before first use")
+		}
+	}
+	results := g.Query(randomSignature(64, 7))
+	if len(results) != 1 || results[0].(string) != "7" {
+		t.Fatal(results)
+	}
+	for i := range g.HashTables {
+		if len(g.HashTables[i]) != len(f.HashTables[i]) {
+			t.Fatal("hash table not loaded on first use")
+		}
+	}
+}
+
+type testKey struct {
+	ID   int
+	Name string
+}
+
+func Test_SaveLoadKeyTypes(t *testing.T) {
+	gob.Register(testKey{})
+	filename, cleanup := tempFilename(t)
+	defer cleanup()
+	keys := []interface{}{"a", int(-1), int64(2), int32(-3), uint64(4), uint32(5),
+		float64(6.5), testKey{7, "seven"}}
+	f := NewMinhashLSH16(64, 0.5, len(keys))
+	for i, key := range keys {
+		f.Add(key, randomSignature(64, int64(i)))
+	}
+	f.Index()
+	if err := f.Save(filename); err != nil {
+		t.Fatal(err)
+	}
+	g, err := Load(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, key := range keys {
+		results := g.Query(randomSignature(64, int64(i)))
+		if len(results) != 1 || results[0] != key {
+			t.Errorf("expected %#v, got %#v", key, results)
+		}
+	}
+}
+
+func Test_SaveMemory(t *testing.T) {
+	f := testIndex(20000)
+	w := &countingWriter{w: ioutil.Discard}
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	config := newPersistConfig([]PersistOption{WithCompression(NoCompression), WithParallelism(1)})
+	if err := f.write(w, config); err != nil {
+		t.Fatal(err)
+	}
+	runtime.ReadMemStats(&after)
+	// Saving sequentially streams the entries, so it should not allocate
+	// memory in proportion to the index size.
+	if alloc := after.TotalAlloc - before.TotalAlloc; alloc > uint64(w.n/2) {
+		t.Fatalf("saving %d bytes allocated %d bytes", w.n, alloc)
+	}
+}
+
+func Test_EncodeDecode(t *testing.T) {
+	f := testIndex(100)
+	var buf bytes.Buffer
+	if err := f.Encode(&buf); err != nil {
+		t.Fatal(err)
+	}
+	for _, opts := range [][]PersistOption{nil, {WithLazyLoading()}} {
+		g, err := Decode(bytes.NewReader(buf.Bytes()), opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		results := g.Query(randomSignature(64, 7))
+		if len(results) != 1 || results[0].(string) != "7" {
+			t.Fatal(results)
+		}
+	}
+	if _, err := Decode(&buf, WithLazyLoading()); err == nil {
+		t.Fatal("expected an error decoding lazily without io.ReaderAt")
+	}
+}
+
+func Test_DecodeZeroCopy(t *testing.T) {
+	gob.Register(testKey{})
+	f := NewMinhashLSH16(64, 0.5, 0)
+	for i := 0; i < 100; i++ {
+		var key interface{} = strconv.Itoa(i)
+		switch i % 3 {
+		case 1:
+			key = int64(i)
+		case 2:
+			key = testKey{ID: i}
+		}
+		f.Add(key, randomSignature(64, int64(i)))
+	}
+	f.Index()
+	var buf bytes.Buffer
+	if err := f.Encode(&buf); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+	loaded, err := Decode(bytes.NewReader(data), WithZeroCopy())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(loaded.HashTables, f.HashTables) {
+		t.Error("hash tables differ after zero-copy decoding")
+	}
+
+	data[len(data)/2] ^= 0xff
+	if _, err := Decode(bytes.NewReader(data), WithZeroCopy()); err == nil {
+		t.Error("expected an error decoding a corrupted index")
+	}
+}
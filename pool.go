@@ -0,0 +1,44 @@
+package minhashlsh
+
+import "sync"
+
+// keyBufferPool holds the buffers in which hash keys are built.
+var keyBufferPool = sync.Pool{
+	New: func() interface{} { return new([]byte) },
+}
+
+// acquireKeyBuffer returns a pooled buffer of n bytes.
+func acquireKeyBuffer(n int) *[]byte {
+	b := keyBufferPool.Get().(*[]byte)
+	if cap(*b) < n {
+		*b = make([]byte, n)
+	}
+	*b = (*b)[:n]
+	return b
+}
+
+func releaseKeyBuffer(b *[]byte) {
+	keyBufferPool.Put(b)
+}
+
+var signaturePool sync.Pool
+
+// AcquireSignature returns a signature slice of n hash values, reusing one
+// released by ReleaseSignature if possible, for Minhash.SignatureTo.
+// Sketch-and-query loops reusing signatures this way avoid allocating
+// a signature per set. The hash values are not zeroed.
+func AcquireSignature(n int) []uint64 {
+	if p, ok := signaturePool.Get().(*[]uint64); ok && cap(*p) >= n {
+		return (*p)[:n]
+	}
+	return make([]uint64, n)
+}
+
+// ReleaseSignature returns a signature slice to the pool of
+// AcquireSignature. It must not be used afterwards, nor be still referenced
+// by an index: indexes keep the signatures of WithSignatureStorage as
+// copies, but a signature must not be released while Add or Query is using
+// it.
+func ReleaseSignature(sig []uint64) {
+	signaturePool.Put(&sig)
+}
@@ -0,0 +1,65 @@
+package minhashlsh
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_SignatureReuse(t *testing.T) {
+	tokens := randomTokens(20, 1)
+	mh := NewMinhash(1, 64)
+	for _, token := range tokens {
+		mh.Push(token)
+	}
+	expected := mh.Signature()
+	sig := mh.SignatureTo(AcquireSignature(64))
+	if !reflect.DeepEqual(sig, expected) {
+		t.Error("SignatureTo differs from Signature")
+	}
+	ReleaseSignature(sig)
+	if sig := AcquireSignature(32); len(sig) != 32 {
+		t.Errorf("expected 32 hash values, got %d", len(sig))
+	}
+
+	mh.Reset()
+	for _, token := range tokens {
+		mh.Push(token)
+	}
+	if !reflect.DeepEqual(mh.Signature(), expected) {
+		t.Error("signature differs after Reset")
+	}
+}
+
+// raceEnabled is set when testing with the race detector.
+var raceEnabled bool
+
+func Test_HashKeyAllocs(t *testing.T) {
+	if raceEnabled {
+		t.Skip("allocations depend on sync.Pool")
+	}
+	f := NewMinhashLSH16(64, 0.5, 0)
+	sig := randomSignature(64, 1)
+	allocs := testing.AllocsPerRun(100, func() {
+		f.hashKeys(sig)
+	})
+	// One allocation for the hash keys and one per hash key.
+	if max := float64(1 + f.L); allocs > max {
+		t.Errorf("expected at most %.0f allocations, got %.0f", max, allocs)
+	}
+}
+
+func Test_QueryAllocs(t *testing.T) {
+	if raceEnabled {
+		t.Skip("allocations depend on sync.Pool")
+	}
+	sig := randomSignature(64, 1000)
+	for _, f := range []*MinhashLSH{testIndex(100), NewMinhashLSH16(64, 0.5, 0, WithSalt(1))} {
+		allocs := testing.AllocsPerRun(100, func() {
+			f.Query(sig)
+		})
+		// Only the results map and slice allocate without candidates.
+		if allocs > 2 {
+			t.Errorf("salt %d: expected at most 2 allocations, got %.0f", f.salt, allocs)
+		}
+	}
+}
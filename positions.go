@@ -0,0 +1,53 @@
+package minhashlsh
+
+// position is the location of an entry in the hash tables.
+type position struct {
+	band  int
+	index int
+}
+
+// removedEntry replaces the key of entries removed through key positions.
+type removedEntry struct{}
+
+// WithKeyPositions maintains a map from each key to the positions of its
+// entries, so Remove and Update only touch the entries of the key instead of
+// scanning the hash tables when a removed key is added again.
+// The map costs memory for every entry and is rebuilt by Index and Compact.
+func WithKeyPositions() Option {
+	return func(f *MinhashLSH) {
+		f.positions = make(map[interface{}][]position)
+	}
+}
+
+// Update replaces the signature of a key.
+// The new signature won't be searchable until Index() is called.
+func (f *MinhashLSH) Update(key interface{}, sig []uint64) {
+	f.Remove(key)
+	f.Add(key, sig)
+}
+
+// removeEntries marks the entries of a key as removed in place.
+func (f *MinhashLSH) removeEntries(key interface{}) {
+	for _, p := range f.positions[key] {
+		f.HashTables[p.band][p.index].Key = removedEntry{}
+		f.numRemovedEntries++
+	}
+	delete(f.positions, key)
+}
+
+// indexPositions rebuilds the key positions after entries have been moved.
+func (f *MinhashLSH) indexPositions() {
+	if f.positions == nil {
+		return
+	}
+	positions := make(map[interface{}][]position, len(f.positions))
+	for i, table := range f.HashTables {
+		for j, e := range table {
+			if e.Key == (removedEntry{}) {
+				continue
+			}
+			positions[e.Key] = append(positions[e.Key], position{i, j})
+		}
+	}
+	f.positions = positions
+}
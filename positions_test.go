@@ -0,0 +1,46 @@
+package minhashlsh
+
+import "testing"
+
+func Test_KeyPositions(t *testing.T) {
+	f := NewMinhashLSH16(64, 0.5, 10, WithKeyPositions())
+	for i := 0; i < 10; i++ {
+		f.Add(i, randomSignature(64, int64(i)))
+	}
+	f.Index()
+	for key, positions := range f.positions {
+		if len(positions) != f.L {
+			t.Fatalf("key %v has %d positions", key, len(positions))
+		}
+		for _, p := range positions {
+			if f.HashTables[p.band][p.index].Key != key {
+				t.Fatalf("position of key %v points to %v", key, f.HashTables[p.band][p.index].Key)
+			}
+		}
+	}
+
+	f.Update(3, randomSignature(64, 42))
+	f.Index()
+	for _, key := range f.Query(randomSignature(64, 3)) {
+		if key == 3 {
+			t.Error("old signature of updated key found")
+		}
+	}
+	found := false
+	for _, key := range f.Query(randomSignature(64, 42)) {
+		found = found || key == 3
+	}
+	if !found {
+		t.Error("updated key not found")
+	}
+
+	f.Compact()
+	for i, table := range f.HashTables {
+		if len(table) != 10 {
+			t.Errorf("band %d has %d entries after compaction", i, len(table))
+		}
+	}
+	if len(f.positions[3]) != f.L {
+		t.Errorf("updated key has %d positions", len(f.positions[3]))
+	}
+}
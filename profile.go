@@ -0,0 +1,70 @@
+package minhashlsh
+
+import "time"
+
+// QueryProfile breaks down the time spent by a query in its stages.
+type QueryProfile struct {
+	// HashKeys is the time spent computing the band hash keys.
+	HashKeys time.Duration
+	// Search is the time spent binary searching the buckets.
+	Search time.Duration
+	// Scan is the time spent reading the entries of the buckets and
+	// skipping the removed keys.
+	Scan time.Duration
+	// Dedup is the time spent deduplicating the candidates.
+	Dedup time.Duration
+	// Verify is the time spent by the verifier of the index, if any.
+	Verify time.Duration
+	// Entries is the number of bucket entries visited.
+	Entries int
+	// Candidates is the number of distinct candidates returned.
+	Candidates int
+}
+
+// QueryProfile is Query also returning the time spent in each stage of
+// the query, to attribute performance regressions to the right stage.
+// Stages run one after the other when profiling, so the total time is
+// slightly higher than that of Query.
+func (f *MinhashLSH) QueryProfile(sig []uint64) ([]interface{}, QueryProfile) {
+	var p QueryProfile
+	start := time.Now()
+	hashKeys := f.hashKeys(sig)
+	now := time.Now()
+	p.HashKeys = now.Sub(start)
+
+	buckets := make([]hashTable, len(hashKeys))
+	for i := range hashKeys {
+		buckets[i] = f.bucket(i, hashKeys[i])
+	}
+	start, now = now, time.Now()
+	p.Search = now.Sub(start)
+
+	keys := make([]interface{}, 0)
+	for _, bucket := range buckets {
+		step := f.bucketCap.step(len(bucket))
+		for j := 0; j < len(bucket); j += step {
+			p.Entries++
+			if key := bucket[j].Key; !f.isHidden(key) {
+				keys = append(keys, key)
+			}
+		}
+	}
+	start, now = now, time.Now()
+	p.Scan = now.Sub(start)
+
+	results := &candidateSet{keys: make([]interface{}, 0)}
+	for _, key := range keys {
+		results.add(key)
+	}
+	f.countCandidates(results)
+	start, now = now, time.Now()
+	p.Dedup = now.Sub(start)
+
+	keys = results.keys
+	if f.verifier != nil {
+		keys = verify(f.verifier, sig, keys)
+	}
+	p.Verify = time.Since(now)
+	p.Candidates = len(keys)
+	return keys, p
+}
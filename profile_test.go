@@ -0,0 +1,23 @@
+package minhashlsh
+
+import "testing"
+
+func Test_QueryProfile(t *testing.T) {
+	f := NewMinhashLSH16(64, 0.5, 0)
+	sig := randomSignature(64, 1)
+	for i := 0; i < 100; i++ {
+		f.Add(i, sig)
+	}
+	f.Add("other", randomSignature(64, 2))
+	f.Index()
+	results, p := f.QueryProfile(sig)
+	if len(results) != 100 || p.Candidates != 100 {
+		t.Errorf("expected 100 candidates, got %d and %d", len(results), p.Candidates)
+	}
+	if p.Entries != 100*f.L {
+		t.Errorf("expected %d entries visited, got %d", 100*f.L, p.Entries)
+	}
+	if p.HashKeys < 0 || p.Search < 0 || p.Scan < 0 || p.Dedup < 0 {
+		t.Errorf("negative stage duration %+v", p)
+	}
+}
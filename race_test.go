@@ -0,0 +1,9 @@
+//go:build race
+// +build race
+
+package minhashlsh
+
+func init() {
+	// sync.Pool drops items at random under the race detector.
+	raceEnabled = true
+}
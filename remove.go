@@ -0,0 +1,118 @@
+package minhashlsh
+
+// Remove deletes a key from the index. Its entries are only marked as
+// removed, queries skip them until Compact reclaims their space.
+// Adding the key again drops the removed entries on the next Index.
+func (f *MinhashLSH) Remove(key interface{}) {
+	f.invalidateCache()
+	f.publish(ChangeRemove, key, f.signatures[key])
+	delete(f.signatures, key)
+	delete(f.cardinalities, key)
+	delete(f.softDeleted, key)
+	if _, versioned := key.(VersionedKey); versioned {
+		f.latestVersions = nil
+	}
+	if f.positions != nil {
+		f.removeEntries(key)
+		return
+	}
+	if f.removed == nil {
+		f.removed = make(map[interface{}]struct{})
+	}
+	f.removed[key] = struct{}{}
+}
+
+// unremove lets a removed key be added again. Its previous entries stay
+// hidden until the next Index or Compact drops them, so adding keys again
+// does not rewrite the hash tables every time.
+func (f *MinhashLSH) unremove(key interface{}) {
+	if key == (removedEntry{}) || !f.isRemoved(key) {
+		return
+	}
+	delete(f.removed, key)
+	if f.stale == nil {
+		f.stale = make(map[interface{}]int)
+	}
+	f.stale[key] = len(f.HashTables[0])
+}
+
+// isStale reports whether the entry at position j of a hash table is
+// a previous entry of a key added again after being removed.
+func (f *MinhashLSH) isStale(key interface{}, j int) bool {
+	if len(f.stale) == 0 {
+		return false
+	}
+	n, exist := f.stale[key]
+	return exist && j < n
+}
+
+// dropStale drops the previous entries of the keys added again after
+// being removed.
+func (f *MinhashLSH) dropStale() {
+	if len(f.stale) == 0 {
+		return
+	}
+	f.rewrite(func(e entry, j int) bool { return f.isStale(e.Key, j) })
+	f.stale = nil
+}
+
+func (f *MinhashLSH) isRemoved(key interface{}) bool {
+	if key == (removedEntry{}) {
+		return true
+	}
+	if len(f.removed) == 0 {
+		return false
+	}
+	_, removed := f.removed[key]
+	return removed
+}
+
+// Compact rewrites the hash tables without the entries of removed keys.
+// The indexed entries stay sorted, so Index does not need to be called.
+func (f *MinhashLSH) Compact() {
+	if len(f.removed) == 0 && f.numRemovedEntries == 0 && len(f.stale) == 0 {
+		return
+	}
+	removed, stale := f.removed, f.stale
+	f.removed, f.stale = nil, nil
+	f.numRemovedEntries = 0
+	f.rewrite(func(e entry, j int) bool {
+		if e.Key == (removedEntry{}) {
+			return true
+		}
+		if n, exist := stale[e.Key]; exist && j < n {
+			return true
+		}
+		_, exist := removed[e.Key]
+		return exist
+	})
+}
+
+// rewrite drops the entries at position j for which drop returns true.
+func (f *MinhashLSH) rewrite(drop func(e entry, j int) bool) {
+	f.materialize()
+	numIndexedKeys := f.NumIndexedKeys
+	for i, table := range f.HashTables {
+		kept := table[:0]
+		indexed := 0
+		for j, e := range table {
+			if drop(e, j) {
+				continue
+			}
+			if j < f.NumIndexedKeys {
+				indexed++
+			}
+			kept = append(kept, e)
+		}
+		// Release the dropped keys.
+		for j := len(kept); j < len(table); j++ {
+			table[j] = entry{}
+		}
+		f.HashTables[i] = kept
+		// Each key has an entry in every band, so all tables keep
+		// the same number of indexed entries.
+		numIndexedKeys = indexed
+	}
+	f.NumIndexedKeys = numIndexedKeys
+	f.indexPositions()
+}
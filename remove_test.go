@@ -0,0 +1,144 @@
+package minhashlsh
+
+import (
+	"bytes"
+	"strconv"
+	"testing"
+)
+
+func Test_Remove(t *testing.T) {
+	f := testIndex(100)
+	f.Remove("5")
+	for _, key := range f.Query(randomSignature(64, 5)) {
+		if key == "5" {
+			t.Error("removed key returned by query")
+		}
+	}
+	for _, e := range f.QueryExplain(randomSignature(64, 5)) {
+		if e.Key == "5" {
+			t.Error("removed key returned by QueryExplain")
+		}
+	}
+
+	f.Compact()
+	for i, table := range f.HashTables {
+		if len(table) != 99 {
+			t.Errorf("band %d has %d entries after compaction", i, len(table))
+		}
+	}
+	if f.NumIndexedKeys != 99 {
+		t.Errorf("expected 99 indexed keys, got %d", f.NumIndexedKeys)
+	}
+	for i := 0; i < 100; i++ {
+		found := false
+		for _, key := range f.Query(randomSignature(64, int64(i))) {
+			if key == strconv.Itoa(i) {
+				found = true
+			}
+		}
+		if found != (i != 5) {
+			t.Errorf("key %d found: %v", i, found)
+		}
+	}
+}
+
+func Test_RemoveAddAgain(t *testing.T) {
+	f := testIndex(10)
+	f.Remove("3")
+	f.Add("3", randomSignature(64, 42))
+	f.Index()
+	for i, table := range f.HashTables {
+		if len(table) != 10 {
+			t.Errorf("band %d has %d entries", i, len(table))
+		}
+	}
+	for _, key := range f.Query(randomSignature(64, 3)) {
+		if key == "3" {
+			t.Error("old signature of re-added key found")
+		}
+	}
+	found := false
+	for _, key := range f.Query(randomSignature(64, 42)) {
+		found = found || key == "3"
+	}
+	if !found {
+		t.Error("re-added key not found")
+	}
+}
+
+func Test_RemoveAddAgainBeforeIndex(t *testing.T) {
+	f := testIndex(10)
+	f.Remove("3")
+	f.Add("3", randomSignature(64, 42))
+	// The previous entries are dropped by Index, not by Add.
+	if len(f.HashTables[0]) != 11 {
+		t.Errorf("expected 11 entries before Index, got %d", len(f.HashTables[0]))
+	}
+	if contains(f.Query(randomSignature(64, 3)), "3") {
+		t.Error("old signature of re-added key found before Index")
+	}
+	var buf bytes.Buffer
+	if err := f.Encode(&buf); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := Decode(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.NumIndexedKeys != 9 || contains(loaded.Query(randomSignature(64, 3)), "3") {
+		t.Errorf("old entries of re-added key saved, got %d keys", loaded.NumIndexedKeys)
+	}
+	f.Remove("5")
+	f.Add("5", randomSignature(64, 43))
+	f.Index()
+	if len(f.HashTables[0]) != 10 || f.NumIndexedKeys != 10 {
+		t.Errorf("expected 10 indexed entries, got %d", f.NumIndexedKeys)
+	}
+	for key, seed := range map[string]int64{"3": 42, "5": 43} {
+		if !contains(f.Query(randomSignature(64, seed)), key) {
+			t.Errorf("re-added key %s not found", key)
+		}
+	}
+}
+
+func Test_RemoveSave(t *testing.T) {
+	f := testIndex(10)
+	f.Remove("1")
+	var buf bytes.Buffer
+	if err := f.Encode(&buf); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := Decode(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.NumIndexedKeys != 9 {
+		t.Errorf("expected 9 saved keys, got %d", loaded.NumIndexedKeys)
+	}
+	if contains(loaded.Query(randomSignature(64, 1)), "1") {
+		t.Error("removed key found in the loaded index")
+	}
+	// Saving leaves the removed entries to Compact.
+	if f.NumIndexedKeys != 10 || len(f.removed) != 1 {
+		t.Errorf("expected the index unchanged by saving, got %d keys", f.NumIndexedKeys)
+	}
+}
+
+func Test_SoftDeleteSave(t *testing.T) {
+	f := testIndex(10)
+	f.SoftDelete("2")
+	var buf bytes.Buffer
+	if err := f.Encode(&buf, WithKeyCodec(StringKeyCodec)); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := Decode(&buf, WithKeyCodec(StringKeyCodec))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.NumIndexedKeys != 9 || contains(loaded.Query(randomSignature(64, 2)), "2") {
+		t.Error("soft-deleted key saved with the index")
+	}
+	if !f.IsSoftDeleted("2") {
+		t.Error("expected the key still soft-deleted after saving")
+	}
+}
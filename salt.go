@@ -0,0 +1,38 @@
+package minhashlsh
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+)
+
+// WithSalt mixes a secret salt into the hash keys of the index, so inputs
+// crafted against a known Minhash seed cannot make many distinct hash values
+// collide in their truncated form and fill a single bucket. Identical band
+// hash values still collide, keeping the seed of the Minhash used to
+// compute signatures secret is needed against those.
+// The salt is saved with the index. A zero salt disables salting.
+func WithSalt(salt uint64) Option {
+	return func(f *MinhashLSH) {
+		f.salt = salt
+		f.setHashKeyFunc()
+	}
+}
+
+// WithRandomSalt is WithSalt with a salt from crypto/rand.
+func WithRandomSalt() Option {
+	var b [8]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		panic(err)
+	}
+	return WithSalt(binary.LittleEndian.Uint64(b[:]) | 1)
+}
+
+// mix64 is the finalizer of SplitMix64, a bijection on 64-bit values.
+func mix64(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
@@ -0,0 +1,43 @@
+package minhashlsh
+
+import (
+	"bytes"
+	"testing"
+)
+
+func Test_Salt(t *testing.T) {
+	f := NewMinhashLSH16(64, 0.5, 0, WithRandomSalt())
+	g := NewMinhashLSH16(64, 0.5, 0)
+	// Hash values colliding in their lower 16 bits.
+	sig1, sig2 := make([]uint64, 64), make([]uint64, 64)
+	for i := range sig2 {
+		sig2[i] = 1 << 20
+	}
+	if g.HashKeyFunc(sig1) != g.HashKeyFunc(sig2) {
+		t.Fatal("expected truncated hash values to collide without a salt")
+	}
+	if f.HashKeyFunc(sig1) == f.HashKeyFunc(sig2) {
+		t.Error("truncated hash values collide with a salt")
+	}
+
+	for i := 0; i < 10; i++ {
+		f.Add(i, randomSignature(64, int64(i)))
+	}
+	f.Index()
+	var buf bytes.Buffer
+	if err := f.Encode(&buf); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := Decode(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.salt != f.salt {
+		t.Errorf("expected salt %x, got %x", f.salt, loaded.salt)
+	}
+	for i := 0; i < 10; i++ {
+		if !contains(loaded.Query(randomSignature(64, int64(i))), i) {
+			t.Errorf("key %d not found in the loaded index", i)
+		}
+	}
+}
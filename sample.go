@@ -0,0 +1,94 @@
+package minhashlsh
+
+import (
+	"math/rand"
+	"sort"
+)
+
+// Bucket is the visible keys of a bucket of a band.
+type Bucket struct {
+	Band int
+	Keys []interface{}
+}
+
+// sampleBucket returns the bucket of a random indexed entry of a random
+// band, without its hidden keys.
+func (f *MinhashLSH) sampleBucket(r *rand.Rand) Bucket {
+	i := r.Intn(f.L)
+	table := f.table(i)[:f.NumIndexedKeys]
+	hashKey := table[r.Intn(len(table))].HashKey
+	start := sort.Search(len(table), func(x int) bool {
+		return table[x].HashKey >= hashKey
+	})
+	b := Bucket{Band: i}
+	for j := start; j < len(table) && table[j].HashKey == hashKey; j++ {
+		if !f.isHidden(table[j].Key) {
+			b.Keys = append(b.Keys, table[j].Key)
+		}
+	}
+	return b
+}
+
+// SampleBuckets returns n buckets of the indexed keys, each the bucket of a
+// random key in a random band, for corpus-level estimates or approximate
+// clustering without joining the index with itself. A bucket is thus
+// sampled with a probability proportional to its size, and may be sampled
+// more than once. Buckets of hidden keys only are skipped, and none are
+// sampled from an empty index.
+func (f *MinhashLSH) SampleBuckets(n int, r *rand.Rand) []Bucket {
+	if f.NumIndexedKeys == 0 {
+		return nil
+	}
+	buckets := make([]Bucket, 0, n)
+	// Bound the attempts in case most keys are hidden.
+	for attempts := 0; len(buckets) < n && attempts < 10*n; attempts++ {
+		if b := f.sampleBucket(r); len(b.Keys) > 0 {
+			buckets = append(buckets, b)
+		}
+	}
+	return buckets
+}
+
+// SamplePairs returns n candidate pairs of the indexed keys, drawing two
+// keys of the buckets sampled as by SampleBuckets among the buckets of more
+// than one key. A pair sharing a bucket of s keys is sampled with a
+// probability proportional to 1/(s-1), so the pairs of large buckets are
+// less likely than in a uniform sample of candidate pairs. None are sampled
+// if no keys collide.
+func (f *MinhashLSH) SamplePairs(n int, r *rand.Rand) []Pair {
+	if f.NumIndexedKeys == 0 {
+		return nil
+	}
+	pairs := make([]Pair, 0, n)
+	for attempts := 0; len(pairs) < n && attempts < 100*n; attempts++ {
+		b := f.sampleBucket(r)
+		if len(b.Keys) < 2 {
+			continue
+		}
+		i := r.Intn(len(b.Keys))
+		j := r.Intn(len(b.Keys) - 1)
+		if j >= i {
+			j++
+		}
+		pairs = append(pairs, Pair{b.Keys[i], b.Keys[j]})
+	}
+	return pairs
+}
+
+// EstimateDuplicateRate estimates, from n sampled buckets, the fraction of
+// the indexed keys sharing their bucket of a random band with another key,
+// a lower bound of the fraction of keys having candidates. It returns 0 for
+// an empty index.
+func (f *MinhashLSH) EstimateDuplicateRate(n int, r *rand.Rand) float64 {
+	buckets := f.SampleBuckets(n, r)
+	if len(buckets) == 0 {
+		return 0
+	}
+	shared := 0
+	for _, b := range buckets {
+		if len(b.Keys) > 1 {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(buckets))
+}
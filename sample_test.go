@@ -0,0 +1,44 @@
+package minhashlsh
+
+import (
+	"math"
+	"math/rand"
+	"strconv"
+	"testing"
+)
+
+func Test_SampleBuckets(t *testing.T) {
+	f := NewMinhashLSH16(64, 0.5, 0)
+	r := rand.New(rand.NewSource(1))
+	if f.SampleBuckets(10, r) != nil || f.EstimateDuplicateRate(10, r) != 0 {
+		t.Error("expected no samples from an empty index")
+	}
+	// Half of the keys are in pairs of duplicates.
+	for i := 0; i < 1000; i++ {
+		seed := int64(i)
+		if i%4 == 1 {
+			seed = int64(i - 1)
+		}
+		f.Add(strconv.Itoa(i), randomSignature(64, seed))
+	}
+	f.Index()
+	buckets := f.SampleBuckets(100, r)
+	if len(buckets) != 100 {
+		t.Fatalf("expected 100 buckets, got %d", len(buckets))
+	}
+	for _, b := range buckets {
+		if b.Band < 0 || b.Band >= f.L || len(b.Keys) == 0 || len(b.Keys) > 2 {
+			t.Fatalf("unexpected bucket %v", b)
+		}
+	}
+	if rate := f.EstimateDuplicateRate(2000, r); math.Abs(rate-0.5) > 0.05 {
+		t.Errorf("expected a duplicate rate of about 0.5, got %f", rate)
+	}
+	for _, p := range f.SamplePairs(50, r) {
+		i, _ := strconv.Atoi(p.Key1.(string))
+		j, _ := strconv.Atoi(p.Key2.(string))
+		if i/2 != j/2 || i%4 > 1 || i == j {
+			t.Errorf("unexpected pair %v", p)
+		}
+	}
+}
@@ -0,0 +1,257 @@
+package minhashlsh
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"hash"
+	"hash/crc32"
+	"io"
+)
+
+// An index file consists of sections that can be decoded independently.
+// Each section is checksummed, compressed, optionally encrypted and then
+// split into length-prefixed frames terminated by an empty frame,
+// so the end of a section is known without decoding it. Sections are
+// numbered from 0 for the parameters, band i being section 1+i.
+const frameSize = 64 * 1024
+
+// The CRC-32 table used for index file checksums.
+var checksumTable = crc32.MakeTable(crc32.Castagnoli)
+
+// frameWriter splits everything written to it into frames,
+// the terminating frame is written on Close.
+type frameWriter struct {
+	w   io.Writer
+	buf []byte
+}
+
+func newFrameWriter(w io.Writer) *frameWriter {
+	return &frameWriter{w: w, buf: make([]byte, 4, 4+frameSize)}
+}
+
+func (f *frameWriter) Write(p []byte) (int, error) {
+	var n int
+	for len(p) > 0 {
+		if len(f.buf) == cap(f.buf) {
+			if err := f.flush(); err != nil {
+				return n, err
+			}
+		}
+		m := copy(f.buf[len(f.buf):cap(f.buf)], p)
+		f.buf = f.buf[:len(f.buf)+m]
+		p = p[m:]
+		n += m
+	}
+	return n, nil
+}
+
+func (f *frameWriter) flush() error {
+	binary.LittleEndian.PutUint32(f.buf, uint32(len(f.buf)-4))
+	_, err := f.w.Write(f.buf)
+	f.buf = f.buf[:4]
+	return err
+}
+
+// Close writes the remaining data and the terminating frame.
+// It does not close the underlying writer.
+func (f *frameWriter) Close() error {
+	if len(f.buf) > 4 {
+		if err := f.flush(); err != nil {
+			return err
+		}
+	}
+	return f.flush()
+}
+
+// frameReader reads the frames of a single section,
+// returning io.EOF at the terminating frame.
+type frameReader struct {
+	r         io.Reader
+	remaining uint32
+	done      bool
+}
+
+func (f *frameReader) Read(p []byte) (int, error) {
+	if f.remaining == 0 {
+		if f.done {
+			return 0, io.EOF
+		}
+		var size [4]byte
+		if _, err := io.ReadFull(f.r, size[:]); err != nil {
+			return 0, &CorruptIndexError{"truncated section"}
+		}
+		f.remaining = binary.LittleEndian.Uint32(size[:])
+		if f.remaining == 0 {
+			f.done = true
+			return 0, io.EOF
+		}
+	}
+	if uint32(len(p)) > f.remaining {
+		p = p[:f.remaining]
+	}
+	n, err := f.r.Read(p)
+	f.remaining -= uint32(n)
+	if err == io.EOF {
+		err = &CorruptIndexError{"truncated section"}
+	}
+	return n, err
+}
+
+// checksumReader computes the checksum of all bytes read through it.
+type checksumReader struct {
+	r   *bufio.Reader
+	crc hash.Hash32
+}
+
+func (c *checksumReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.crc.Write(p[:n])
+	return n, err
+}
+
+func (c *checksumReader) ReadByte() (byte, error) {
+	b, err := c.r.ReadByte()
+	if err == nil {
+		c.crc.Write([]byte{b})
+	}
+	return b, err
+}
+
+// writeSection writes the given section with the content written by encode.
+func writeSection(w io.Writer, config *persistConfig, section int, encode func(io.Writer) error) error {
+	fw := newFrameWriter(w)
+	var body io.Writer = fw
+	var ew *encryptWriter
+	if config.encryptionKey != nil {
+		var err error
+		if ew, err = newEncryptWriter(fw, config.encryptionKey, config.associatedData(section)); err != nil {
+			return err
+		}
+		body = ew
+	}
+	fz, err := config.compressor.NewWriter(body)
+	if err != nil {
+		return err
+	}
+	crc := crc32.New(checksumTable)
+	if err := encode(io.MultiWriter(fz, crc)); err != nil {
+		return err
+	}
+	if err := binary.Write(fz, binary.LittleEndian, crc.Sum32()); err != nil {
+		return err
+	}
+	if err := fz.Close(); err != nil {
+		return err
+	}
+	if ew != nil {
+		if err := ew.Close(); err != nil {
+			return err
+		}
+	}
+	return fw.Close()
+}
+
+// openSection returns the decrypted and decompressed content of the next
+// section of r, the given section of the file, followed by its checksum.
+func openSection(r io.Reader, config *persistConfig, section int) (*frameReader, io.ReadCloser, error) {
+	fr := &frameReader{r: r}
+	var body io.Reader = fr
+	if config.encryptionKey != nil {
+		dr, err := newDecryptReader(bufio.NewReader(fr), config.encryptionKey, config.associatedData(section))
+		if err != nil {
+			return nil, nil, err
+		}
+		body = dr
+	}
+	fz, err := config.compressor.NewReader(body)
+	if err != nil {
+		return nil, nil, corruptError(err)
+	}
+	return fr, fz, nil
+}
+
+// closeSection checks that the whole section has been read.
+func closeSection(fr *frameReader) error {
+	if !fr.done {
+		if _, err := fr.Read(make([]byte, 1)); err != io.EOF {
+			return &CorruptIndexError{"unexpected data at end of section"}
+		}
+	}
+	return nil
+}
+
+// readSection reads the next section from r, the given section of the file,
+// passing its content to decode. decode must consume the whole content.
+func readSection(r io.Reader, config *persistConfig, section int, decode func(*checksumReader) error) error {
+	fr, fz, err := openSection(r, config, section)
+	if err != nil {
+		return err
+	}
+	defer fz.Close()
+
+	cr := &checksumReader{bufio.NewReader(fz), crc32.New(checksumTable)}
+	if err := decode(cr); err != nil {
+		return corruptError(err)
+	}
+	var checksum uint32
+	if err := binary.Read(cr.r, binary.LittleEndian, &checksum); err != nil {
+		return &CorruptIndexError{"missing checksum"}
+	}
+	if checksum != cr.crc.Sum32() {
+		return &CorruptIndexError{"checksum mismatch"}
+	}
+	// Reading to the end makes the decompressor verify its own trailer.
+	if _, err := cr.r.ReadByte(); err != io.EOF {
+		if err == nil {
+			return &CorruptIndexError{"unexpected data after checksum"}
+		}
+		return corruptError(err)
+	}
+	return closeSection(fr)
+}
+
+// readSectionContent reads the whole content of the next section from r,
+// the given section of the file.
+func readSectionContent(r io.Reader, config *persistConfig, section int) (string, error) {
+	fr, fz, err := openSection(r, config, section)
+	if err != nil {
+		return "", err
+	}
+	defer fz.Close()
+
+	var buf bytes.Buffer
+	if _, err := buf.ReadFrom(fz); err != nil {
+		return "", corruptError(err)
+	}
+	b := buf.Bytes()
+	if len(b) < 4 {
+		return "", &CorruptIndexError{"missing checksum"}
+	}
+	content := b[:len(b)-4]
+	if binary.LittleEndian.Uint32(b[len(content):]) != crc32.Checksum(content, checksumTable) {
+		return "", &CorruptIndexError{"checksum mismatch"}
+	}
+	if err := closeSection(fr); err != nil {
+		return "", err
+	}
+	return string(content), nil
+}
+
+// CorruptIndexError is returned by Load when an index file is truncated,
+// malformed, or does not match its checksum, and by Verify.
+type CorruptIndexError struct {
+	Reason string
+}
+
+func (e *CorruptIndexError) Error() string {
+	return "minhashlsh: corrupt index: " + e.Reason
+}
+
+// corruptError converts a decoding error into a *CorruptIndexError.
+func corruptError(err error) error {
+	if _, ok := err.(*CorruptIndexError); ok || err == ErrKeyCodecRequired {
+		return err
+	}
+	return &CorruptIndexError{err.Error()}
+}
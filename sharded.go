@@ -0,0 +1,103 @@
+package minhashlsh
+
+import (
+	"sort"
+	"strconv"
+	"sync"
+)
+
+// Shard is an index holding part of the keys of a ShardedIndex, such as
+// a *MinhashLSH, a *SnapshotIndex, or a client of a remote index.
+type Shard interface {
+	Add(key interface{}, sig []uint64)
+	Index()
+	Query(sig []uint64) []interface{}
+}
+
+// ShardedIndex routes every key to a shard by consistent hashing of the key,
+// and queries all shards concurrently, merging their results. Adding or
+// removing a shard only moves the keys of about one shard.
+type ShardedIndex struct {
+	shards map[string]Shard
+	ring   ring
+}
+
+type ringPoint struct {
+	hash  uint64
+	shard string
+}
+
+type ring []ringPoint
+
+func (r ring) Len() int           { return len(r) }
+func (r ring) Less(i, j int) bool { return r[i].hash < r[j].hash }
+func (r ring) Swap(i, j int)      { r[i], r[j] = r[j], r[i] }
+
+// NewShardedIndex returns an index over the given shards by name, each
+// placed at the given number of points of the hash ring; some hundreds of
+// points spread the keys evenly. Names identify the shards on the ring, so
+// a shard must keep its name for its keys to keep being routed to it.
+func NewShardedIndex(points int, shards map[string]Shard) *ShardedIndex {
+	s := &ShardedIndex{shards: shards}
+	for name := range shards {
+		for i := 0; i < points; i++ {
+			s.ring = append(s.ring, ringPoint{ringHash(name + "#" + strconv.Itoa(i)), name})
+		}
+	}
+	sort.Sort(s.ring)
+	return s
+}
+
+// Shard returns the name of the shard of a key.
+func (s *ShardedIndex) Shard(key interface{}) string {
+	h := ringHash(key)
+	i := sort.Search(len(s.ring), func(i int) bool { return s.ring[i].hash >= h })
+	if i == len(s.ring) {
+		i = 0
+	}
+	return s.ring[i].shard
+}
+
+// ringHash places a key on the ring, mixing its FNV hash for keys differing
+// in few bytes to spread evenly.
+func ringHash(key interface{}) uint64 {
+	return mix64(keyHash(key))
+}
+
+// Add adds a key to its shard.
+func (s *ShardedIndex) Add(key interface{}, sig []uint64) {
+	s.shards[s.Shard(key)].Add(key, sig)
+}
+
+// Index indexes all shards concurrently.
+func (s *ShardedIndex) Index() {
+	s.forEachShard(func(shard Shard) { shard.Index() })
+}
+
+// Query queries all shards concurrently and returns the union of their
+// candidate keys, each key once.
+func (s *ShardedIndex) Query(sig []uint64) []interface{} {
+	var mu sync.Mutex
+	set := &candidateSet{keys: make([]interface{}, 0)}
+	s.forEachShard(func(shard Shard) {
+		keys := shard.Query(sig)
+		mu.Lock()
+		defer mu.Unlock()
+		for _, key := range keys {
+			set.add(key)
+		}
+	})
+	return set.keys
+}
+
+func (s *ShardedIndex) forEachShard(fn func(shard Shard)) {
+	var wg sync.WaitGroup
+	wg.Add(len(s.shards))
+	for _, shard := range s.shards {
+		go func(shard Shard) {
+			defer wg.Done()
+			fn(shard)
+		}(shard)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,38 @@
+package minhashlsh
+
+import (
+	"fmt"
+	"testing"
+)
+
+func Test_ShardedIndex(t *testing.T) {
+	shards := make(map[string]Shard)
+	for i := 0; i < 4; i++ {
+		shards[fmt.Sprintf("shard%d", i)] = NewMinhashLSH16(64, 0.5, 0)
+	}
+	s := NewShardedIndex(100, shards)
+	sig := randomSignature(64, 1)
+	for i := 0; i < 1000; i++ {
+		s.Add(i, randomSignature(64, int64(i)))
+	}
+	s.Add("dup", sig)
+	s.Index()
+	for name, shard := range shards {
+		if n := shard.(*MinhashLSH).NumIndexedKeys; n < 150 || n > 350 {
+			t.Errorf("%s holds %d keys", name, n)
+		}
+	}
+	results := s.Query(sig)
+	if len(results) != 2 || !contains(results, 1) || !contains(results, "dup") {
+		t.Errorf("unexpected results %v", results)
+	}
+
+	// Adding a shard only moves the keys routed to it.
+	shards["shard4"] = NewMinhashLSH16(64, 0.5, 0)
+	grown := NewShardedIndex(100, shards)
+	for i := 0; i < 1000; i++ {
+		if before, after := s.Shard(i), grown.Shard(i); before != after && after != "shard4" {
+			t.Fatalf("key %d moved from %s to %s", i, before, after)
+		}
+	}
+}
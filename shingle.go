@@ -0,0 +1,71 @@
+package minhashlsh
+
+import (
+	"encoding/binary"
+	"math/rand"
+)
+
+// buzTable maps bytes to random values for the buzhash rolling hash.
+var buzTable = func() [256]uint64 {
+	var t [256]uint64
+	r := rand.New(rand.NewSource(0x62757a))
+	for i := range t {
+		t[i] = uint64(r.Int63())<<1 ^ uint64(r.Int63())
+	}
+	return t
+}()
+
+func rotl(x uint64, n uint) uint64 {
+	n %= 64
+	return x<<n | x>>(64-n)
+}
+
+// ShingleHashes returns the hashes of all the k-byte shingles of data,
+// computed by buzhash in constant time per position whatever k is.
+// Data shorter than k has no shingles.
+func ShingleHashes(data []byte, k int) []uint64 {
+	if k <= 0 || len(data) < k {
+		return nil
+	}
+	hashes := make([]uint64, 0, len(data)-k+1)
+	forEachShingle(data, k, func(h uint64) {
+		hashes = append(hashes, h)
+	})
+	return hashes
+}
+
+func forEachShingle(data []byte, k int, fn func(h uint64)) {
+	if k <= 0 || len(data) < k {
+		return
+	}
+	var h uint64
+	for i := 0; i < k; i++ {
+		h = rotl(h, 1) ^ buzTable[data[i]]
+	}
+	fn(h)
+	for i := k; i < len(data); i++ {
+		h = rotl(h, 1) ^ rotl(buzTable[data[i-k]], uint(k)) ^ buzTable[data[i]]
+		fn(h)
+	}
+}
+
+// PushShingles pushes the k-byte shingles of data to the MinHash, by their
+// rolling hash instead of their bytes, so large binary blobs and long texts
+// are sketched in time independent of k.
+func (m *Minhash) PushShingles(data []byte, k int) {
+	var b [8]byte
+	forEachShingle(data, k, func(h uint64) {
+		binary.LittleEndian.PutUint64(b[:], h)
+		m.Push(b[:])
+	})
+}
+
+// PushShingles pushes the k-byte shingles of data to the MinHash by their
+// rolling hash, see Minhash.PushShingles.
+func (m *Minhash128) PushShingles(data []byte, k int) {
+	var b [8]byte
+	forEachShingle(data, k, func(h uint64) {
+		binary.LittleEndian.PutUint64(b[:], h)
+		m.Push(b[:])
+	})
+}
@@ -0,0 +1,51 @@
+package minhashlsh
+
+import (
+	"bytes"
+	"testing"
+)
+
+func Test_ShingleHashes(t *testing.T) {
+	data := []byte("the quick brown fox jumps over the lazy dog, the quick brown cat")
+	k := 9
+	hashes := ShingleHashes(data, k)
+	if len(hashes) != len(data)-k+1 {
+		t.Fatalf("expected %d shingles, got %d", len(data)-k+1, len(hashes))
+	}
+	for i := range hashes {
+		// The rolling hash must match the hash of the shingle alone.
+		if h := ShingleHashes(data[i:i+k], k); len(h) != 1 || h[0] != hashes[i] {
+			t.Fatalf("rolling hash of shingle %d differs", i)
+		}
+		for j := 0; j < i; j++ {
+			if bytes.Equal(data[i:i+k], data[j:j+k]) != (hashes[i] == hashes[j]) {
+				t.Errorf("shingles %d and %d: hash equality differs from byte equality", i, j)
+			}
+		}
+	}
+	if ShingleHashes(data[:3], 4) != nil {
+		t.Error("expected no shingles for short data")
+	}
+}
+
+func Test_PushShingles(t *testing.T) {
+	var data []byte
+	for _, token := range randomTokens(500, 1) {
+		data = append(data, token...)
+	}
+	changed := append([]byte(nil), data...)
+	copy(changed[100:], "changed")
+	a, b := NewMinhash(1, 128), NewMinhash(1, 128)
+	a.PushShingles(data, 8)
+	b.PushShingles(changed, 8)
+	equal := 0
+	sa, sb := a.Signature(), b.Signature()
+	for i := range sa {
+		if sa[i] == sb[i] {
+			equal++
+		}
+	}
+	if equal < 100 {
+		t.Errorf("expected similar signatures, %d of 128 values equal", equal)
+	}
+}
@@ -0,0 +1,65 @@
+package minhashlsh
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrNoSignatures is returned when an index does not store signatures.
+var ErrNoSignatures = errors.New("minhashlsh: index does not store signatures")
+
+// WithSignatureStorage keeps a copy of the signature of every key added,
+// available with Signature. Signatures are not saved with the index.
+func WithSignatureStorage() Option {
+	return func(f *MinhashLSH) {
+		f.signatures = make(map[interface{}][]uint64)
+	}
+}
+
+// Signature returns the signature of a key if the index stores signatures.
+func (f *MinhashLSH) Signature(key interface{}) ([]uint64, bool) {
+	sig, exist := f.signatures[key]
+	return sig, exist
+}
+
+func (f *MinhashLSH) storeSignature(key interface{}, sig []uint64) {
+	if f.signatures != nil {
+		f.signatures[key] = append([]uint64(nil), sig...)
+	}
+}
+
+// QueryIndex queries f with every signature stored in other, such as a new
+// batch against a catalog, streaming pairs of a key of other and a candidate
+// key of f kept by the verifier of f if any. Removed and soft-deleted keys
+// of other are skipped. An error is returned if a signature of other is
+// shorter than f.SignatureSize(). The channel must be drained, or ctx
+// cancelled, which closes it.
+func (f *MinhashLSH) QueryIndex(ctx context.Context, other *MinhashLSH) (<-chan Pair, error) {
+	if other.signatures == nil {
+		return nil, ErrNoSignatures
+	}
+	for key, sig := range other.signatures {
+		if len(sig) < f.SignatureSize() {
+			return nil, fmt.Errorf("minhashlsh: signature of %v has %d hash values, expected at least %d",
+				key, len(sig), f.SignatureSize())
+		}
+	}
+	pairs := make(chan Pair)
+	go func() {
+		defer close(pairs)
+		for key, sig := range other.signatures {
+			if other.isHidden(key) {
+				continue
+			}
+			for _, candidate := range f.queryContext(ctx, sig, f.verifier) {
+				select {
+				case pairs <- Pair{key, candidate}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return pairs, nil
+}
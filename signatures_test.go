@@ -0,0 +1,75 @@
+package minhashlsh
+
+import (
+	"context"
+	"strconv"
+	"testing"
+)
+
+func Test_QueryIndex(t *testing.T) {
+	catalog := testIndex(100)
+	batch := NewMinhashLSH16(64, 0.5, 0, WithSignatureStorage())
+	for i := 0; i < 10; i++ {
+		batch.Add(-i, randomSignature(64, int64(i)))
+	}
+	batch.Add(-10, randomSignature(64, 10))
+	batch.Remove(-10)
+	if _, exist := batch.Signature(-10); exist {
+		t.Error("signature of removed key still stored")
+	}
+	pairs, err := catalog.QueryIndex(context.Background(), batch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	found := make(map[Pair]bool)
+	for p := range pairs {
+		found[p] = true
+	}
+	for i := 0; i < 10; i++ {
+		if p := (Pair{-i, strconv.Itoa(i)}); !found[p] {
+			t.Errorf("missing pair %v", p)
+		}
+	}
+	if found[Pair{-10, "10"}] {
+		t.Error("removed key queried")
+	}
+
+	if _, err := batch.QueryIndex(context.Background(), catalog); err != ErrNoSignatures {
+		t.Errorf("expected ErrNoSignatures, got %v", err)
+	}
+}
+
+func Test_QueryIndexChecks(t *testing.T) {
+	catalog := testIndex(100)
+	catalog.verifier = VerifierFunc(func(sig []uint64, key interface{}) bool { return key != "1" })
+	batch := NewMinhashLSH16(64, 0.5, 0, WithSignatureStorage())
+	for i := 0; i < 3; i++ {
+		batch.Add(-i, randomSignature(64, int64(i)))
+	}
+	batch.SoftDelete(-2)
+	pairs, err := catalog.QueryIndex(context.Background(), batch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	found := make(map[Pair]bool)
+	for p := range pairs {
+		found[p] = true
+	}
+	if !found[Pair{0, "0"}] || found[Pair{-1, "1"}] || found[Pair{-2, "2"}] {
+		t.Errorf("unexpected pairs %v", found)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	pairs, err = catalog.QueryIndex(ctx, batch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cancel()
+	for range pairs {
+	}
+
+	batch.signatures[-3] = randomSignature(8, 3)
+	if _, err := catalog.QueryIndex(context.Background(), batch); err == nil {
+		t.Error("expected an error for a short signature")
+	}
+}
@@ -0,0 +1,59 @@
+package minhashlsh
+
+// SizeEstimate is the estimated Jaccard similarity of a candidate with a
+// query, with the estimated sizes of the intersection and union of their
+// sets derived from the cardinalities of the sets.
+type SizeEstimate struct {
+	Key          interface{}
+	Similarity   float64
+	Intersection float64
+	Union        float64
+}
+
+// SetCardinality sets the cardinality of the set of a key, such as its
+// exact number of tokens or one estimated by a HyperLogLog, for
+// QuerySizes. Cardinalities are not saved with the index.
+func (f *MinhashLSH) SetCardinality(key interface{}, n float64) {
+	if f.cardinalities == nil {
+		f.cardinalities = make(map[interface{}]float64)
+	}
+	f.cardinalities[key] = n
+}
+
+// AddWithCardinality adds a key with the signature and the cardinality of
+// its set.
+func (f *MinhashLSH) AddWithCardinality(key interface{}, sig []uint64, n float64) {
+	f.Add(key, sig)
+	f.SetCardinality(key, n)
+}
+
+// QuerySizes returns the candidate keys given the query signature and the
+// cardinality of the query set, with their estimated similarity and the
+// sizes of their intersection and union with the query set, as used to
+// score record matches. With a Jaccard similarity J, the intersection of
+// sets of sizes a and b is J(a+b)/(1+J) and their union (a+b)/(1+J).
+// Candidates without a stored signature or cardinality are skipped.
+// ErrNoSignatures is returned unless the index stores signatures.
+func (f *MinhashLSH) QuerySizes(sig []uint64, n float64) ([]SizeEstimate, error) {
+	if f.signatures == nil {
+		return nil, ErrNoSignatures
+	}
+	words := f.HashValueSize / wordSize(f.HashValueSize)
+	results := make([]SizeEstimate, 0)
+	for _, key := range f.queryVerified(sig) {
+		stored, exist := f.signatures[key]
+		size, known := f.cardinalities[key]
+		if !exist || !known {
+			continue
+		}
+		equal, count := countEqual(sig, stored, words)
+		if count == 0 {
+			continue
+		}
+		e := SizeEstimate{Key: key, Similarity: float64(equal) / float64(count)}
+		e.Union = (n + size) / (1 + e.Similarity)
+		e.Intersection = e.Similarity * e.Union
+		results = append(results, e)
+	}
+	return results, nil
+}
@@ -0,0 +1,44 @@
+package minhashlsh
+
+import (
+	"math"
+	"testing"
+)
+
+func Test_QuerySizes(t *testing.T) {
+	f := NewMinhashLSH16(64, 0.3, 0)
+	if _, err := f.QuerySizes(randomSignature(64, 1), 10); err != ErrNoSignatures {
+		t.Errorf("expected ErrNoSignatures, got %v", err)
+	}
+	f = NewMinhashLSH16(64, 0.3, 0, WithSignatureStorage())
+	tokens := randomTokens(200, 1)
+	sketch := func(tokens [][]byte) []uint64 {
+		mh := NewMinhash(1, 64)
+		for _, token := range tokens {
+			mh.Push(token)
+		}
+		return mh.Signature()
+	}
+	// The sets share 100 tokens, of a union of 200.
+	f.AddWithCardinality("a", sketch(tokens[:150]), 150)
+	f.Add("unknown", sketch(tokens[:150]))
+	f.Index()
+	results, err := f.QuerySizes(sketch(tokens[50:]), 150)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0].Key != "a" {
+		t.Fatalf("unexpected results %v", results)
+	}
+	e := results[0]
+	if math.Abs(e.Intersection-100) > 20 || math.Abs(e.Union-200) > 20 {
+		t.Errorf("expected an intersection of about 100 and a union of about 200, got %+v", e)
+	}
+	if math.Abs(e.Intersection+e.Union-300) > 1e-9 {
+		t.Errorf("intersection and union sizes inconsistent with the set sizes: %+v", e)
+	}
+	f.Remove("a")
+	if _, known := f.cardinalities["a"]; known {
+		t.Error("cardinality of a removed key kept")
+	}
+}
@@ -0,0 +1,126 @@
+package minhashlsh
+
+import "fmt"
+
+// WithMinhashSeed sets the seed of the Minhash used by AddSet and QuerySet
+// to sketch raw tokens. The seed is not saved with the index; on a loaded
+// index, set it again with WithMinhashSeed(seed)(index).
+func WithMinhashSeed(seed int64) Option {
+	return func(f *MinhashLSH) {
+		f.minhashSeed = &seed
+	}
+}
+
+// sketch computes the signature of the tokens with the Minhash of the index.
+func (f *MinhashLSH) sketch(tokens [][]byte) []uint64 {
+	if f.minhashSeed == nil {
+		panic("minhashlsh: sketching tokens requires WithMinhashSeed")
+	}
+	if f.HashValueSize == 16 {
+		mh := NewMinhash128(*f.minhashSeed, f.SignatureSize()/2)
+		for _, token := range tokens {
+			mh.Push(token)
+		}
+		return mh.Signature()
+	}
+	mh := NewMinhash(*f.minhashSeed, f.SignatureSize())
+	for _, token := range tokens {
+		mh.Push(token)
+	}
+	return mh.Signature()
+}
+
+// AddSet adds a key with the signature of a set of raw tokens,
+// computed with the Minhash seed of the index.
+func (f *MinhashLSH) AddSet(key interface{}, tokens [][]byte) {
+	f.Add(key, f.sketch(tokens))
+}
+
+// QuerySet returns candidate keys given a set of raw tokens, sketched with
+// the Minhash seed of the index. It panics unless the index was created
+// with WithMinhashSeed.
+func (f *MinhashLSH) QuerySet(tokens [][]byte) []interface{} {
+	return f.Query(f.sketch(tokens))
+}
+
+// SetIndex combines a MinhashLSH with the Minhash parameters used to sketch
+// its sets, so every signature in the index is produced with compatible
+// parameters.
+type SetIndex struct {
+	seed    int64
+	numHash int
+	lsh     *MinhashLSH
+}
+
+// NewSetIndex creates an index of sets sketched by Minhash with the given
+// seed and number of hash functions, using 32-bit hash values.
+func NewSetIndex(seed int64, numHash int, threshold float64, initSize int, opts ...Option) *SetIndex {
+	opts = append(opts[:len(opts):len(opts)], WithMinhashSeed(seed))
+	return &SetIndex{seed, numHash, NewMinhashLSH(numHash, threshold, initSize, opts...)}
+}
+
+// NewSetIndexFrom wraps an index, such as a loaded one, whose signatures
+// were computed by Minhash, or Minhash128 for 128-bit hash values, with the
+// given seed and number of hash functions.
+func NewSetIndexFrom(seed int64, numHash int, lsh *MinhashLSH) (*SetIndex, error) {
+	size := numHash
+	if lsh.HashValueSize == 16 {
+		// Each 128-bit hash value takes two signature values.
+		size *= 2
+	}
+	if lsh.SignatureSize() > size {
+		return nil, fmt.Errorf("minhashlsh: index uses %d signature values, more than %d",
+			lsh.SignatureSize(), size)
+	}
+	WithMinhashSeed(seed)(lsh)
+	return &SetIndex{seed, numHash, lsh}, nil
+}
+
+// Seed returns the Minhash seed.
+func (s *SetIndex) Seed() int64 {
+	return s.seed
+}
+
+// NumHash returns the number of Minhash hash functions.
+func (s *SetIndex) NumHash() int {
+	return s.numHash
+}
+
+// LSH returns the underlying index, to save it or query it with signatures.
+// Keys added to it directly must use signatures computed by Signature.
+func (s *SetIndex) LSH() *MinhashLSH {
+	return s.lsh
+}
+
+// Signature computes the full Minhash signature of a set of tokens,
+// with Minhash128 if the index uses 128-bit hash values.
+func (s *SetIndex) Signature(tokens [][]byte) []uint64 {
+	if s.lsh.HashValueSize == 16 {
+		mh := NewMinhash128(s.seed, s.numHash)
+		for _, token := range tokens {
+			mh.Push(token)
+		}
+		return mh.Signature()
+	}
+	mh := NewMinhash(s.seed, s.numHash)
+	for _, token := range tokens {
+		mh.Push(token)
+	}
+	return mh.Signature()
+}
+
+// AddSet adds a key with the signature of a set of tokens.
+// The key won't be searchable until Index is called.
+func (s *SetIndex) AddSet(key interface{}, tokens [][]byte) {
+	s.lsh.AddSet(key, tokens)
+}
+
+// Index makes all the keys added searchable.
+func (s *SetIndex) Index() {
+	s.lsh.Index()
+}
+
+// QuerySet returns candidate keys given a set of tokens.
+func (s *SetIndex) QuerySet(tokens [][]byte) []interface{} {
+	return s.lsh.QuerySet(tokens)
+}
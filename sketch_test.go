@@ -0,0 +1,94 @@
+package minhashlsh
+
+import "testing"
+
+func Test_QuerySet(t *testing.T) {
+	words := [][]byte{[]byte("hello"), []byte("world"), []byte("minhash"),
+		[]byte("one"), []byte("two"), []byte("three"), []byte("four")}
+	f := NewMinhashLSH16(128, 0.5, 1, WithMinhashSeed(42))
+	f.AddSet("s1", words)
+	f.Index()
+	mh := NewMinhash(42, 128)
+	for _, w := range words {
+		mh.Push(w)
+	}
+	results := f.Query(mh.Signature())
+	if len(results) != 1 || results[0] != "s1" {
+		t.Errorf("signature query expected s1, got %v", results)
+	}
+	results = f.QuerySet(words[1:])
+	if len(results) != 1 || results[0] != "s1" {
+		t.Errorf("set query expected s1, got %v", results)
+	}
+}
+
+func Test_SetIndex(t *testing.T) {
+	words := [][]byte{[]byte("one"), []byte("two"), []byte("three"), []byte("four"),
+		[]byte("five"), []byte("six"), []byte("seven"), []byte("eight")}
+	s := NewSetIndex(7, 128, 0.5, 0)
+	s.AddSet("s1", words)
+	s.Index()
+	if results := s.QuerySet(words[1:]); len(results) != 1 || results[0] != "s1" {
+		t.Errorf("expected s1, got %v", results)
+	}
+	if results := s.LSH().Query(s.Signature(words)); len(results) != 1 {
+		t.Errorf("expected s1 querying with the signature, got %v", results)
+	}
+
+	wrapped, err := NewSetIndexFrom(7, 128, s.LSH())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results := wrapped.QuerySet(words); len(results) != 1 {
+		t.Errorf("expected s1 from the wrapped index, got %v", results)
+	}
+	if _, err := NewSetIndexFrom(7, 2, s.LSH()); err == nil {
+		t.Error("expected an error wrapping with too few hash functions")
+	}
+}
+
+func Test_QuerySetBands128(t *testing.T) {
+	words := [][]byte{[]byte("one"), []byte("two"), []byte("three"), []byte("four")}
+	f := NewMinhashLSH128(32, 0.5, 0, WithMinhashSeed(3))
+	f.AddSet("s1", words)
+	f.Index()
+	filename, cleanup := tempFilename(t)
+	defer cleanup()
+	if err := f.Save(filename); err != nil {
+		t.Fatal(err)
+	}
+	g, err := Load(filename, WithBands(f.L-1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	WithMinhashSeed(3)(g)
+	if results := g.QuerySet(words); len(results) != 1 || results[0] != "s1" {
+		t.Errorf("expected s1 from the partial index, got %v", results)
+	}
+	g.AddSet("s2", words[1:])
+	g.Index()
+	if !contains(g.QuerySet(words[1:]), "s2") {
+		t.Error("set added to the partial index not found")
+	}
+}
+
+func Test_SetIndex128(t *testing.T) {
+	words := [][]byte{[]byte("one"), []byte("two"), []byte("three"), []byte("four")}
+	f := NewMinhashLSH128(32, 0.5, 0)
+	s, err := NewSetIndexFrom(5, 32, f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.AddSet("s1", words)
+	s.Index()
+	sig := s.Signature(words)
+	if len(sig) != f.SignatureSize() {
+		t.Fatalf("expected a signature of %d values, got %d", f.SignatureSize(), len(sig))
+	}
+	if results := f.Query(sig); len(results) != 1 || results[0] != "s1" {
+		t.Errorf("expected s1 querying with the signature, got %v", results)
+	}
+	if _, err := NewSetIndexFrom(5, 16, f); err == nil {
+		t.Error("expected an error wrapping with too few hash functions")
+	}
+}
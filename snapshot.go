@@ -0,0 +1,218 @@
+package minhashlsh
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// SnapshotIndex lets queries run concurrently with Add, Remove, Index and
+// Compact. Queries use the snapshot of the index published by the last call
+// to Index or Compact, which is never modified: instead of sorting the hash
+// tables in place, Index merges the added entries into new tables and swaps
+// the snapshot atomically. Snapshots still used by readers are kept alive by
+// the garbage collector, so tables can be rewritten while queries are in
+// flight. Writers are serialized by a mutex.
+type SnapshotIndex struct {
+	mu       sync.Mutex
+	lsh      *MinhashLSH
+	snapshot atomic.Value
+}
+
+// NewSnapshotIndex wraps lsh, which must not be used directly afterwards.
+// A lazily loaded index is materialized first. Key positions are not
+// supported, as they modify the hash tables in place.
+func NewSnapshotIndex(lsh *MinhashLSH) (*SnapshotIndex, error) {
+	if err := prepareSnapshots(lsh); err != nil {
+		return nil, err
+	}
+	s := &SnapshotIndex{lsh: lsh}
+	s.Index()
+	return s, nil
+}
+
+func prepareSnapshots(lsh *MinhashLSH) error {
+	if err := lsh.Materialize(); err != nil {
+		return err
+	}
+	lsh.lazy = nil
+	lsh.positions = nil
+	return nil
+}
+
+// Replace atomically swaps in lsh in place of the wrapped index, such as
+// an index rebuilt offline, indexing it and publishing its snapshot.
+// Queries in flight complete on the previous snapshot. As in
+// NewSnapshotIndex, lsh must not be used directly afterwards.
+func (s *SnapshotIndex) Replace(lsh *MinhashLSH) error {
+	if err := prepareSnapshots(lsh); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lsh = lsh
+	s.index()
+	return nil
+}
+
+// Reload loads the index file written by Save and swaps it in with Replace.
+// The wrapped index is kept if loading fails.
+func (s *SnapshotIndex) Reload(filename string, opts ...PersistOption) error {
+	lsh, err := Load(filename, opts...)
+	if err != nil {
+		return err
+	}
+	return s.Replace(lsh)
+}
+
+// Snapshot returns the index published by the last call to Index or
+// Compact. It must not be modified.
+func (s *SnapshotIndex) Snapshot() *MinhashLSH {
+	return s.snapshot.Load().(*MinhashLSH)
+}
+
+// Query returns candidate keys given the query signature from the current
+// snapshot.
+func (s *SnapshotIndex) Query(sig []uint64) []interface{} {
+	return s.Snapshot().Query(sig)
+}
+
+// Add a key with its signature. The key won't be searchable until Index
+// is called.
+func (s *SnapshotIndex) Add(key interface{}, sig []uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lsh.Add(key, sig)
+}
+
+// Remove deletes a key. It is still returned by queries until Index or
+// Compact is called.
+func (s *SnapshotIndex) Remove(key interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lsh.Remove(key)
+}
+
+// Index makes all the keys added searchable, publishing a new snapshot.
+func (s *SnapshotIndex) Index() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.index()
+}
+
+func (s *SnapshotIndex) index() {
+	f := s.lsh
+	span := startSpan(f.tracer, context.Background(), "minhashlsh.Index")
+	defer span.End()
+	start := time.Now()
+	var added int
+	if f.L > 0 {
+		added = len(f.HashTables[0]) - f.NumIndexedKeys
+	}
+	if len(f.stale) > 0 {
+		// The entries of re-added keys are purged in place.
+		f.copyTables()
+		f.dropStale()
+	}
+	f.forEachBand(func(i int) {
+		f.HashTables[i] = mergeAdded(f.HashTables[i], f.NumIndexedKeys)
+	})
+	if f.L > 0 {
+		f.indexed(span, start, added)
+	}
+	s.publish()
+}
+
+// Compact rewrites the hash tables without the removed keys,
+// publishing a new snapshot.
+func (s *SnapshotIndex) Compact() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.lsh.removed) > 0 || len(s.lsh.stale) > 0 {
+		s.lsh.copyTables()
+		s.lsh.Compact()
+	}
+	s.publish()
+}
+
+// copyTables gives the index its own copy of the hash tables, so they can be
+// modified in place without affecting the snapshots.
+func (f *MinhashLSH) copyTables() {
+	for i, table := range f.HashTables {
+		f.HashTables[i] = append(make(hashTable, 0, cap(table)), table...)
+	}
+}
+
+// mergeAdded returns a new table with the entries added after the first
+// numIndexed sorted entries merged into them.
+func mergeAdded(table hashTable, numIndexed int) hashTable {
+	added := append(hashTable(nil), table[numIndexed:]...)
+	sortHashTable(added)
+	indexed := table[:numIndexed]
+	merged := make(hashTable, 0, cap(table))
+	i, j := 0, 0
+	for i < len(indexed) && j < len(added) {
+		if added[j].HashKey < indexed[i].HashKey {
+			merged = append(merged, added[j])
+			j++
+		} else {
+			merged = append(merged, indexed[i])
+			i++
+		}
+	}
+	merged = append(merged, indexed[i:]...)
+	return append(merged, added[j:]...)
+}
+
+// publish makes a read-only copy of the index the current snapshot.
+// The hash tables are shared, as the index only appends entries past
+// the indexed ones, which snapshots do not read.
+func (s *SnapshotIndex) publish() {
+	f := s.lsh
+	snapshot := *f
+	snapshot.HashTables = append([]hashTable(nil), f.HashTables...)
+	snapshot.removed = copyKeySet(f.removed)
+	snapshot.softDeleted = copyKeySet(f.softDeleted)
+	if f.stale != nil {
+		snapshot.stale = make(map[interface{}]int, len(f.stale))
+		for key, n := range f.stale {
+			snapshot.stale[key] = n
+		}
+	}
+	if f.signatures != nil {
+		snapshot.signatures = make(map[interface{}][]uint64, len(f.signatures))
+		for key, sig := range f.signatures {
+			snapshot.signatures[key] = sig
+		}
+	}
+	latest := f.latest()
+	snapshot.latestVersions = make(map[interface{}]int64, len(latest))
+	for key, version := range latest {
+		snapshot.latestVersions[key] = version
+	}
+	if f.cardinalities != nil {
+		snapshot.cardinalities = make(map[interface{}]float64, len(f.cardinalities))
+		for key, n := range f.cardinalities {
+			snapshot.cardinalities[key] = n
+		}
+	}
+	if f.cache != nil {
+		snapshot.cache = newQueryCache(f.cache.size)
+	}
+	if f.negCache != nil {
+		snapshot.negCache = newNegativeCache(len(f.negCache.ring))
+	}
+	s.snapshot.Store(&snapshot)
+}
+
+func copyKeySet(set map[interface{}]struct{}) map[interface{}]struct{} {
+	if set == nil {
+		return nil
+	}
+	c := make(map[interface{}]struct{}, len(set))
+	for key := range set {
+		c[key] = struct{}{}
+	}
+	return c
+}
@@ -0,0 +1,122 @@
+package minhashlsh
+
+import (
+	"sync"
+	"testing"
+)
+
+func Test_SnapshotIndex(t *testing.T) {
+	s, err := NewSnapshotIndex(NewMinhashLSH16(64, 0.5, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var wg sync.WaitGroup
+	done := make(chan struct{})
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			snapshot := s.Snapshot()
+			n := snapshot.NumIndexedKeys
+			for i := 0; i < n; i++ {
+				// Key 3 is removed and added again.
+				if i == 3 {
+					continue
+				}
+				if !contains(snapshot.Query(randomSignature(64, int64(i))), i) {
+					t.Errorf("key %d missing from a snapshot of %d keys", i, n)
+					return
+				}
+			}
+		}
+	}()
+	for i := 0; i < 500; i++ {
+		s.Add(i, randomSignature(64, int64(i)))
+		if i%50 == 49 {
+			s.Index()
+		}
+	}
+	s.Remove(3)
+	s.Compact()
+	s.Add(3, randomSignature(64, 3))
+	s.Index()
+	close(done)
+	wg.Wait()
+
+	snapshot := s.Snapshot()
+	if snapshot.NumIndexedKeys != 500 {
+		t.Errorf("expected 500 indexed keys, got %d", snapshot.NumIndexedKeys)
+	}
+	for i, table := range snapshot.HashTables {
+		for j := 1; j < len(table); j++ {
+			if table[j-1].HashKey > table[j].HashKey {
+				t.Fatalf("band %d not sorted", i)
+			}
+		}
+	}
+}
+
+func Test_SnapshotIndexReload(t *testing.T) {
+	filename, cleanup := tempFilename(t)
+	defer cleanup()
+	rebuilt := testIndex(10)
+	if err := rebuilt.Save(filename); err != nil {
+		t.Fatal(err)
+	}
+	s, err := NewSnapshotIndex(NewMinhashLSH16(64, 0.5, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	old := s.Snapshot()
+	if err := s.Reload(filename); err != nil {
+		t.Fatal(err)
+	}
+	if len(old.Query(randomSignature(64, 1))) != 0 {
+		t.Error("the previous snapshot changed")
+	}
+	if !contains(s.Query(randomSignature(64, 1)), "1") {
+		t.Error("key 1 not found in the reloaded index")
+	}
+	if err := s.Reload(filename + ".missing"); err == nil {
+		t.Error("expected an error reloading a missing file")
+	}
+	if s.Snapshot().NumIndexedKeys != 10 {
+		t.Error("expected the index to be kept when reloading fails")
+	}
+}
+
+func Test_SnapshotIndexIndexSteps(t *testing.T) {
+	var events []IndexEvent
+	lsh := NewMinhashLSH16(64, 0.5, 0, WithDeduplication(),
+		WithHooks(Hooks{OnIndex: func(e IndexEvent) { events = append(events, e) }}))
+	sub := lsh.Subscribe(10)
+	s, err := NewSnapshotIndex(lsh)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig := randomSignature(64, 1)
+	s.Add("a", sig)
+	s.Add("a", sig)
+	s.Index()
+	if n := len(s.Snapshot().HashTables[0]); n != 1 {
+		t.Errorf("expected duplicates collapsed to 1 entry, got %d", n)
+	}
+	if len(events) != 2 || events[1].Indexed != 1 {
+		t.Errorf("expected 2 index events ending with 1 indexed entry, got %v", events)
+	}
+	sub.Close()
+	var indexes int
+	for change := range sub.C {
+		if change.Type == ChangeIndex {
+			indexes++
+		}
+	}
+	if indexes != 2 {
+		t.Errorf("expected 2 index changes, got %d", indexes)
+	}
+}
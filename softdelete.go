@@ -0,0 +1,98 @@
+package minhashlsh
+
+// SoftDelete hides a key from query results while keeping its entries,
+// so it can be restored cheaply with Undelete, or removed for good with
+// Remove. Like removed keys, soft-deleted keys are left out when the
+// index is saved.
+func (f *MinhashLSH) SoftDelete(key interface{}) {
+	f.invalidateCache()
+	if f.softDeleted == nil {
+		f.softDeleted = make(map[interface{}]struct{})
+	}
+	f.softDeleted[key] = struct{}{}
+	f.versionVisibilityChanged(key)
+	f.publish(ChangeSoftDelete, key, nil)
+}
+
+// Undelete restores a soft-deleted key.
+func (f *MinhashLSH) Undelete(key interface{}) {
+	f.invalidateCache()
+	delete(f.softDeleted, key)
+	f.versionVisibilityChanged(key)
+	f.publish(ChangeUndelete, key, nil)
+}
+
+// IsSoftDeleted reports whether a key is soft-deleted.
+func (f *MinhashLSH) IsSoftDeleted(key interface{}) bool {
+	_, deleted := f.softDeleted[key]
+	return deleted
+}
+
+// SoftDeleted returns the soft-deleted keys.
+func (f *MinhashLSH) SoftDeleted() []interface{} {
+	keys := make([]interface{}, 0, len(f.softDeleted))
+	for key := range f.softDeleted {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// isHidden reports whether a key is removed or soft-deleted,
+// so it must not be returned by queries. The indexed entries of keys
+// added again after being removed are their previous ones, also hidden.
+func (f *MinhashLSH) isHidden(key interface{}) bool {
+	if len(f.softDeleted) > 0 {
+		if _, deleted := f.softDeleted[key]; deleted {
+			return true
+		}
+	}
+	if len(f.stale) > 0 {
+		if _, stale := f.stale[key]; stale {
+			return true
+		}
+	}
+	return f.isRemoved(key)
+}
+
+// isHiddenAt is isHidden for the entry of a key at position j of a hash
+// table, indexed or not.
+func (f *MinhashLSH) isHiddenAt(key interface{}, j int) bool {
+	if len(f.softDeleted) > 0 {
+		if _, deleted := f.softDeleted[key]; deleted {
+			return true
+		}
+	}
+	return f.isStale(key, j) || f.isRemoved(key)
+}
+
+// hiddenFilter returns isHiddenAt if the index has hidden entries, which
+// are left out when the index is saved, or nil otherwise.
+func (f *MinhashLSH) hiddenFilter() func(key interface{}, j int) bool {
+	if len(f.removed) == 0 && f.numRemovedEntries == 0 && len(f.softDeleted) == 0 && len(f.stale) == 0 {
+		return nil
+	}
+	return f.isHiddenAt
+}
+
+// numVisibleIndexed returns the number of indexed entries per band not
+// hidden by hidden, which may be nil.
+func (f *MinhashLSH) numVisibleIndexed(hidden func(key interface{}, j int) bool) int {
+	if hidden == nil {
+		return f.NumIndexedKeys
+	}
+	n := 0
+	for j, e := range f.HashTables[0][:f.NumIndexedKeys] {
+		if !hidden(e.Key, j) {
+			n++
+		}
+	}
+	return n
+}
+
+// versionVisibilityChanged forgets the latest versions when a version
+// is hidden or restored.
+func (f *MinhashLSH) versionVisibilityChanged(key interface{}) {
+	if _, versioned := key.(VersionedKey); versioned {
+		f.latestVersions = nil
+	}
+}
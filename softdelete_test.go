@@ -0,0 +1,36 @@
+package minhashlsh
+
+import "testing"
+
+func contains(keys []interface{}, key interface{}) bool {
+	for _, k := range keys {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+func Test_SoftDelete(t *testing.T) {
+	f := testIndex(10)
+	sig := randomSignature(64, 4)
+	f.SoftDelete("4")
+	if contains(f.Query(sig), "4") {
+		t.Error("soft-deleted key returned by query")
+	}
+	if !f.IsSoftDeleted("4") || len(f.SoftDeleted()) != 1 {
+		t.Error("key not reported as soft-deleted")
+	}
+	f.Compact()
+	f.Undelete("4")
+	if !contains(f.Query(sig), "4") {
+		t.Error("undeleted key not returned by query")
+	}
+
+	f.SoftDelete("4")
+	f.Remove("4")
+	f.Undelete("4")
+	if contains(f.Query(sig), "4") {
+		t.Error("removed key restored by Undelete")
+	}
+}
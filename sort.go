@@ -0,0 +1,58 @@
+package minhashlsh
+
+import "sort"
+
+// radixSortThreshold is the table size below which a comparison sort
+// is cheaper than the radix passes.
+const radixSortThreshold = 256
+
+// sortHashTable sorts the hash table by hash keys.
+// Band hash keys are fixed-width byte strings, so an LSD radix sort is used,
+// falling back to sort.Sort for small tables or keys of varying width.
+func sortHashTable(h hashTable) {
+	if len(h) < radixSortThreshold {
+		sort.Sort(h)
+		return
+	}
+	width := len(h[0].HashKey)
+	for i := range h {
+		if len(h[i].HashKey) != width {
+			sort.Sort(h)
+			return
+		}
+	}
+	radixSort(h, make(hashTable, len(h)), width)
+}
+
+// radixSort performs an LSD radix sort of h on hash keys of the given width,
+// using buf as the scratch space. buf must have the same length as h.
+func radixSort(h, buf hashTable, width int) {
+	src, dst := h, buf
+	var count [256]int
+	for pos := width - 1; pos >= 0; pos-- {
+		for i := range count {
+			count[i] = 0
+		}
+		for i := range src {
+			count[src[i].HashKey[pos]]++
+		}
+		// Skip the pass if all keys share the same byte at this position.
+		if count[src[0].HashKey[pos]] == len(src) {
+			continue
+		}
+		offset := 0
+		for i, c := range count {
+			count[i] = offset
+			offset += c
+		}
+		for i := range src {
+			b := src[i].HashKey[pos]
+			dst[count[b]] = src[i]
+			count[b]++
+		}
+		src, dst = dst, src
+	}
+	if &src[0] != &h[0] {
+		copy(h, src)
+	}
+}
@@ -0,0 +1,32 @@
+package minhashlsh
+
+import (
+	"sort"
+	"strconv"
+	"testing"
+)
+
+func Test_RadixSort(t *testing.T) {
+	f := hashKeyFuncGen(2)
+	h := make(hashTable, 1000)
+	for i := range h {
+		// Use a small range of values so that duplicate hash keys exist.
+		sig := randomSignature(4, int64(i%300))
+		h[i] = entry{f(sig), strconv.Itoa(i)}
+	}
+	sortHashTable(h)
+	if !sort.IsSorted(h) {
+		t.Fatal("hash table is not sorted")
+	}
+}
+
+func Test_RadixSortVaryingWidth(t *testing.T) {
+	h := make(hashTable, 2*radixSortThreshold)
+	for i := range h {
+		h[i] = entry{strconv.Itoa(len(h) - i), i}
+	}
+	sortHashTable(h)
+	if !sort.IsSorted(h) {
+		t.Fatal("hash table is not sorted")
+	}
+}
@@ -0,0 +1,214 @@
+package minhashlsh
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+)
+
+var sqlIdentifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// SQLStore stores an index as (band, hashkey, key) rows in a SQLite
+// database, with an index on (band, hashkey) so the stored index can be
+// queried directly. The database is opened by the caller using any
+// SQLite driver, e.g. github.com/mattn/go-sqlite3.
+//
+// Keys must be of type string, float64 or an integer type.
+// Integer keys are loaded as int64.
+type SQLStore struct {
+	db    *sql.DB
+	table string
+}
+
+// NewSQLStore creates the tables prefixed with the given name unless they
+// exist already.
+func NewSQLStore(db *sql.DB, name string) (*SQLStore, error) {
+	if !sqlIdentifier.MatchString(name) {
+		return nil, fmt.Errorf("minhashlsh: invalid table name %q", name)
+	}
+	s := &SQLStore{db, name}
+	statements := []string{
+		`CREATE TABLE IF NOT EXISTS ` + s.table + `_params (
+			k INTEGER NOT NULL,
+			l INTEGER NOT NULL,
+			hash_value_size INTEGER NOT NULL,
+			num_indexed_keys INTEGER NOT NULL)`,
+		// pos is the position of the entry in its band's hash table.
+		`CREATE TABLE IF NOT EXISTS ` + s.table + ` (
+			band INTEGER NOT NULL,
+			pos INTEGER NOT NULL,
+			hashkey BLOB NOT NULL,
+			key NOT NULL,
+			PRIMARY KEY (band, pos))`,
+		`CREATE INDEX IF NOT EXISTS ` + s.table + `_band_hashkey ON ` +
+			s.table + ` (band, hashkey)`,
+	}
+	for _, stmt := range statements {
+		if _, err := db.Exec(stmt); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// Save replaces the stored index with the given one in a single transaction.
+// As with the file format, removed and soft-deleted keys are left out.
+func (s *SQLStore) Save(f *MinhashLSH) error {
+	if f.salt != 0 {
+		return errors.New("minhashlsh: salted indexes cannot be stored in SQL")
+	}
+	if f.trim != TrimLowBits {
+		return errors.New("minhashlsh: indexes with a trim policy cannot be stored in SQL")
+	}
+	if f.bands != nil {
+		return errPartialIndex
+	}
+	if err := f.Materialize(); err != nil {
+		return err
+	}
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	if err := s.save(tx, f); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *SQLStore) save(tx *sql.Tx, f *MinhashLSH) error {
+	hidden := f.hiddenFilter()
+	if _, err := tx.Exec(`DELETE FROM ` + s.table + `_params`); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM ` + s.table); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`INSERT INTO `+s.table+`_params
+		(k, l, hash_value_size, num_indexed_keys) VALUES (?, ?, ?, ?)`,
+		f.K, f.L, f.HashValueSize, f.numVisibleIndexed(hidden)); err != nil {
+		return err
+	}
+	stmt, err := tx.Prepare(`INSERT INTO ` + s.table +
+		` (band, pos, hashkey, key) VALUES (?, ?, ?, ?)`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	for band, table := range f.HashTables {
+		pos := 0
+		for j, e := range table {
+			if hidden != nil && hidden(e.Key, j) {
+				continue
+			}
+			if _, err := stmt.Exec(band, pos, []byte(e.HashKey), e.Key); err != nil {
+				return err
+			}
+			pos++
+		}
+	}
+	return nil
+}
+
+// Load reads the stored index.
+func (s *SQLStore) Load() (*MinhashLSH, error) {
+	f, err := s.params()
+	if err != nil {
+		return nil, err
+	}
+	rows, err := s.db.Query(`SELECT band, hashkey, key FROM ` + s.table +
+		` ORDER BY band, pos`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var band int
+		var hashKey []byte
+		var key interface{}
+		if err := rows.Scan(&band, &hashKey, &key); err != nil {
+			return nil, err
+		}
+		if band < 0 || band >= f.L {
+			return nil, fmt.Errorf("minhashlsh: stored band %d out of range", band)
+		}
+		f.HashTables[band] = append(f.HashTables[band], entry{string(hashKey), sqlKey(key)})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Query returns candidate keys given the query signature,
+// using the stored index directly.
+func (s *SQLStore) Query(sig []uint64) ([]interface{}, error) {
+	f, err := s.params()
+	if err != nil {
+		return nil, err
+	}
+	stmt, err := s.db.Prepare(`SELECT key FROM ` + s.table +
+		` WHERE band = ? AND hashkey = ? AND pos < ?`)
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+	seen := make(map[interface{}]bool)
+	results := make([]interface{}, 0)
+	for band, hashKey := range f.hashKeys(sig) {
+		rows, err := stmt.Query(band, []byte(hashKey), f.NumIndexedKeys)
+		if err != nil {
+			return nil, err
+		}
+		for rows.Next() {
+			var key interface{}
+			if err := rows.Scan(&key); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			key = sqlKey(key)
+			if !seen[key] {
+				seen[key] = true
+				results = append(results, key)
+			}
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+	}
+	return results, nil
+}
+
+// params returns an empty index with the stored parameters.
+func (s *SQLStore) params() (*MinhashLSH, error) {
+	var k, l, hashValueSize, numIndexedKeys int
+	err := s.db.QueryRow(`SELECT k, l, hash_value_size, num_indexed_keys FROM `+
+		s.table+`_params`).Scan(&k, &l, &hashValueSize, &numIndexedKeys)
+	if err == sql.ErrNoRows {
+		return nil, errors.New("minhashlsh: no index stored in " + s.table)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &MinhashLSH{
+		K:              k,
+		L:              l,
+		HashValueSize:  hashValueSize,
+		HashTables:     make([]hashTable, l),
+		HashKeyFunc:    hashKeyFuncGen(hashValueSize),
+		appendKey:      appendKeyFuncGen(hashValueSize),
+		NumIndexedKeys: numIndexedKeys,
+	}, nil
+}
+
+// sqlKey converts byte slice keys returned by drivers for text columns to
+// strings, as slices cannot be used as map keys in query results.
+func sqlKey(key interface{}) interface{} {
+	if b, ok := key.([]byte); ok {
+		return string(b)
+	}
+	return key
+}
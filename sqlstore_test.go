@@ -0,0 +1,285 @@
+package minhashlsh
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeSQL is a database/sql driver keeping tables in memory, understanding
+// only the statements of SQLStore, so the store is tested without a SQLite
+// driver.
+type fakeSQL struct {
+	mu     sync.Mutex
+	params map[string][]driver.Value
+	rows   map[string][]fakeRow
+}
+
+type fakeRow struct {
+	band, pos int64
+	hashKey   []byte
+	key       driver.Value
+}
+
+var fakeSQLDB = &fakeSQL{params: make(map[string][]driver.Value), rows: make(map[string][]fakeRow)}
+
+func init() {
+	sql.Register("minhashlsh-fake", fakeSQLDB)
+}
+
+func (d *fakeSQL) Open(name string) (driver.Conn, error) { return fakeConn{d}, nil }
+
+type fakeConn struct{ db *fakeSQL }
+
+func (c fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return fakeStmt{c.db, strings.Join(strings.Fields(query), " ")}, nil
+}
+
+func (c fakeConn) Close() error { return nil }
+
+// Begin snapshots the tables, restored by Rollback.
+func (c fakeConn) Begin() (driver.Tx, error) {
+	c.db.mu.Lock()
+	defer c.db.mu.Unlock()
+	tx := &fakeTx{db: c.db, params: make(map[string][]driver.Value), rows: make(map[string][]fakeRow)}
+	for name, p := range c.db.params {
+		tx.params[name] = p
+	}
+	for name, rows := range c.db.rows {
+		tx.rows[name] = append([]fakeRow(nil), rows...)
+	}
+	return tx, nil
+}
+
+type fakeTx struct {
+	db     *fakeSQL
+	params map[string][]driver.Value
+	rows   map[string][]fakeRow
+}
+
+func (tx *fakeTx) Commit() error { return nil }
+
+func (tx *fakeTx) Rollback() error {
+	tx.db.mu.Lock()
+	defer tx.db.mu.Unlock()
+	tx.db.params, tx.db.rows = tx.params, tx.rows
+	return nil
+}
+
+type fakeRowsByPos []fakeRow
+
+func (r fakeRowsByPos) Len() int      { return len(r) }
+func (r fakeRowsByPos) Swap(i, j int) { r[i], r[j] = r[j], r[i] }
+func (r fakeRowsByPos) Less(i, j int) bool {
+	if r[i].band != r[j].band {
+		return r[i].band < r[j].band
+	}
+	return r[i].pos < r[j].pos
+}
+
+type fakeStmt struct {
+	db    *fakeSQL
+	query string
+}
+
+var (
+	fakeDeleteParams = regexp.MustCompile(`^DELETE FROM (\w+)_params$`)
+	fakeDelete       = regexp.MustCompile(`^DELETE FROM (\w+)$`)
+	fakeInsertParams = regexp.MustCompile(`^INSERT INTO (\w+)_params `)
+	fakeInsert       = regexp.MustCompile(`^INSERT INTO (\w+) \(band, pos, hashkey, key\)`)
+	fakeSelectParams = regexp.MustCompile(`^SELECT k, l, hash_value_size, num_indexed_keys FROM (\w+)_params$`)
+	fakeSelectAll    = regexp.MustCompile(`^SELECT band, hashkey, key FROM (\w+) ORDER BY band, pos$`)
+	fakeSelectBucket = regexp.MustCompile(`^SELECT key FROM (\w+) WHERE band = \? AND hashkey = \? AND pos < \?$`)
+)
+
+func (s fakeStmt) Close() error  { return nil }
+func (s fakeStmt) NumInput() int { return -1 }
+
+func (s fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+	if strings.HasPrefix(s.query, "CREATE ") {
+		return driver.RowsAffected(0), nil
+	}
+	if m := fakeDeleteParams.FindStringSubmatch(s.query); m != nil {
+		delete(s.db.params, m[1])
+	} else if m := fakeDelete.FindStringSubmatch(s.query); m != nil {
+		delete(s.db.rows, m[1])
+	} else if m := fakeInsertParams.FindStringSubmatch(s.query); m != nil {
+		s.db.params[m[1]] = append([]driver.Value(nil), args...)
+	} else if m := fakeInsert.FindStringSubmatch(s.query); m != nil {
+		s.db.rows[m[1]] = append(s.db.rows[m[1]], fakeRow{
+			args[0].(int64), args[1].(int64), append([]byte(nil), args[2].([]byte)...), args[3]})
+	} else {
+		return nil, fmt.Errorf("unexpected statement %q", s.query)
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func (s fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+	if m := fakeSelectParams.FindStringSubmatch(s.query); m != nil {
+		rows := &fakeRows{columns: []string{"k", "l", "hash_value_size", "num_indexed_keys"}}
+		if p, exist := s.db.params[m[1]]; exist {
+			rows.values = [][]driver.Value{p}
+		}
+		return rows, nil
+	}
+	if m := fakeSelectAll.FindStringSubmatch(s.query); m != nil {
+		stored := append([]fakeRow(nil), s.db.rows[m[1]]...)
+		sort.Sort(fakeRowsByPos(stored))
+		rows := &fakeRows{columns: []string{"band", "hashkey", "key"}}
+		for _, r := range stored {
+			rows.values = append(rows.values, []driver.Value{r.band, r.hashKey, r.key})
+		}
+		return rows, nil
+	}
+	if m := fakeSelectBucket.FindStringSubmatch(s.query); m != nil {
+		rows := &fakeRows{columns: []string{"key"}}
+		for _, r := range s.db.rows[m[1]] {
+			if r.band == args[0].(int64) && string(r.hashKey) == string(args[1].([]byte)) && r.pos < args[2].(int64) {
+				rows.values = append(rows.values, []driver.Value{r.key})
+			}
+		}
+		return rows, nil
+	}
+	return nil, fmt.Errorf("unexpected query %q", s.query)
+}
+
+type fakeRows struct {
+	columns []string
+	values  [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string { return r.columns }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.values[0])
+	r.values = r.values[1:]
+	return nil
+}
+
+func testSQLStore(t *testing.T, name string) *SQLStore {
+	db, err := sql.Open("minhashlsh-fake", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := NewSQLStore(db, name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func Test_SQLStore(t *testing.T) {
+	s := testSQLStore(t, "roundtrip")
+	f := testIndex(20)
+	if err := s.Save(f); err != nil {
+		t.Fatal(err)
+	}
+	g, err := s.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.K != f.K || g.L != f.L || g.NumIndexedKeys != f.NumIndexedKeys {
+		t.Fatalf("loaded index has different parameters or number of keys")
+	}
+	for i := 0; i < 20; i++ {
+		sig := randomSignature(64, int64(i))
+		key := fmt.Sprint(i)
+		if !contains(g.Query(sig), key) {
+			t.Errorf("key %s not found in the loaded index", key)
+		}
+		results, err := s.Query(sig)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !contains(results, key) {
+			t.Errorf("key %s not found in the stored index", key)
+		}
+	}
+}
+
+func Test_SQLStoreIntKeys(t *testing.T) {
+	s := testSQLStore(t, "intkeys")
+	f := NewMinhashLSH16(64, 0.5, 0)
+	f.Add(7, randomSignature(64, 7))
+	f.Index()
+	if err := s.Save(f); err != nil {
+		t.Fatal(err)
+	}
+	g, err := s.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !contains(g.Query(randomSignature(64, 7)), int64(7)) {
+		t.Error("integer key not loaded as int64")
+	}
+}
+
+func Test_SQLStoreMissing(t *testing.T) {
+	s := testSQLStore(t, "missing")
+	if _, err := s.Load(); err == nil {
+		t.Error("expected an error loading a missing index")
+	}
+	if _, err := s.Query(randomSignature(64, 1)); err == nil {
+		t.Error("expected an error querying a missing index")
+	}
+}
+
+func Test_SQLStoreOverwrite(t *testing.T) {
+	s := testSQLStore(t, "overwrite")
+	if err := s.Save(testIndex(20)); err != nil {
+		t.Fatal(err)
+	}
+	f := testIndex(5)
+	f.Remove("1")
+	f.SoftDelete("2")
+	if err := s.Save(f); err != nil {
+		t.Fatal(err)
+	}
+	g, err := s.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.NumIndexedKeys != 3 || len(g.HashTables[0]) != 3 {
+		t.Fatalf("expected 3 stored keys, got %d", len(g.HashTables[0]))
+	}
+	for _, key := range []string{"1", "2", "10"} {
+		for _, e := range g.HashTables[0] {
+			if e.Key == key {
+				t.Errorf("unexpected stored key %s", key)
+			}
+		}
+	}
+}
+
+func Test_SQLStoreInvalid(t *testing.T) {
+	db, err := sql.Open("minhashlsh-fake", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewSQLStore(db, "bad name"); err == nil {
+		t.Error("expected an error for an invalid table name")
+	}
+	s := testSQLStore(t, "invalid")
+	if err := s.Save(NewMinhashLSH16(64, 0.5, 0, WithSalt(1))); err == nil {
+		t.Error("expected an error saving a salted index")
+	}
+	partial := NewMinhashLSH16(64, 0.5, 0)
+	partial.bands = []int{0}
+	if err := s.Save(partial); err != errPartialIndex {
+		t.Errorf("expected errPartialIndex, got %v", err)
+	}
+}
@@ -0,0 +1,78 @@
+package minhashlsh
+
+import "sync/atomic"
+
+// BucketStats describes the sizes of the buckets of a band, the groups of
+// indexed entries sharing a hash key. Enormous buckets, such as from empty
+// documents all hashing identically, make queries slow.
+type BucketStats struct {
+	Band       int
+	NumBuckets int
+	MaxSize    int
+	// MaxHashKey is the hash key of the largest bucket.
+	MaxHashKey string
+	// Histogram maps bucket sizes to the number of buckets of that size.
+	Histogram map[int]int
+}
+
+// BucketStats returns the distribution of bucket sizes of each band,
+// over the indexed entries.
+func (f *MinhashLSH) BucketStats() []BucketStats {
+	stats := make([]BucketStats, f.L)
+	for i := range stats {
+		s := BucketStats{Band: i, Histogram: make(map[int]int)}
+		table := f.table(i)[:f.NumIndexedKeys]
+		for j := 0; j < len(table); {
+			k := j + 1
+			for k < len(table) && table[k].HashKey == table[j].HashKey {
+				k++
+			}
+			size := k - j
+			s.NumBuckets++
+			s.Histogram[size]++
+			if size > s.MaxSize {
+				s.MaxSize = size
+				s.MaxHashKey = table[j].HashKey
+			}
+			j = k
+		}
+		stats[i] = s
+	}
+	return stats
+}
+
+// bucketCap samples the buckets larger than size at query time.
+type bucketCap struct {
+	// capped is first to be aligned for atomic operations.
+	capped uint64
+	size   int
+}
+
+// WithBucketCap caps the number of entries of a bucket visited by Query to
+// about size, sampling oversized buckets evenly, so a pathological bucket
+// holding a large part of the index does not make every query a full scan.
+// Candidates in oversized buckets may be missed; CappedBuckets counts
+// the buckets sampled.
+func WithBucketCap(size int) Option {
+	return func(f *MinhashLSH) {
+		f.bucketCap = &bucketCap{size: size}
+	}
+}
+
+// step returns the step between the entries visited in a bucket of size n.
+func (c *bucketCap) step(n int) int {
+	if c == nil || c.size <= 0 || n <= c.size {
+		return 1
+	}
+	atomic.AddUint64(&c.capped, 1)
+	return (n + c.size - 1) / c.size
+}
+
+// CappedBuckets returns the number of oversized buckets sampled by queries,
+// see WithBucketCap.
+func (f *MinhashLSH) CappedBuckets() uint64 {
+	if f.bucketCap == nil {
+		return 0
+	}
+	return atomic.LoadUint64(&f.bucketCap.capped)
+}
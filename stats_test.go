@@ -0,0 +1,48 @@
+package minhashlsh
+
+import "testing"
+
+func Test_BucketStats(t *testing.T) {
+	f := NewMinhashLSH16(64, 0.5, 0)
+	empty := make([]uint64, 64)
+	for i := 0; i < 10; i++ {
+		f.Add(i, randomSignature(64, int64(i)))
+		f.Add(-i-1, empty)
+	}
+	f.Index()
+	stats := f.BucketStats()
+	if len(stats) != f.L {
+		t.Fatalf("expected %d bands, got %d", f.L, len(stats))
+	}
+	for _, s := range stats {
+		if s.MaxSize != 10 || s.MaxHashKey != f.HashKeyFunc(empty[:f.K]) {
+			t.Errorf("band %d: expected the empty bucket of size 10, got %d", s.Band, s.MaxSize)
+		}
+		total := 0
+		for size, count := range s.Histogram {
+			total += size * count
+		}
+		if total != 20 {
+			t.Errorf("band %d: histogram counts %d entries", s.Band, total)
+		}
+	}
+}
+
+func Test_BucketCap(t *testing.T) {
+	f := NewMinhashLSH16(64, 0.5, 0, WithBucketCap(10))
+	sig := randomSignature(64, 1)
+	for i := 0; i < 100; i++ {
+		f.Add(i, sig)
+	}
+	f.Add(-1, randomSignature(64, 2))
+	f.Index()
+	if results := f.Query(sig); len(results) > 10 || len(results) == 0 {
+		t.Errorf("expected at most 10 candidates, got %d", len(results))
+	}
+	if f.CappedBuckets() != uint64(f.L) {
+		t.Errorf("expected %d capped buckets, got %d", f.L, f.CappedBuckets())
+	}
+	if !contains(f.Query(randomSignature(64, 2)), -1) {
+		t.Error("key of a small bucket missed")
+	}
+}
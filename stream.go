@@ -0,0 +1,57 @@
+package minhashlsh
+
+import "context"
+
+// Candidate is a candidate key of a query, with the first band in which
+// it collided with the query.
+type Candidate struct {
+	Key  interface{}
+	Band int
+}
+
+// QueryChan streams the candidate keys given the query signature while
+// the bands are scanned, each key once, instead of building the full set
+// of results first. The channel must be drained.
+func (f *MinhashLSH) QueryChan(sig []uint64) <-chan Candidate {
+	return f.QueryChanContext(context.Background(), sig)
+}
+
+// QueryChanContext is QueryChan stopping the scan and closing the channel
+// once ctx is done, so the channel need not be drained.
+func (f *MinhashLSH) QueryChanContext(ctx context.Context, sig []uint64) <-chan Candidate {
+	hashKeys := f.hashKeys(sig)
+	candidates := make(chan Candidate)
+	go func() {
+		defer close(candidates)
+		span := startSpan(f.tracer, ctx, "minhashlsh.QueryChan")
+		defer span.End()
+		seen := make(map[interface{}]struct{})
+		for i := range hashKeys {
+			bucket := f.bucket(i, hashKeys[i])
+			step := f.bucketCap.step(len(bucket))
+			for j := 0; j < len(bucket); j += step {
+				key := bucket[j].Key
+				if _, exist := seen[key]; exist || f.isHidden(key) {
+					continue
+				}
+				if ctx.Err() != nil {
+					return
+				}
+				seen[key] = struct{}{}
+				if !f.verified(sig, key) {
+					continue
+				}
+				if f.candidateCounts != nil {
+					f.candidateCounts.add(key)
+				}
+				select {
+				case candidates <- Candidate{key, i}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+		span.SetAttribute(attrCandidates, int64(len(seen)))
+	}()
+	return candidates
+}
@@ -0,0 +1,42 @@
+package minhashlsh
+
+import (
+	"context"
+	"testing"
+)
+
+func Test_QueryChan(t *testing.T) {
+	f := NewMinhashLSH16(64, 0.5, 0)
+	sig := randomSignature(64, 1)
+	for i := 0; i < 10; i++ {
+		f.Add(i, sig)
+	}
+	f.Add("other", randomSignature(64, 2))
+	f.Index()
+	seen := make(map[interface{}]bool)
+	for c := range f.QueryChan(sig) {
+		if seen[c.Key] {
+			t.Errorf("key %v streamed twice", c.Key)
+		}
+		seen[c.Key] = true
+		if c.Band != 0 {
+			t.Errorf("expected key %v to match in band 0, got %d", c.Key, c.Band)
+		}
+	}
+	if len(seen) != 10 {
+		t.Errorf("expected 10 candidates, got %d", len(seen))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	candidates := f.QueryChanContext(ctx, sig)
+	<-candidates
+	cancel()
+	n := 0
+	for range candidates {
+		n++
+	}
+	// The candidate being sent when ctx is done may still be received.
+	if n > 1 {
+		t.Errorf("expected the scan to stop, got %d more candidates", n)
+	}
+}
@@ -0,0 +1,56 @@
+package minhashlsh
+
+import (
+	"hash/fnv"
+	"math/rand"
+)
+
+// tabulationFamily is a family of simple tabulation hash functions, each
+// made of a table of random values per byte of the hashed value.
+type tabulationFamily struct {
+	tables [][8][256]uint64
+}
+
+// WithTabulationHashing computes the i-th hash value of a value by simple
+// tabulation hashing of its FNV-1a 64-bit hash: the XOR of the entries of
+// the 8 random tables of the i-th hash function indexed by its 8 bytes.
+// Tabulation hashing is 3-independent and gives MinHash estimates with
+// a provably small bias, unlike double hashing, at the cost of 16 KiB of
+// tables per hash function. The tables are drawn from
+// rand.NewSource(seed), each entry from two Uint32.
+func WithTabulationHashing() MinhashOption {
+	return func(c *minhashConfig) {
+		c.family = func(seed int64, numHash int) hashFamily {
+			r := rand.New(rand.NewSource(seed))
+			f := &tabulationFamily{tables: make([][8][256]uint64, numHash)}
+			for i := range f.tables {
+				for j := range f.tables[i] {
+					for k := range f.tables[i][j] {
+						f.tables[i][j][k] = uint64(r.Uint32())<<32 | uint64(r.Uint32())
+					}
+				}
+			}
+			return f
+		}
+	}
+}
+
+func (f *tabulationFamily) push(b []byte, mins []uint64) {
+	h := fnv.New64a()
+	h.Write(b)
+	x := h.Sum64()
+	for i := range mins {
+		t := &f.tables[i]
+		v := t[0][byte(x)] ^ t[1][byte(x>>8)] ^ t[2][byte(x>>16)] ^ t[3][byte(x>>24)] ^
+			t[4][byte(x>>32)] ^ t[5][byte(x>>40)] ^ t[6][byte(x>>48)] ^ t[7][byte(x>>56)]
+		if v < mins[i] {
+			mins[i] = v
+		}
+	}
+}
+
+// equal relies on Merge checking the seeds, from which the tables derive.
+func (f *tabulationFamily) equal(o hashFamily) bool {
+	g, ok := o.(*tabulationFamily)
+	return ok && len(f.tables) == len(g.tables)
+}
@@ -0,0 +1,35 @@
+package minhashlsh
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_TabulationHashing(t *testing.T) {
+	tokens := randomTokens(110, 1)
+	a, b := NewMinhash(1, 128, WithTabulationHashing()), NewMinhash(1, 128, WithTabulationHashing())
+	for i := 0; i < 100; i++ {
+		a.Push(tokens[i])
+		b.Push(tokens[i+10])
+	}
+	// The Jaccard similarity is 90/110.
+	if equal, n := countEqual(a.Signature(), b.Signature(), 1); float64(equal) < 0.7*float64(n) || float64(equal) > 0.95*float64(n) {
+		t.Errorf("similarity estimate %d/%d far from 0.82", equal, n)
+	}
+
+	union := NewMinhash(1, 128, WithTabulationHashing())
+	for i := 0; i < 110; i++ {
+		union.Push(tokens[i])
+	}
+	a.Merge(b)
+	if !reflect.DeepEqual(a.Signature(), union.Signature()) {
+		t.Error("merged signature differs from the signature of the union")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected merging different hash families to panic")
+		}
+	}()
+	a.Merge(NewMinhash(1, 128))
+}
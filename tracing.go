@@ -0,0 +1,67 @@
+package minhashlsh
+
+import "context"
+
+// Tracer creates spans for the operations of an index, so their latency
+// shows up in distributed traces. An OpenTelemetry adapter wraps
+// a trace.Tracer:
+//
+//	type otelTracer struct{ t trace.Tracer }
+//
+//	func (o otelTracer) Start(ctx context.Context, name string) (context.Context, minhashlsh.Span) {
+//		ctx, span := o.t.Start(ctx, name)
+//		return ctx, otelSpan{span}
+//	}
+//
+//	type otelSpan struct{ trace.Span }
+//
+//	func (s otelSpan) SetAttribute(key string, value int64) {
+//		s.SetAttributes(attribute.Int64(key, value))
+//	}
+//
+//	func (s otelSpan) End() { s.Span.End() }
+type Tracer interface {
+	Start(ctx context.Context, name string) (context.Context, Span)
+}
+
+// Span is a traced operation.
+type Span interface {
+	SetAttribute(key string, value int64)
+	End()
+}
+
+// Span attributes.
+const (
+	attrBandsProbed = "minhashlsh.bands_probed"
+	attrCandidates  = "minhashlsh.candidates"
+	attrKeys        = "minhashlsh.keys"
+	attrBands       = "minhashlsh.bands"
+)
+
+// WithTracer traces Add, Index, Query and Save of the index.
+func WithTracer(t Tracer) Option {
+	return func(f *MinhashLSH) {
+		f.tracer = t
+	}
+}
+
+// WithPersistTracer traces Save, Load, Encode and Decode, taking precedence
+// over the tracer of the index.
+func WithPersistTracer(t Tracer) PersistOption {
+	return func(c *persistConfig) {
+		c.tracer = t
+	}
+}
+
+type noopSpan struct{}
+
+func (noopSpan) SetAttribute(key string, value int64) {}
+func (noopSpan) End()                                 {}
+
+func startSpan(t Tracer, ctx context.Context, name string) Span {
+	if t == nil {
+		return noopSpan{}
+	}
+	_, span := t.Start(ctx, name)
+	return span
+}
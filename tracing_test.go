@@ -0,0 +1,48 @@
+package minhashlsh
+
+import (
+	"bytes"
+	"context"
+	"testing"
+)
+
+type testTracer struct {
+	spans map[string]map[string]int64
+}
+
+type testSpan struct {
+	attrs map[string]int64
+}
+
+func (t *testTracer) Start(ctx context.Context, name string) (context.Context, Span) {
+	span := testSpan{make(map[string]int64)}
+	t.spans[name] = span.attrs
+	return ctx, span
+}
+
+func (s testSpan) SetAttribute(key string, value int64) { s.attrs[key] = value }
+func (s testSpan) End()                                 {}
+
+func Test_Tracer(t *testing.T) {
+	tracer := &testTracer{make(map[string]map[string]int64)}
+	f := NewMinhashLSH16(64, 0.5, 2, WithTracer(tracer))
+	f.Add("sig1", randomSignature(64, 1))
+	f.Index()
+	f.Query(randomSignature(64, 1))
+	var buf bytes.Buffer
+	if err := f.Encode(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Decode(&buf, WithPersistTracer(tracer)); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"minhashlsh.Add", "minhashlsh.Index",
+		"minhashlsh.Query", "minhashlsh.Save", "minhashlsh.Load"} {
+		if _, ok := tracer.spans[name]; !ok {
+			t.Errorf("missing span %s", name)
+		}
+	}
+	if c := tracer.spans["minhashlsh.Query"][attrCandidates]; c != 1 {
+		t.Errorf("expected 1 candidate, got %d", c)
+	}
+}
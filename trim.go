@@ -0,0 +1,90 @@
+package minhashlsh
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+// TrimPolicy is how 64-bit hash values are trimmed to the hash value size
+// of indexes using 16 or 32-bit hash values.
+type TrimPolicy uint8
+
+// Trimming policies.
+const (
+	// TrimLowBits keeps the low bits of hash values.
+	TrimLowBits TrimPolicy = iota
+	// TrimHighBits keeps the high bits of hash values.
+	TrimHighBits
+	// TrimFold folds hash values by XORing their chunks of the hash
+	// value size together, so all bits contribute.
+	TrimFold
+)
+
+// WithTrimPolicy sets how hash values are trimmed, the low bits are kept by
+// default. Keeping the high bits or folding all bits avoids collisions from
+// upstream hash functions with weak low bits. The policy is saved with
+// the index and has no effect on 64 and 128-bit hash values.
+func WithTrimPolicy(policy TrimPolicy) Option {
+	return func(f *MinhashLSH) {
+		f.trim = policy
+		f.setHashKeyFunc()
+	}
+}
+
+// TrimPolicy returns the trimming policy of the index.
+func (f *MinhashLSH) TrimPolicy() TrimPolicy {
+	return f.trim
+}
+
+// trim returns the hash value v trimmed to size bytes, in its low bytes.
+func (p TrimPolicy) trim(v uint64, size int) uint64 {
+	bits := uint(8 * size)
+	if bits >= 64 {
+		return v
+	}
+	switch p {
+	case TrimHighBits:
+		return v >> (64 - bits)
+	case TrimFold:
+		folded := v
+		for shift := bits; shift < 64; shift += bits {
+			folded ^= v >> shift
+		}
+		return folded
+	}
+	return v
+}
+
+func (p TrimPolicy) valid() bool {
+	return p <= TrimFold
+}
+
+var errInvalidTrimPolicy = errors.New("invalid trim policy")
+
+// appendKeyFuncFor returns the function appending the hash keys of bands
+// of an index with the given salt and trimming policy.
+func appendKeyFuncFor(hashValueSize int, salt uint64, policy TrimPolicy) appendKeyFunc {
+	if salt == 0 && policy == TrimLowBits {
+		return appendKeyFuncGen(hashValueSize)
+	}
+	hashValueSize = wordSize(hashValueSize)
+	return func(dst []byte, sig []uint64) []byte {
+		var buf [8]byte
+		for i, v := range sig {
+			if salt != 0 {
+				// Mix each hash value with the salt and its position.
+				v = mix64(v ^ salt + uint64(i)*0x9e3779b97f4a7c15)
+			}
+			binary.LittleEndian.PutUint64(buf[:], policy.trim(v, hashValueSize))
+			dst = append(dst, buf[:hashValueSize]...)
+		}
+		return dst
+	}
+}
+
+// setHashKeyFunc sets the hash key functions of the index from its hash
+// value size, salt and trimming policy.
+func (f *MinhashLSH) setHashKeyFunc() {
+	f.appendKey = appendKeyFuncFor(f.HashValueSize, f.salt, f.trim)
+	f.HashKeyFunc = stringKeyFunc(f.appendKey)
+}
@@ -0,0 +1,56 @@
+package minhashlsh
+
+import (
+	"bytes"
+	"testing"
+)
+
+func Test_TrimPolicy(t *testing.T) {
+	tests := []struct {
+		policy TrimPolicy
+		want   uint64
+	}{
+		{TrimLowBits, 0x7788},
+		{TrimHighBits, 0x1122},
+		{TrimFold, 0x7788 ^ 0x5566 ^ 0x3344 ^ 0x1122},
+	}
+	for _, test := range tests {
+		if v := test.policy.trim(0x1122334455667788, 2); v&0xffff != test.want {
+			t.Errorf("policy %d: expected %x, got %x", test.policy, test.want, v&0xffff)
+		}
+	}
+
+	// Hash values differing only in their high bits.
+	sig1, sig2 := make([]uint64, 64), make([]uint64, 64)
+	for i := range sig2 {
+		sig2[i] = 1 << 60
+	}
+	if f := NewMinhashLSH16(64, 0.5, 0); f.HashKeyFunc(sig1) != f.HashKeyFunc(sig2) {
+		t.Fatal("expected the low bits to collide")
+	}
+	f := NewMinhashLSH16(64, 0.5, 0, WithTrimPolicy(TrimHighBits))
+	if f.HashKeyFunc(sig1) == f.HashKeyFunc(sig2) {
+		t.Error("expected the high bits not to collide")
+	}
+
+	for i := 0; i < 10; i++ {
+		f.Add(i, randomSignature(64, int64(i)))
+	}
+	f.Index()
+	var buf bytes.Buffer
+	if err := f.Encode(&buf); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := Decode(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.TrimPolicy() != TrimHighBits {
+		t.Errorf("expected the high bits policy, got %d", loaded.TrimPolicy())
+	}
+	for i := 0; i < 10; i++ {
+		if !contains(loaded.Query(randomSignature(64, int64(i))), i) {
+			t.Errorf("key %d not found in the loaded index", i)
+		}
+	}
+}
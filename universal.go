@@ -0,0 +1,104 @@
+package minhashlsh
+
+import (
+	"hash/fnv"
+	"math/bits"
+	"math/rand"
+)
+
+// UniversalPrime is the Mersenne prime 2^61-1 of the universal hash
+// functions of WithUniversalHashing.
+const UniversalPrime = 1<<61 - 1
+
+// universalFamily is the (a*x + b) mod prime family of hash functions.
+type universalFamily struct {
+	a, b []uint64
+}
+
+// WithUniversalHashing computes the i-th hash value of a value as
+// (a[i]*x + b[i]) mod UniversalPrime, x being the FNV-1a 64-bit hash of the
+// value reduced mod UniversalPrime, instead of the default double hashing.
+// a[i] in [1, UniversalPrime) and b[i] in [0, UniversalPrime) are drawn in
+// this order from rand.NewSource(seed).Int63, reduced mod UniversalPrime.
+// Signatures can be reproduced from the parameters alone, see
+// UniversalParams and NewUniversalMinhash.
+func WithUniversalHashing() MinhashOption {
+	return func(c *minhashConfig) {
+		c.family = func(seed int64, numHash int) hashFamily {
+			r := rand.New(rand.NewSource(seed))
+			f := &universalFamily{a: make([]uint64, numHash), b: make([]uint64, numHash)}
+			for i := range f.a {
+				f.a[i] = uint64(r.Int63())%(UniversalPrime-1) + 1
+				f.b[i] = uint64(r.Int63()) % UniversalPrime
+			}
+			return f
+		}
+	}
+}
+
+// NewUniversalMinhash returns a Minhash using the universal hash functions
+// of the given parameters, see WithUniversalHashing. They must be of the
+// same length, the number of hash functions, and below UniversalPrime.
+func NewUniversalMinhash(a, b []uint64) *Minhash {
+	if len(a) != len(b) {
+		panic("Universal hashing parameters of different lengths")
+	}
+	for i := range a {
+		if a[i] == 0 || a[i] >= UniversalPrime || b[i] >= UniversalPrime {
+			panic("Universal hashing parameters out of range")
+		}
+	}
+	f := &universalFamily{a: append([]uint64(nil), a...), b: append([]uint64(nil), b...)}
+	return newMinhashFamily(0, f, len(a))
+}
+
+// UniversalParams returns the parameters of the universal hash functions
+// of a Minhash created with WithUniversalHashing or NewUniversalMinhash.
+func (m *Minhash) UniversalParams() (a, b []uint64, ok bool) {
+	f, ok := m.family.(*universalFamily)
+	if !ok {
+		return nil, nil, false
+	}
+	return append([]uint64(nil), f.a...), append([]uint64(nil), f.b...), true
+}
+
+func (f *universalFamily) push(b []byte, mins []uint64) {
+	h := fnv.New64a()
+	h.Write(b)
+	x := mod61(h.Sum64())
+	for i := range mins {
+		if v := mulAddMod61(f.a[i], x, f.b[i]); v < mins[i] {
+			mins[i] = v
+		}
+	}
+}
+
+func (f *universalFamily) equal(o hashFamily) bool {
+	g, ok := o.(*universalFamily)
+	if !ok || len(f.a) != len(g.a) {
+		return false
+	}
+	for i := range f.a {
+		if f.a[i] != g.a[i] || f.b[i] != g.b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// mod61 reduces x mod 2^61-1.
+func mod61(x uint64) uint64 {
+	x = x&UniversalPrime + x>>61
+	if x >= UniversalPrime {
+		x -= UniversalPrime
+	}
+	return x
+}
+
+// mulAddMod61 returns (a*x + b) mod 2^61-1 for a, x and b below 2^61-1.
+func mulAddMod61(a, x, b uint64) uint64 {
+	hi, lo := bits.Mul64(a, x)
+	// hi*2^64 + lo = (hi<<3 | lo>>61)*2^61 + lo&(2^61-1), and 2^61 = 1.
+	r := lo&UniversalPrime + (hi<<3 | lo>>61)
+	return mod61(mod61(r) + b)
+}
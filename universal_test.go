@@ -0,0 +1,53 @@
+package minhashlsh
+
+import (
+	"math/big"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func Test_MulAddMod61(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	p := big.NewInt(UniversalPrime)
+	values := []uint64{0, 1, UniversalPrime - 1, UniversalPrime - 2}
+	for i := 0; i < 1000; i++ {
+		values = append(values, uint64(r.Int63())%UniversalPrime)
+	}
+	for i := 0; i+2 < len(values); i++ {
+		a, x, b := values[i], values[i+1], values[i+2]
+		expected := new(big.Int).Mul(new(big.Int).SetUint64(a), new(big.Int).SetUint64(x))
+		expected.Add(expected, new(big.Int).SetUint64(b)).Mod(expected, p)
+		if v := mulAddMod61(a, x, b); v != expected.Uint64() {
+			t.Fatalf("(%d*%d + %d) mod p: expected %s, got %d", a, x, b, expected, v)
+		}
+	}
+}
+
+func Test_UniversalHashing(t *testing.T) {
+	tokens := randomTokens(110, 1)
+	a, b := NewMinhash(1, 128, WithUniversalHashing()), NewMinhash(1, 128, WithUniversalHashing())
+	for i := 0; i < 100; i++ {
+		a.Push(tokens[i])
+		b.Push(tokens[i+10])
+	}
+	// The Jaccard similarity is 90/110.
+	if equal, n := countEqual(a.Signature(), b.Signature(), 1); float64(equal) < 0.7*float64(n) || float64(equal) > 0.95*float64(n) {
+		t.Errorf("similarity estimate %d/%d far from 0.82", equal, n)
+	}
+
+	params1, params2, ok := a.UniversalParams()
+	if !ok {
+		t.Fatal("expected universal hashing parameters")
+	}
+	c := NewUniversalMinhash(params1, params2)
+	for i := 0; i < 100; i++ {
+		c.Push(tokens[i])
+	}
+	if !reflect.DeepEqual(c.Signature(), a.Signature()) {
+		t.Error("signature not reproduced from the parameters")
+	}
+	if _, _, ok := NewMinhash(1, 128).UniversalParams(); ok {
+		t.Error("unexpected universal hashing parameters of the default hash functions")
+	}
+}
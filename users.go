@@ -0,0 +1,70 @@
+package minhashlsh
+
+import "sort"
+
+// SimilarUser is a user with the estimated Jaccard similarity of their
+// items with the ones of another user.
+type SimilarUser struct {
+	User       interface{}
+	Similarity float64
+}
+
+type similarUsers []SimilarUser
+
+func (s similarUsers) Len() int           { return len(s) }
+func (s similarUsers) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s similarUsers) Less(i, j int) bool { return s[i].Similarity > s[j].Similarity }
+
+// UserIndex generates candidates for collaborative filtering: users are
+// sketched as the sets of the IDs of the items they interacted with, and
+// the users similar to a user are found among the candidates of the index,
+// ranked by the similarity estimated from their signatures.
+type UserIndex struct {
+	set *SetIndex
+}
+
+// NewUserIndex creates an index of users sketched by Minhash with the given
+// seed and number of hash functions. Users below the threshold are rarely
+// found.
+func NewUserIndex(seed int64, numHash int, threshold float64, opts ...Option) *UserIndex {
+	opts = append(opts[:len(opts):len(opts)], WithSignatureStorage())
+	return &UserIndex{NewSetIndex(seed, numHash, threshold, 0, opts...)}
+}
+
+// AddUser adds a user with the IDs of their items.
+// The user won't be searchable until Index is called.
+func (u *UserIndex) AddUser(user interface{}, items []string) {
+	tokens := make([][]byte, len(items))
+	for i, item := range items {
+		tokens[i] = []byte(item)
+	}
+	u.set.LSH().Add(user, u.set.Signature(tokens))
+}
+
+// Index makes all the users added searchable.
+func (u *UserIndex) Index() {
+	u.set.Index()
+}
+
+// SimilarUsers returns at most k indexed users most similar to a user, by
+// decreasing estimated similarity, or false if the user was not added.
+func (u *UserIndex) SimilarUsers(user interface{}, k int) ([]SimilarUser, bool) {
+	lsh := u.set.LSH()
+	sig, exist := lsh.Signature(user)
+	if !exist {
+		return nil, false
+	}
+	results := make(similarUsers, 0)
+	for _, key := range lsh.query(sig).keys {
+		if key == user {
+			continue
+		}
+		equal, n := countEqual(sig, lsh.signatures[key], 1)
+		results = append(results, SimilarUser{key, float64(equal) / float64(n)})
+	}
+	sort.Stable(results)
+	if len(results) > k {
+		results = results[:k]
+	}
+	return results, true
+}
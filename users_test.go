@@ -0,0 +1,39 @@
+package minhashlsh
+
+import (
+	"strconv"
+	"testing"
+)
+
+func Test_UserIndex(t *testing.T) {
+	u := NewUserIndex(1, 128, 0.3)
+	items := func(from, to int) []string {
+		var ids []string
+		for i := from; i < to; i++ {
+			ids = append(ids, strconv.FormatUint(mix64(uint64(i)), 16))
+		}
+		return ids
+	}
+	u.AddUser("alice", items(0, 100))
+	u.AddUser("bob", items(10, 100))
+	u.AddUser("carol", items(40, 140))
+	u.AddUser("dave", items(500, 600))
+	u.Index()
+	similar, ok := u.SimilarUsers("alice", 5)
+	if !ok {
+		t.Fatal("alice not found")
+	}
+	if len(similar) != 2 || similar[0].User != "bob" || similar[1].User != "carol" {
+		t.Fatalf("expected bob then carol, got %v", similar)
+	}
+	// The Jaccard similarity of alice and bob is 0.9.
+	if s := similar[0].Similarity; s < 0.8 || s > 1 {
+		t.Errorf("expected a similarity of about 0.9, got %f", s)
+	}
+	if similar, _ := u.SimilarUsers("alice", 1); len(similar) != 1 {
+		t.Errorf("expected a single user, got %v", similar)
+	}
+	if _, ok := u.SimilarUsers("eve", 5); ok {
+		t.Error("unknown user found")
+	}
+}
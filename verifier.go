@@ -0,0 +1,74 @@
+package minhashlsh
+
+import "context"
+
+// Verifier checks the candidates of a query before they are returned,
+// removing the false positives of LSH with an exact measure such as the
+// Jaccard similarity or the edit distance of the original items, or with
+// business rules. Verify reports whether the candidate key is kept for the
+// query signature.
+type Verifier interface {
+	Verify(sig []uint64, key interface{}) bool
+}
+
+// VerifierFunc adapts a function to a Verifier.
+type VerifierFunc func(sig []uint64, key interface{}) bool
+
+// Verify calls fn(sig, key).
+func (fn VerifierFunc) Verify(sig []uint64, key interface{}) bool {
+	return fn(sig, key)
+}
+
+// JaccardVerifier keeps the candidates whose exact Jaccard similarity with
+// the query set is at least threshold, as FilterExact. The sets are sorted
+// and deduplicated slices as returned by HashSet, set returns the one of
+// a candidate key. The verifier is specific to the query set, see
+// QueryVerified.
+func JaccardVerifier(query []uint64, set func(key interface{}) []uint64, threshold float64) Verifier {
+	return VerifierFunc(func(sig []uint64, key interface{}) bool {
+		return JaccardSorted(query, set(key)) >= threshold
+	})
+}
+
+// WithVerifier verifies the candidates returned by the queries of the
+// index, such as Query, QueryBands, QueryUpTo, QueryChan or QueryIndex.
+// Contains and Count report band collisions, before verification.
+func WithVerifier(v Verifier) Option {
+	return func(f *MinhashLSH) {
+		f.verifier = v
+	}
+}
+
+// QueryVerified returns the candidate keys given the query signature kept
+// by the verifier, which takes precedence over the verifier of the index.
+func (f *MinhashLSH) QueryVerified(sig []uint64, v Verifier) []interface{} {
+	return f.queryContext(context.Background(), sig, v)
+}
+
+// verified reports whether the verifier of the index, if any, keeps the
+// candidate key of the query signature.
+func (f *MinhashLSH) verified(sig []uint64, key interface{}) bool {
+	return f.verifier == nil || f.verifier.Verify(sig, key)
+}
+
+// queryVerified returns the candidate keys given the query signature kept
+// by the verifier of the index, if any.
+func (f *MinhashLSH) queryVerified(sig []uint64) []interface{} {
+	keys := f.query(sig).keys
+	if f.verifier != nil {
+		keys = verify(f.verifier, sig, keys)
+	}
+	return keys
+}
+
+// verify returns the candidates kept by the verifier, in a new slice as
+// the candidates may be cached.
+func verify(v Verifier, sig []uint64, candidates []interface{}) []interface{} {
+	results := make([]interface{}, 0, len(candidates))
+	for _, key := range candidates {
+		if v.Verify(sig, key) {
+			results = append(results, key)
+		}
+	}
+	return results
+}
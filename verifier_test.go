@@ -0,0 +1,67 @@
+package minhashlsh
+
+import "testing"
+
+func Test_Verifier(t *testing.T) {
+	sig := randomSignature(64, 1)
+	odd := VerifierFunc(func(sig []uint64, key interface{}) bool { return key.(int)%2 == 1 })
+	f := NewMinhashLSH16(64, 0.5, 0, WithVerifier(odd), WithQueryCache(10))
+	for i := 0; i < 4; i++ {
+		f.Add(i, sig)
+	}
+	f.Index()
+	for i := 0; i < 2; i++ {
+		if results := f.Query(sig); len(results) != 2 || contains(results, 0) || contains(results, 2) {
+			t.Errorf("expected the odd keys, got %v", results)
+		}
+	}
+	even := VerifierFunc(func(sig []uint64, key interface{}) bool { return key.(int)%2 == 0 })
+	if results := f.QueryVerified(sig, even); len(results) != 2 || !contains(results, 0) || !contains(results, 2) {
+		t.Errorf("expected the even keys, got %v", results)
+	}
+}
+
+func Test_VerifierQueries(t *testing.T) {
+	sig := randomSignature(64, 1)
+	odd := VerifierFunc(func(sig []uint64, key interface{}) bool { return key.(int)%2 == 1 })
+	f := NewMinhashLSH16(64, 0.5, 0, WithVerifier(odd))
+	for i := 0; i < 4; i++ {
+		f.Add(i, sig)
+	}
+	f.Index()
+	check := func(name string, results []interface{}) {
+		if len(results) != 2 || contains(results, 0) || contains(results, 2) {
+			t.Errorf("%s: expected the odd keys, got %v", name, results)
+		}
+	}
+	check("QueryBands", f.QueryBands(sig, 2))
+	check("QueryUpTo", f.QueryUpTo(sig, 10))
+	check("QueryPrepared", f.QueryPrepared(f.Prepare(sig)))
+	check("Similar", f.Similar(sig).Run())
+	results, _ := f.QueryProfile(sig)
+	check("QueryProfile", results)
+	var streamed, explained []interface{}
+	for c := range f.QueryChan(sig) {
+		streamed = append(streamed, c.Key)
+	}
+	check("QueryChan", streamed)
+	for _, e := range f.QueryExplain(sig) {
+		explained = append(explained, e.Key)
+	}
+	check("QueryExplain", explained)
+	if upTo := f.QueryUpTo(sig, 1); len(upTo) != 1 || upTo[0].(int)%2 != 1 {
+		t.Errorf("expected an odd key, got %v", upTo)
+	}
+}
+
+func Test_JaccardVerifier(t *testing.T) {
+	sets := map[interface{}][]uint64{
+		"similar":   HashSet(tokens("a", "b", "c", "d")),
+		"different": HashSet(tokens("a", "x", "y", "z")),
+	}
+	v := JaccardVerifier(HashSet(tokens("a", "b", "c")),
+		func(key interface{}) []uint64 { return sets[key] }, 0.5)
+	if !v.Verify(nil, "similar") || v.Verify(nil, "different") {
+		t.Error("unexpected verification")
+	}
+}
@@ -0,0 +1,43 @@
+package minhashlsh
+
+import "fmt"
+
+// Verify checks the invariants of the index: one hash table per band,
+// tables of the same length, hash keys of K*HashValueSize bytes, and
+// the first NumIndexedKeys entries of every table sorted by hash key.
+// It loads all bands of a lazily loaded index. Violations, such as from
+// a custom backend or a modified HashTables, are reported as
+// a *CorruptIndexError describing the first one found.
+func (f *MinhashLSH) Verify() error {
+	if f.K <= 0 || f.L <= 0 || f.HashValueSize <= 0 {
+		return &CorruptIndexError{fmt.Sprintf("invalid parameters K=%d L=%d HashValueSize=%d",
+			f.K, f.L, f.HashValueSize)}
+	}
+	if len(f.HashTables) != f.L {
+		return &CorruptIndexError{fmt.Sprintf("%d hash tables for %d bands", len(f.HashTables), f.L)}
+	}
+	if err := f.Materialize(); err != nil {
+		return err
+	}
+	width := f.K * f.HashValueSize
+	for i, table := range f.HashTables {
+		if len(table) != len(f.HashTables[0]) {
+			return &CorruptIndexError{fmt.Sprintf("band %d has %d entries, band 0 has %d",
+				i, len(table), len(f.HashTables[0]))}
+		}
+		if f.NumIndexedKeys < 0 || f.NumIndexedKeys > len(table) {
+			return &CorruptIndexError{fmt.Sprintf("%d indexed keys for %d entries",
+				f.NumIndexedKeys, len(table))}
+		}
+		for j, e := range table {
+			if len(e.HashKey) != width {
+				return &CorruptIndexError{fmt.Sprintf("entry %d of band %d has a hash key of %d bytes, expected %d",
+					j, i, len(e.HashKey), width)}
+			}
+			if j > 0 && j < f.NumIndexedKeys && e.HashKey < table[j-1].HashKey {
+				return &CorruptIndexError{fmt.Sprintf("entry %d of band %d is not sorted", j, i)}
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package minhashlsh
+
+import (
+	"strings"
+	"testing"
+)
+
+func Test_Verify(t *testing.T) {
+	tests := []struct {
+		name   string
+		damage func(f *MinhashLSH)
+		reason string
+	}{
+		{"valid", func(f *MinhashLSH) {}, ""},
+		{"tables", func(f *MinhashLSH) { f.HashTables = f.HashTables[1:] }, "hash tables"},
+		{"length", func(f *MinhashLSH) { f.HashTables[1] = f.HashTables[1][1:] }, "band 1 has"},
+		{"indexed", func(f *MinhashLSH) { f.NumIndexedKeys = 11 }, "indexed keys"},
+		{"width", func(f *MinhashLSH) { f.HashTables[0][3].HashKey = "x" }, "hash key of 1 bytes"},
+		{"sorted", func(f *MinhashLSH) {
+			table := f.HashTables[2]
+			table[0], table[9] = table[9], table[0]
+		}, "not sorted"},
+	}
+	for _, test := range tests {
+		f := testIndex(10)
+		test.damage(f)
+		err := f.Verify()
+		if test.reason == "" {
+			if err != nil {
+				t.Errorf("%s: unexpected error %v", test.name, err)
+			}
+			continue
+		}
+		if _, ok := err.(*CorruptIndexError); !ok || !strings.Contains(err.Error(), test.reason) {
+			t.Errorf("%s: expected an error about %q, got %v", test.name, test.reason, err)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package minhashlsh
+
+import "encoding/gob"
+
+// VersionedKey is the key of a version of a logical key.
+type VersionedKey struct {
+	Key     interface{}
+	Version int64
+}
+
+func init() {
+	gob.Register(VersionedKey{})
+}
+
+// AddVersion adds a version of a logical key with its signature, so several
+// revisions of a document can be indexed. It is Add with a VersionedKey.
+func (f *MinhashLSH) AddVersion(key interface{}, version int64, sig []uint64) {
+	f.Add(VersionedKey{key, version}, sig)
+	f.versioned = true
+	if f.latestVersions != nil {
+		if latest, exist := f.latestVersions[key]; !exist || version > latest {
+			f.latestVersions[key] = version
+		}
+	}
+}
+
+// QueryVersions returns the candidate versions of logical keys given the
+// query signature, including all the versions of each key.
+// Keys not added by AddVersion are skipped.
+func (f *MinhashLSH) QueryVersions(sig []uint64) []VersionedKey {
+	results := make([]VersionedKey, 0)
+	for _, key := range f.queryVerified(sig) {
+		if v, ok := key.(VersionedKey); ok {
+			results = append(results, v)
+		}
+	}
+	return results
+}
+
+// QueryLatest returns the candidate versions given the query signature,
+// only keeping the versions that are the latest one of their logical key.
+// A version added but not indexed yet hides the older ones.
+func (f *MinhashLSH) QueryLatest(sig []uint64) []VersionedKey {
+	latest := f.latest()
+	results := make([]VersionedKey, 0)
+	for _, v := range f.QueryVersions(sig) {
+		if latest[v.Key] == v.Version {
+			results = append(results, v)
+		}
+	}
+	return results
+}
+
+// latest returns the latest version of each logical key. It does not
+// modify the index, so queries can run concurrently: unless they are
+// known already, as after Index, the versions are found again.
+func (f *MinhashLSH) latest() map[interface{}]int64 {
+	if f.latestVersions != nil {
+		return f.latestVersions
+	}
+	return f.findLatest()
+}
+
+// findLatest finds the latest version of each logical key in the hash
+// table of the first band.
+func (f *MinhashLSH) findLatest() map[interface{}]int64 {
+	latest := make(map[interface{}]int64)
+	if f.L > 0 {
+		for j, e := range f.table(0) {
+			v, ok := e.Key.(VersionedKey)
+			if !ok || f.isHiddenAt(e.Key, j) {
+				continue
+			}
+			if version, exist := latest[v.Key]; !exist || v.Version > version {
+				latest[v.Key] = v.Version
+			}
+		}
+	}
+	return latest
+}
@@ -0,0 +1,70 @@
+package minhashlsh
+
+import (
+	"bytes"
+	"sync"
+	"testing"
+)
+
+func Test_Versions(t *testing.T) {
+	f := NewMinhashLSH16(64, 0.5, 0)
+	sig := randomSignature(64, 1)
+	f.Add("other", randomSignature(64, 1))
+	f.AddVersion("doc", 1, sig)
+	f.AddVersion("doc", 3, sig)
+	f.AddVersion("doc", 2, sig)
+	f.Index()
+	if versions := f.QueryVersions(sig); len(versions) != 3 {
+		t.Errorf("expected 3 versions, got %v", versions)
+	}
+	latest := f.QueryLatest(sig)
+	if len(latest) != 1 || latest[0] != (VersionedKey{"doc", 3}) {
+		t.Errorf("expected version 3, got %v", latest)
+	}
+
+	f.Remove(VersionedKey{"doc", 3})
+	latest = f.QueryLatest(sig)
+	if len(latest) != 1 || latest[0] != (VersionedKey{"doc", 2}) {
+		t.Errorf("expected version 2 after removing version 3, got %v", latest)
+	}
+
+	var buf bytes.Buffer
+	if err := f.Encode(&buf); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := Decode(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	latest = loaded.QueryLatest(sig)
+	if len(latest) != 1 || latest[0] != (VersionedKey{"doc", 2}) {
+		t.Errorf("expected version 2 after loading, got %v", latest)
+	}
+}
+
+func Test_QueryLatestConcurrent(t *testing.T) {
+	f := NewMinhashLSH16(64, 0.5, 0)
+	sig := randomSignature(64, 1)
+	f.AddVersion("doc", 1, sig)
+	f.AddVersion("doc", 2, sig)
+	f.Index()
+	f.Remove(VersionedKey{"doc", 2})
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if latest := f.QueryLatest(sig); len(latest) != 1 || latest[0].Version != 1 {
+				t.Errorf("expected version 1, got %v", latest)
+			}
+		}()
+	}
+	wg.Wait()
+	if f.latestVersions != nil {
+		t.Error("latest versions stored by a query")
+	}
+	f.Index()
+	if f.latestVersions == nil || f.latestVersions["doc"] != 1 {
+		t.Errorf("expected the latest versions found by Index, got %v", f.latestVersions)
+	}
+}
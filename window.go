@@ -0,0 +1,108 @@
+package minhashlsh
+
+import "time"
+
+// Window is an index only retaining keys added during a sliding window of
+// time, such as for deduplicating news or alerts. It is divided into slots,
+// each an index of the keys added during a fraction of the window; a slot is
+// evicted as a whole once it is older than the window, so keys are retained
+// for at least the window size and at most one slot duration longer.
+type Window struct {
+	size     time.Duration
+	span     time.Duration
+	newIndex func() *MinhashLSH
+	// The slots from the oldest to the newest.
+	slots []*windowSlot
+}
+
+type windowSlot struct {
+	start time.Time
+	lsh   *MinhashLSH
+	dirty bool
+}
+
+// NewWindow creates a window of the given size divided in numSlots slots,
+// each an index created by newIndex.
+func NewWindow(size time.Duration, numSlots int, newIndex func() *MinhashLSH) *Window {
+	if numSlots <= 0 {
+		numSlots = 1
+	}
+	return &Window{
+		size:     size,
+		span:     size / time.Duration(numSlots),
+		newIndex: newIndex,
+	}
+}
+
+// slot returns the slot holding keys added at t, creating it if needed,
+// or nil if keys added at t are already evicted.
+func (w *Window) slot(t time.Time) *windowSlot {
+	start := t.Truncate(w.span)
+	i := len(w.slots)
+	for i > 0 && w.slots[i-1].start.After(start) {
+		i--
+	}
+	if i > 0 && w.slots[i-1].start.Equal(start) {
+		return w.slots[i-1]
+	}
+	if i == 0 && len(w.slots) > 0 && w.expired(start, w.slots[len(w.slots)-1].start) {
+		return nil
+	}
+	s := &windowSlot{start: start, lsh: w.newIndex()}
+	w.slots = append(w.slots, nil)
+	copy(w.slots[i+1:], w.slots[i:])
+	w.slots[i] = s
+	return s
+}
+
+// expired reports whether the slot starting at start is out of the window
+// ending at now.
+func (w *Window) expired(start, now time.Time) bool {
+	return !start.Add(w.span).After(now.Add(-w.size))
+}
+
+// Add a key with its signature at time t. The key won't be searchable
+// until Index is called. Keys added at times already evicted are ignored.
+func (w *Window) Add(key interface{}, sig []uint64, t time.Time) {
+	if s := w.slot(t); s != nil {
+		s.lsh.Add(key, sig)
+		s.dirty = true
+	}
+}
+
+// Index makes all the keys added searchable.
+func (w *Window) Index() {
+	for _, s := range w.slots {
+		if s.dirty {
+			s.lsh.Index()
+			s.dirty = false
+		}
+	}
+}
+
+// Expire evicts the slots older than the window ending at now.
+func (w *Window) Expire(now time.Time) {
+	i := 0
+	for i < len(w.slots) && w.expired(w.slots[i].start, now) {
+		w.slots[i] = nil
+		i++
+	}
+	w.slots = w.slots[i:]
+}
+
+// Query evicts the slots older than the window ending at now, and returns
+// the candidate keys given the query signature among those retained.
+func (w *Window) Query(sig []uint64, now time.Time) []interface{} {
+	w.Expire(now)
+	results := make([]interface{}, 0)
+	seen := make(map[interface{}]bool)
+	for _, s := range w.slots {
+		for _, key := range s.lsh.query(sig).keys {
+			if !seen[key] {
+				seen[key] = true
+				results = append(results, key)
+			}
+		}
+	}
+	return results
+}
@@ -0,0 +1,34 @@
+package minhashlsh
+
+import (
+	"testing"
+	"time"
+)
+
+func Test_Window(t *testing.T) {
+	w := NewWindow(time.Hour, 4, func() *MinhashLSH {
+		return NewMinhashLSH16(64, 0.5, 0)
+	})
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 12; i++ {
+		w.Add(i, randomSignature(64, int64(i)), start.Add(time.Duration(i)*10*time.Minute))
+	}
+	w.Index()
+	now := start.Add(2 * time.Hour)
+	for i := 0; i < 12; i++ {
+		added := start.Add(time.Duration(i) * 10 * time.Minute)
+		found := false
+		for _, key := range w.Query(randomSignature(64, int64(i)), now) {
+			found = found || key == i
+		}
+		slotEnd := added.Truncate(15 * time.Minute).Add(15 * time.Minute)
+		if retained := slotEnd.After(now.Add(-time.Hour)); found != retained {
+			t.Errorf("key %d added %v before found: %v", i, now.Sub(added), found)
+		}
+	}
+	numSlots := len(w.slots)
+	w.Add(100, randomSignature(64, 100), start)
+	if len(w.slots) != numSlots {
+		t.Errorf("key added at an evicted time created a slot")
+	}
+}
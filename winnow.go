@@ -0,0 +1,68 @@
+package minhashlsh
+
+import "encoding/binary"
+
+// Fingerprint is a shingle hash selected by winnowing, with the offset of
+// its shingle in the data.
+type Fingerprint struct {
+	Hash uint64
+	Pos  int
+}
+
+// Winnow selects fingerprints of data by winnowing (Schleimer, Wilkerson
+// and Aiken, 2003), as done by MOSS: the minimum hash of each window of w
+// consecutive k-byte shingles is selected, keeping the one selected for the
+// previous window on ties. Any substring of at least w+k-1 bytes shared by
+// two documents shares a fingerprint, wherever it is in the documents,
+// while only about 2/(w+1) of the shingles are selected.
+// Data shorter than w+k-1 bytes, but not than k, has a single window.
+func Winnow(data []byte, k, w int) []Fingerprint {
+	hashes := ShingleHashes(data, k)
+	if len(hashes) == 0 || w <= 0 {
+		return nil
+	}
+	if w > len(hashes) {
+		w = len(hashes)
+	}
+	fingerprints := make([]Fingerprint, 0, 2*len(hashes)/(w+1)+1)
+	// window holds the positions of the candidates for the minimum of the
+	// current window and later ones, of non-decreasing hashes.
+	window := make([]int, 0, w)
+	selected := -1
+	for i, h := range hashes {
+		for len(window) > 0 && hashes[window[len(window)-1]] > h {
+			window = window[:len(window)-1]
+		}
+		window = append(window, i)
+		if window[0] <= i-w {
+			window = window[1:]
+		}
+		if i >= w-1 && window[0] != selected {
+			selected = window[0]
+			fingerprints = append(fingerprints, Fingerprint{hashes[selected], selected})
+		}
+	}
+	return fingerprints
+}
+
+// PushWinnowed pushes the fingerprints selected by Winnow to the MinHash
+// instead of all the shingles of data, so documents sharing passages
+// anywhere in them get similar signatures, as looked for by plagiarism
+// detection.
+func (m *Minhash) PushWinnowed(data []byte, k, w int) {
+	var b [8]byte
+	for _, fp := range Winnow(data, k, w) {
+		binary.LittleEndian.PutUint64(b[:], fp.Hash)
+		m.Push(b[:])
+	}
+}
+
+// PushWinnowed pushes the fingerprints selected by Winnow to the MinHash,
+// see Minhash.PushWinnowed.
+func (m *Minhash128) PushWinnowed(data []byte, k, w int) {
+	var b [8]byte
+	for _, fp := range Winnow(data, k, w) {
+		binary.LittleEndian.PutUint64(b[:], fp.Hash)
+		m.Push(b[:])
+	}
+}
@@ -0,0 +1,84 @@
+package minhashlsh
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func randomText(n int, seed int64) []byte {
+	r := rand.New(rand.NewSource(seed))
+	data := make([]byte, n)
+	for i := range data {
+		data[i] = byte('a' + r.Intn(26))
+	}
+	return data
+}
+
+func Test_Winnow(t *testing.T) {
+	k, w := 5, 8
+	data := randomText(2000, 1)
+	hashes := ShingleHashes(data, k)
+	fingerprints := Winnow(data, k, w)
+	// Every window has its minimum selected.
+	for i := 0; i+w <= len(hashes); i++ {
+		min := hashes[i]
+		for _, h := range hashes[i : i+w] {
+			if h < min {
+				min = h
+			}
+		}
+		found := false
+		for _, fp := range fingerprints {
+			if fp.Pos >= i && fp.Pos < i+w && fp.Hash == min {
+				found = true
+			}
+		}
+		if !found {
+			t.Fatalf("minimum of window %d not selected", i)
+		}
+	}
+	if density := float64(len(fingerprints)) / float64(len(hashes)); density > 0.3 {
+		t.Errorf("expected a density of about %.2f, got %.2f", 2/float64(w+1), density)
+	}
+
+	// A passage of w+k-1 bytes shares a fingerprint wherever it is.
+	passage := data[1000 : 1000+w+k-1]
+	other := append(append(randomText(333, 2), passage...), randomText(100, 3)...)
+	shared := false
+	for _, a := range fingerprints {
+		for _, b := range Winnow(other, k, w) {
+			shared = shared || a.Hash == b.Hash
+		}
+	}
+	if !shared {
+		t.Error("expected a shared fingerprint")
+	}
+	if len(Winnow(data[:k+1], k, w)) != 1 {
+		t.Error("expected a single fingerprint for short data")
+	}
+}
+
+func Test_PushWinnowed(t *testing.T) {
+	lsh := NewMinhashLSH16(128, 0.5, 0)
+	sketch := func(data []byte) []uint64 {
+		mh := NewMinhash(1, 128)
+		mh.PushWinnowed(data, 8, 16)
+		return mh.Signature()
+	}
+	original := randomText(3000, 1)
+	for i := 0; i < 10; i++ {
+		lsh.Add(i, sketch(randomText(3000, int64(i+2))))
+	}
+	lsh.Add("original", sketch(original))
+	lsh.Index()
+	// The plagiarized document moves the paragraphs of the original around
+	// and rewrites some.
+	var plagiarized []byte
+	plagiarized = append(plagiarized, original[2000:]...)
+	plagiarized = append(plagiarized, randomText(300, 20)...)
+	plagiarized = append(plagiarized, original[:1800]...)
+	results := lsh.Query(sketch(plagiarized))
+	if len(results) != 1 || results[0] != "original" {
+		t.Errorf("expected the original document, got %v", results)
+	}
+}